@@ -0,0 +1,435 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ConversationMessage 存储对话中的一条消息
+type ConversationMessage struct {
+	Role      string `json:"role"`
+	Content   string `json:"content"`
+	Provider  string `json:"provider,omitempty"`
+	CreatedAt string `json:"createdAt"`
+	// 角色为 tool 时记录调用的工具和参数，Content 为工具结果
+	ToolName      string `json:"toolName,omitempty"`
+	ToolArguments string `json:"toolArguments,omitempty"`
+}
+
+// Conversation 存储一个对话会话及其消息历史
+type Conversation struct {
+	ID         string                `json:"id"`
+	Title      string                `json:"title"`
+	ProviderID string                `json:"providerId"`
+	CreatedAt  string                `json:"createdAt"`
+	UpdatedAt  string                `json:"updatedAt"`
+	Messages   []ConversationMessage `json:"messages"`
+}
+
+// ConversationSummary 会话列表中显示的摘要信息
+type ConversationSummary struct {
+	ID           string `json:"id"`
+	Title        string `json:"title"`
+	ProviderID   string `json:"providerId"`
+	UpdatedAt    string `json:"updatedAt"`
+	MessageCount int    `json:"messageCount"`
+}
+
+// aiResponseTokenReserve 提供商没有设置最大回复token数时，裁剪历史为模型回复预留的token数
+const aiResponseTokenReserve = 1024
+
+// getConversationsDir 获取会话存储目录
+func (a *App) getConversationsDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "conversations"
+	}
+	dir := filepath.Join(homeDir, ".networ_tester", "conversations")
+
+	// 确保目录存在
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		os.MkdirAll(dir, 0700)
+	}
+
+	return dir
+}
+
+// conversationPath 返回会话文件路径，拒绝包含路径分隔符的ID
+func (a *App) conversationPath(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", fmt.Errorf("无效的会话ID: %s", id)
+	}
+	return filepath.Join(a.getConversationsDir(), id+".json"), nil
+}
+
+// loadConversation 读取会话文件
+func (a *App) loadConversation(id string) (Conversation, error) {
+	path, err := a.conversationPath(id)
+	if err != nil {
+		return Conversation{}, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Conversation{}, fmt.Errorf("未找到会话: %s", id)
+		}
+		return Conversation{}, err
+	}
+
+	var conversation Conversation
+	if err := json.Unmarshal(data, &conversation); err != nil {
+		return Conversation{}, fmt.Errorf("会话文件已损坏: %v", err)
+	}
+
+	return conversation, nil
+}
+
+// saveConversation 写入会话文件
+func (a *App) saveConversation(conversation Conversation) error {
+	path, err := a.conversationPath(conversation.ID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(conversation, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0600)
+}
+
+// CreateConversation 创建新的对话会话
+func (a *App) CreateConversation(title string, providerID string) (Conversation, error) {
+	now := time.Now().Format(time.RFC3339)
+	if strings.TrimSpace(title) == "" {
+		title = "新对话 " + time.Now().Format("2006-01-02 15:04")
+	}
+
+	conversation := Conversation{
+		ID:         fmt.Sprintf("%x", time.Now().UnixNano()),
+		Title:      title,
+		ProviderID: providerID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Messages:   []ConversationMessage{},
+	}
+
+	a.convMu.Lock()
+	defer a.convMu.Unlock()
+
+	return conversation, a.saveConversation(conversation)
+}
+
+// GetConversation 获取会话的完整消息历史
+func (a *App) GetConversation(id string) (Conversation, error) {
+	a.convMu.Lock()
+	defer a.convMu.Unlock()
+
+	return a.loadConversation(id)
+}
+
+// ListConversations 列出所有会话，最近更新的排在前面
+func (a *App) ListConversations() []ConversationSummary {
+	return a.SearchConversations("")
+}
+
+// SearchConversations 按标题和消息内容搜索会话
+func (a *App) SearchConversations(keyword string) []ConversationSummary {
+	a.convMu.Lock()
+	defer a.convMu.Unlock()
+
+	summaries := []ConversationSummary{}
+	keyword = strings.ToLower(strings.TrimSpace(keyword))
+
+	files, _ := filepath.Glob(filepath.Join(a.getConversationsDir(), "*.json"))
+	for _, file := range files {
+		id := strings.TrimSuffix(filepath.Base(file), ".json")
+		conversation, err := a.loadConversation(id)
+		if err != nil {
+			continue
+		}
+
+		if keyword != "" && !conversationMatches(conversation, keyword) {
+			continue
+		}
+
+		summaries = append(summaries, ConversationSummary{
+			ID:           conversation.ID,
+			Title:        conversation.Title,
+			ProviderID:   conversation.ProviderID,
+			UpdatedAt:    conversation.UpdatedAt,
+			MessageCount: len(conversation.Messages),
+		})
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].UpdatedAt > summaries[j].UpdatedAt
+	})
+
+	return summaries
+}
+
+// conversationMatches 判断会话标题或消息内容是否包含关键词（关键词已转为小写）
+func conversationMatches(conversation Conversation, keyword string) bool {
+	if strings.Contains(strings.ToLower(conversation.Title), keyword) {
+		return true
+	}
+	for _, message := range conversation.Messages {
+		if strings.Contains(strings.ToLower(message.Content), keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// RenameConversation 重命名会话
+func (a *App) RenameConversation(id string, title string) error {
+	if strings.TrimSpace(title) == "" {
+		return fmt.Errorf("会话标题不能为空")
+	}
+
+	a.convMu.Lock()
+	defer a.convMu.Unlock()
+
+	conversation, err := a.loadConversation(id)
+	if err != nil {
+		return err
+	}
+
+	conversation.Title = strings.TrimSpace(title)
+	conversation.UpdatedAt = time.Now().Format(time.RFC3339)
+	return a.saveConversation(conversation)
+}
+
+// SetConversationProvider 切换会话使用的AI提供商，之后在该会话中发送的消息都使用此提供商
+func (a *App) SetConversationProvider(id string, providerID string) error {
+	if _, ok := findAIProvider(providerID); !ok {
+		return fmt.Errorf("未找到指定的AI提供商: %s", providerID)
+	}
+
+	a.convMu.Lock()
+	defer a.convMu.Unlock()
+
+	conversation, err := a.loadConversation(id)
+	if err != nil {
+		return err
+	}
+
+	conversation.ProviderID = providerID
+	conversation.UpdatedAt = time.Now().Format(time.RFC3339)
+	return a.saveConversation(conversation)
+}
+
+// DeleteConversation 删除会话
+func (a *App) DeleteConversation(id string) error {
+	a.convMu.Lock()
+	defer a.convMu.Unlock()
+
+	path, err := a.conversationPath(id)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("未找到会话: %s", id)
+		}
+		return err
+	}
+	return nil
+}
+
+// conversationToMarkdown 将会话转换为Markdown文本
+func conversationToMarkdown(conversation Conversation) string {
+	var sb strings.Builder
+
+	sb.WriteString("# " + conversation.Title + "\n\n")
+	sb.WriteString(fmt.Sprintf("- 创建时间: %s\n- 更新时间: %s\n\n", conversation.CreatedAt, conversation.UpdatedAt))
+
+	for _, message := range conversation.Messages {
+		switch message.Role {
+		case "user":
+			sb.WriteString("## 用户")
+		case "assistant":
+			sb.WriteString("## AI")
+			if message.Provider != "" {
+				sb.WriteString(" (" + message.Provider + ")")
+			}
+		case "tool":
+			sb.WriteString("## 工具调用: " + message.ToolName)
+			sb.WriteString("\n\n参数: `" + message.ToolArguments + "`\n\n```\n" + message.Content + "\n```\n\n")
+			continue
+		default:
+			sb.WriteString("## " + message.Role)
+		}
+		sb.WriteString("\n\n" + message.Content + "\n\n")
+	}
+
+	return sb.String()
+}
+
+// ExportConversationMarkdown 将会话导出为Markdown文件，返回保存路径
+// 用户取消保存对话框时返回空路径
+func (a *App) ExportConversationMarkdown(id string) (string, error) {
+	conversation, err := a.GetConversation(id)
+	if err != nil {
+		return "", err
+	}
+
+	if a.ctx == nil {
+		return "", fmt.Errorf("应用程序尚未启动")
+	}
+
+	path, err := wailsruntime.SaveFileDialog(a.ctx, wailsruntime.SaveDialogOptions{
+		Title:           "导出会话",
+		DefaultFilename: conversation.Title + ".md",
+		Filters: []wailsruntime.FileFilter{
+			{DisplayName: "Markdown (*.md)", Pattern: "*.md"},
+		},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	return path, os.WriteFile(path, []byte(conversationToMarkdown(conversation)), 0644)
+}
+
+// SendConversationMessage 在会话中发送消息，历史消息按模型上下文窗口裁剪后一并发送
+// 回复通过 ai:stream 事件流式推送，完成后用户消息和回复都会写入会话历史
+// providerID 为空时使用会话上次使用的提供商
+func (a *App) SendConversationMessage(requestID string, conversationID string, providerID string, apiKey string, customEndpoint string, query string) AIResponse {
+	conversation, err := a.GetConversation(conversationID)
+	if err != nil {
+		return AIResponse{Success: false, Error: err.Error()}
+	}
+	if providerID == "" {
+		providerID = conversation.ProviderID
+	}
+
+	userMessage := ConversationMessage{
+		Role:      "user",
+		Content:   query,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	history := append(conversation.Messages, userMessage)
+
+	// 按实际发送的系统提示词和最大回复token数计算可用于历史的预算
+	config, _ := a.loadAIConfig()
+	contextWindow := 0
+	systemPrompt := ""
+	maxTokens := 0
+	if provider, ok := findAIProvider(providerID); ok {
+		contextWindow = provider.Info().ContextWindow
+		systemPrompt, maxTokens = a.requestPromptSettings(provider, providerID, config)
+	}
+	messages := trimHistoryToContextWindow(history, contextWindow, systemPrompt, maxTokens)
+
+	// 开启工具调用且提供商支持时，由模型按需调用应用内的工具
+	var response AIResponse
+	var toolMessages []ConversationMessage
+	handled := false
+	if config.EnableTools {
+		response, toolMessages, handled = a.runToolConversation(requestID, providerID, apiKey, customEndpoint, messages)
+	}
+	if !handled {
+		response = a.streamMessages(requestID, providerID, apiKey, customEndpoint, messages)
+	}
+
+	// 生成失败且没有任何内容时不修改历史，方便用户重试
+	if !response.Success && response.Content == "" {
+		return response
+	}
+
+	a.convMu.Lock()
+	defer a.convMu.Unlock()
+
+	// 重新读取，避免覆盖生成期间的重命名等修改
+	conversation, err = a.loadConversation(conversationID)
+	if err != nil {
+		return response
+	}
+
+	conversation.Messages = append(conversation.Messages, userMessage)
+	conversation.Messages = append(conversation.Messages, toolMessages...)
+	conversation.Messages = append(conversation.Messages, ConversationMessage{
+		Role:      "assistant",
+		Content:   response.Content,
+		Provider:  response.Provider,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	})
+	conversation.ProviderID = providerID
+	conversation.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	if err := a.saveConversation(conversation); err != nil {
+		fmt.Printf("保存会话失败: %v\n", err)
+	}
+
+	return response
+}
+
+// estimateTokens 粗略估算文本的token数：ASCII约4个字符一个token，其他字符按一个token计
+func estimateTokens(text string) int {
+	ascii := 0
+	other := 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return ascii/4 + other + 4
+}
+
+// trimHistoryToContextWindow 从最新消息开始保留，直到达到上下文窗口限制
+// 上下文窗口需要容纳系统提示词和最多 maxTokens 的回复，maxTokens 不大于0时预留 aiResponseTokenReserve
+// 最新的一条消息总会保留；contextWindow 为0时不裁剪
+func trimHistoryToContextWindow(history []ConversationMessage, contextWindow int, systemPrompt string, maxTokens int) []ChatMessage {
+	// 工具调用记录只用于展示，不再发送给模型
+	filtered := []ConversationMessage{}
+	for _, message := range history {
+		if message.Role != "tool" {
+			filtered = append(filtered, message)
+		}
+	}
+	history = filtered
+
+	start := 0
+	if contextWindow > 0 {
+		reserve := maxTokens
+		if reserve <= 0 {
+			reserve = aiResponseTokenReserve
+		}
+		budget := contextWindow - reserve - estimateTokens(systemPrompt)
+		used := 0
+		start = len(history)
+		for i := len(history) - 1; i >= 0; i-- {
+			used += estimateTokens(history[i].Content)
+			if used > budget && i < len(history)-1 {
+				break
+			}
+			start = i
+		}
+	}
+
+	// 部分提供商要求第一条消息来自用户
+	for start < len(history)-1 && history[start].Role != "user" {
+		start++
+	}
+
+	messages := make([]ChatMessage, 0, len(history)-start)
+	for _, message := range history[start:] {
+		messages = append(messages, ChatMessage{Role: message.Role, Content: message.Content})
+	}
+	return messages
+}
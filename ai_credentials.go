@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AITokenExchanger 由需要先用密钥换取临时访问令牌的提供商实现（如百度文心一言）
+type AITokenExchanger interface {
+	// ExchangeToken 用API密钥和凭据换取访问令牌，返回令牌及其有效期
+	ExchangeToken(ctx context.Context, apiKey string, credentials map[string]string) (string, time.Duration, error)
+}
+
+// AICredentialEndpoint 由可以根据结构化凭据拼出请求地址的提供商实现（如Azure OpenAI）
+type AICredentialEndpoint interface {
+	// EndpointFromCredentials 返回根据凭据生成的端点，凭据不完整时返回空字符串
+	EndpointFromCredentials(credentials map[string]string) string
+}
+
+// cachedAccessToken 缓存的访问令牌
+type cachedAccessToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// accessTokenRefreshMargin 令牌到期前提前刷新的时间
+const accessTokenRefreshMargin = 5 * time.Minute
+
+// AIConnectionTestResult 连接测试结果
+type AIConnectionTestResult struct {
+	Success bool `json:"success"`
+	// Stage 失败的环节：config（配置不完整）、token（换取令牌）、request（发送请求）、response（解析响应）
+	Stage     string `json:"stage,omitempty"`
+	ErrorType string `json:"errorType,omitempty"`
+	Message   string `json:"message"`
+	Model     string `json:"model,omitempty"`
+	Endpoint  string `json:"endpoint,omitempty"`
+	LatencyMs int64  `json:"latencyMs"`
+	Reply     string `json:"reply,omitempty"`
+}
+
+// credentialsWithDefaults 返回提供商的凭据，未填写的字段使用默认值
+func credentialsWithDefaults(info AIProviderInfo, saved map[string]string) map[string]string {
+	credentials := map[string]string{}
+	for _, field := range info.CredentialFields {
+		value := strings.TrimSpace(saved[field.Name])
+		if value == "" {
+			value = field.Default
+		}
+		credentials[field.Name] = value
+	}
+	return credentials
+}
+
+// resolveCredentials 使用界面上尚未保存的凭据，机密字段为空或脱敏预览时沿用已保存的值
+func resolveCredentials(providerID string, saved map[string]string, overrides map[string]string) map[string]string {
+	if overrides == nil {
+		return saved
+	}
+	resolved := map[string]string{}
+	for name, value := range overrides {
+		if isSecretCredential(providerID, name) && (value == "" || isMaskedAPIKey(value)) {
+			value = saved[name]
+		}
+		resolved[name] = value
+	}
+	return resolved
+}
+
+// accessTokenCacheKey 按提供商和密钥区分缓存，密钥只以摘要形式出现在内存中的键里
+func accessTokenCacheKey(providerID string, apiKey string, credentials map[string]string) string {
+	sum := sha256.Sum256([]byte(apiKey + "\x00" + credentials["secretKey"]))
+	return providerID + ":" + hex.EncodeToString(sum[:8])
+}
+
+// getAccessToken 获取访问令牌，缓存未过期时直接使用缓存
+func (a *App) getAccessToken(ctx context.Context, provider AIProvider, exchanger AITokenExchanger, apiKey string, credentials map[string]string) (string, error) {
+	key := accessTokenCacheKey(provider.Info().ID, apiKey, credentials)
+
+	a.tokenMu.Lock()
+	cached, ok := a.accessTokens[key]
+	a.tokenMu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt.Add(-accessTokenRefreshMargin)) {
+		return cached.token, nil
+	}
+
+	token, expiresIn, err := exchanger.ExchangeToken(ctx, apiKey, credentials)
+	if err != nil {
+		return "", err
+	}
+
+	a.tokenMu.Lock()
+	defer a.tokenMu.Unlock()
+	if a.accessTokens == nil {
+		a.accessTokens = make(map[string]cachedAccessToken)
+	}
+	a.accessTokens[key] = cachedAccessToken{token: token, expiresAt: time.Now().Add(expiresIn)}
+
+	return token, nil
+}
+
+// invalidateAccessToken 令牌被服务端拒绝时清除缓存，下次请求重新换取
+func (a *App) invalidateAccessToken(chatReq ChatRequest, err error) {
+	if chatReq.AccessToken == "" || err == nil || toAIError(err).Kind != AIErrorAuth {
+		return
+	}
+
+	a.tokenMu.Lock()
+	defer a.tokenMu.Unlock()
+	for key, cached := range a.accessTokens {
+		if cached.token == chatReq.AccessToken {
+			delete(a.accessTokens, key)
+		}
+	}
+}
+
+// missingCredentials 返回未填写的必填凭据
+func missingCredentials(info AIProviderInfo, apiKey string, endpoint string, credentials map[string]string) []string {
+	missing := []string{}
+	if !info.Local && !info.UserDefined && info.ID != "custom" && apiKey == "" {
+		missing = append(missing, "API Key")
+	}
+	// 仍为示例地址时说明既没有填写端点，也没有填写生成端点所需的凭据
+	if endpoint == "" || strings.Contains(endpoint, "YOUR_") {
+		label := "API端点"
+		if len(info.CredentialFields) > 0 {
+			labels := []string{}
+			for _, field := range info.CredentialFields {
+				if field.Default == "" {
+					labels = append(labels, field.Label)
+				}
+			}
+			label += "（或" + strings.Join(labels, "、") + "）"
+		}
+		missing = append(missing, label)
+	}
+	for _, field := range info.CredentialFields {
+		if field.Required && credentials[field.Name] == "" {
+			missing = append(missing, field.Label)
+		}
+	}
+	return missing
+}
+
+// TestAIConnection 使用最小的请求验证提供商配置和凭据，并报告具体哪个环节出错
+// credentials 为界面上填写的凭据，可以在保存前测试
+func (a *App) TestAIConnection(providerID string, apiKey string, customEndpoint string, credentials map[string]string) AIConnectionTestResult {
+	started := time.Now()
+
+	provider, chatReq, errResp := a.prepareAIRequestWithCredentials(providerID, apiKey, customEndpoint, credentials, []ChatMessage{
+		{Role: "user", Content: "ping"},
+	})
+	if errResp != nil {
+		stage := "config"
+		if errResp.ErrorType != "" {
+			stage = "token"
+		}
+		return AIConnectionTestResult{
+			Stage:     stage,
+			ErrorType: errResp.ErrorType,
+			Message:   errResp.Error,
+			LatencyMs: time.Since(started).Milliseconds(),
+		}
+	}
+
+	result := AIConnectionTestResult{
+		Model:    chatReq.Model,
+		Endpoint: chatReq.Endpoint,
+	}
+
+	if missing := missingCredentials(provider.Info(), chatReq.APIKey, chatReq.Endpoint, chatReq.Credentials); len(missing) > 0 {
+		result.Stage = "config"
+		result.Message = "缺少必填配置: " + strings.Join(missing, "、")
+		return result
+	}
+
+	// 只请求极少的输出，降低测试的花费
+	chatReq.MaxTokens = 5
+	chatReq.SystemPrompt = ""
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	body, err := a.doAIRequest(ctx, provider, chatReq, 20*time.Second)
+	result.LatencyMs = time.Since(started).Milliseconds()
+	if err != nil {
+		response := a.aiErrorResponse(err, provider.Info().Name, "")
+		result.Stage = "request"
+		result.ErrorType = response.ErrorType
+		result.Message = response.Error
+		return result
+	}
+
+	reply, err := provider.ParseResponse(body)
+	if err != nil {
+		response := a.aiErrorResponse(err, provider.Info().Name, "")
+		result.Stage = "response"
+		result.ErrorType = response.ErrorType
+		result.Message = "服务有响应，但返回的格式无法识别，请检查端点地址是否为对话接口: " + response.Error
+		return result
+	}
+
+	result.Success = true
+	result.Reply = reply
+	result.Message = fmt.Sprintf("连接成功，模型 %s 响应耗时 %d ms", chatReq.Model, result.LatencyMs)
+	return result
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// UserAIProvider 用户在提供商注册文件中定义的OpenAI或Anthropic兼容服务
+// 密钥不写入注册文件，而是作为该提供商的 apiKey 凭据加密保存
+type UserAIProvider struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Format 接口格式：openai 或 anthropic
+	Format           string   `json:"format"`
+	EndpointURL      string   `json:"endpointUrl"`
+	DocumentURL      string   `json:"documentUrl,omitempty"`
+	Models           []string `json:"models,omitempty"`
+	DefaultModel     string   `json:"defaultModel,omitempty"`
+	ContextWindow    int      `json:"contextWindow,omitempty"`
+	DefaultMaxTokens int      `json:"defaultMaxTokens,omitempty"`
+	// Headers 每次请求附加的请求头（如网关要求的租户标识）
+	Headers map[string]string `json:"headers,omitempty"`
+	// APIKey 手动编辑注册文件时可以填写，读取后会被移入加密存储并从文件中删除
+	APIKey string `json:"apiKey,omitempty"`
+}
+
+// userAIProviderFile 提供商注册文件的格式
+type userAIProviderFile struct {
+	Providers []UserAIProvider `json:"providers"`
+}
+
+// userProviderIDPattern 自定义提供商ID只允许小写字母、数字、下划线和连字符
+var userProviderIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,39}$`)
+
+// userAIProviderRegistry 从注册文件加载的提供商，findAIProvider 在内置提供商之后查找
+var userAIProviderRegistry struct {
+	sync.RWMutex
+	providers []AIProvider
+}
+
+// userOpenAIProvider 用户定义的OpenAI兼容提供商
+type userOpenAIProvider struct {
+	openAICompatibleProvider
+	headers map[string]string
+}
+
+// DefaultHeaders 返回注册文件中为该提供商配置的请求头
+func (p *userOpenAIProvider) DefaultHeaders() map[string]string {
+	return p.headers
+}
+
+// userAnthropicProvider 用户定义的Anthropic兼容提供商
+type userAnthropicProvider struct {
+	anthropicProvider
+	headers map[string]string
+}
+
+// DefaultHeaders 返回注册文件中为该提供商配置的请求头
+func (p *userAnthropicProvider) DefaultHeaders() map[string]string {
+	return p.headers
+}
+
+// AIDefaultHeaders 由带固定请求头的提供商实现，用户在设置中配置的请求头优先
+type AIDefaultHeaders interface {
+	DefaultHeaders() map[string]string
+}
+
+// userProviderCredentialFields 自定义提供商各自保存密钥，不使用全局API密钥
+var userProviderCredentialFields = []AICredentialField{
+	{Name: "apiKey", Label: "API Key", Placeholder: "该提供商的API密钥，不需要时留空", Secret: true},
+}
+
+// getUserAIProvidersPath 获取提供商注册文件路径
+func (a *App) getUserAIProvidersPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "ai_providers.json"
+	}
+	return filepath.Join(homeDir, ".networ_tester", "ai_providers.json")
+}
+
+// validateUserAIProvider 检查自定义提供商的必填字段，并补全默认值
+func validateUserAIProvider(provider *UserAIProvider) error {
+	provider.ID = strings.TrimSpace(provider.ID)
+	provider.Name = strings.TrimSpace(provider.Name)
+	provider.EndpointURL = strings.TrimSpace(provider.EndpointURL)
+	provider.Format = strings.ToLower(strings.TrimSpace(provider.Format))
+
+	if !userProviderIDPattern.MatchString(provider.ID) {
+		return fmt.Errorf("提供商ID只能包含小写字母、数字、下划线和连字符: %s", provider.ID)
+	}
+	for _, builtin := range aiProviderRegistry {
+		if builtin.Info().ID == provider.ID {
+			return fmt.Errorf("提供商ID与内置提供商重复: %s", provider.ID)
+		}
+	}
+	if provider.Name == "" {
+		provider.Name = provider.ID
+	}
+	if provider.Format == "" {
+		provider.Format = "openai"
+	}
+	if provider.Format != "openai" && provider.Format != "anthropic" {
+		return fmt.Errorf("不支持的接口格式: %s（只支持 openai 或 anthropic）", provider.Format)
+	}
+	if !strings.HasPrefix(provider.EndpointURL, "http://") && !strings.HasPrefix(provider.EndpointURL, "https://") {
+		return fmt.Errorf("提供商 %s 的端点必须是http或https地址", provider.ID)
+	}
+	if provider.DefaultModel == "" && len(provider.Models) > 0 {
+		provider.DefaultModel = provider.Models[0]
+	}
+	if provider.Format == "anthropic" && provider.DefaultMaxTokens <= 0 {
+		// Anthropic格式要求必须指定max_tokens
+		provider.DefaultMaxTokens = 4096
+	}
+	return nil
+}
+
+// newUserAIProvider 根据接口格式创建提供商实现
+func newUserAIProvider(provider UserAIProvider) AIProvider {
+	info := AIProviderInfo{
+		ID:               provider.ID,
+		Name:             provider.Name,
+		EndpointURL:      provider.EndpointURL,
+		DocumentURL:      provider.DocumentURL,
+		ContextWindow:    provider.ContextWindow,
+		DefaultModel:     provider.DefaultModel,
+		DefaultMaxTokens: provider.DefaultMaxTokens,
+		Models:           provider.Models,
+		CredentialFields: userProviderCredentialFields,
+		UserDefined:      true,
+	}
+
+	if provider.Format == "anthropic" {
+		info.ApiKeyHeader = "x-api-key"
+		return &userAnthropicProvider{anthropicProvider: anthropicProvider{info: info}, headers: provider.Headers}
+	}
+
+	info.ApiKeyHeader = "Authorization"
+	info.ApiKeyPrefix = "Bearer "
+	return &userOpenAIProvider{openAICompatibleProvider: openAICompatibleProvider{info: info}, headers: provider.Headers}
+}
+
+// readUserAIProviders 读取注册文件，文件不存在时返回空列表
+func (a *App) readUserAIProviders() ([]UserAIProvider, error) {
+	data, err := os.ReadFile(a.getUserAIProvidersPath())
+	if os.IsNotExist(err) {
+		return []UserAIProvider{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file userAIProviderFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析提供商注册文件失败: %v", err)
+	}
+	if file.Providers == nil {
+		file.Providers = []UserAIProvider{}
+	}
+	return file.Providers, nil
+}
+
+// writeUserAIProviders 写入注册文件，密钥不会写入
+func (a *App) writeUserAIProviders(providers []UserAIProvider) error {
+	for i := range providers {
+		providers[i].APIKey = ""
+	}
+
+	data, err := json.MarshalIndent(userAIProviderFile{Providers: providers}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	os.MkdirAll(filepath.Dir(a.getUserAIProvidersPath()), 0755)
+	return os.WriteFile(a.getUserAIProvidersPath(), data, 0600)
+}
+
+// reloadUserAIProviders 重新读取注册文件并更新可用的提供商
+// 注册文件中手动填写的密钥会被移入加密存储
+func (a *App) reloadUserAIProviders() error {
+	providers, err := a.readUserAIProviders()
+	if err != nil {
+		return err
+	}
+
+	registered := []AIProvider{}
+	seen := map[string]bool{}
+	keys := map[string]string{}
+	for i := range providers {
+		if err := validateUserAIProvider(&providers[i]); err != nil {
+			fmt.Printf("跳过无效的自定义提供商: %v\n", err)
+			continue
+		}
+		if seen[providers[i].ID] {
+			fmt.Printf("跳过重复的自定义提供商: %s\n", providers[i].ID)
+			continue
+		}
+		seen[providers[i].ID] = true
+		if providers[i].APIKey != "" {
+			keys[providers[i].ID] = providers[i].APIKey
+		}
+		registered = append(registered, newUserAIProvider(providers[i]))
+	}
+
+	userAIProviderRegistry.Lock()
+	userAIProviderRegistry.providers = registered
+	userAIProviderRegistry.Unlock()
+
+	if len(keys) > 0 {
+		if err := a.saveUserProviderKeys(keys); err != nil {
+			return fmt.Errorf("保存注册文件中的密钥失败: %v", err)
+		}
+		return a.writeUserAIProviders(providers)
+	}
+	return nil
+}
+
+// saveUserProviderKeys 将自定义提供商的密钥写入加密的凭据存储
+func (a *App) saveUserProviderKeys(keys map[string]string) error {
+	config, err := a.loadAIConfig()
+	if err != nil {
+		return err
+	}
+	if config.Credentials == nil {
+		config.Credentials = map[string]map[string]string{}
+	}
+	for id, key := range keys {
+		if config.Credentials[id] == nil {
+			config.Credentials[id] = map[string]string{}
+		}
+		config.Credentials[id]["apiKey"] = key
+	}
+	return a.SaveAIConfig(config)
+}
+
+// userAIProviders 返回当前已注册的自定义提供商
+func userAIProviders() []AIProvider {
+	userAIProviderRegistry.RLock()
+	defer userAIProviderRegistry.RUnlock()
+	return append([]AIProvider(nil), userAIProviderRegistry.providers...)
+}
+
+// GetUserAIProviders 获取注册文件中的自定义提供商
+func (a *App) GetUserAIProviders() ([]UserAIProvider, error) {
+	return a.readUserAIProviders()
+}
+
+// SaveUserAIProvider 新增或更新一个自定义提供商，apiKey 不为空时加密保存为该提供商的密钥
+func (a *App) SaveUserAIProvider(provider UserAIProvider) error {
+	if err := validateUserAIProvider(&provider); err != nil {
+		return err
+	}
+	// 先迁移注册文件中手动填写的密钥，避免重写文件时丢失
+	if err := a.reloadUserAIProviders(); err != nil {
+		return err
+	}
+
+	providers, err := a.readUserAIProviders()
+	if err != nil {
+		return err
+	}
+
+	key := provider.APIKey
+	replaced := false
+	for i := range providers {
+		if providers[i].ID == provider.ID {
+			providers[i] = provider
+			replaced = true
+		}
+	}
+	if !replaced {
+		providers = append(providers, provider)
+	}
+
+	if err := a.writeUserAIProviders(providers); err != nil {
+		return err
+	}
+	// 先注册提供商，保存密钥时才能识别为机密凭据
+	if err := a.reloadUserAIProviders(); err != nil {
+		return err
+	}
+	if key != "" && !isMaskedAPIKey(key) {
+		return a.saveUserProviderKeys(map[string]string{provider.ID: key})
+	}
+	return nil
+}
+
+// DeleteUserAIProvider 删除自定义提供商及其保存的凭据
+func (a *App) DeleteUserAIProvider(id string) error {
+	// 先迁移注册文件中手动填写的密钥，避免重写文件时丢失
+	if err := a.reloadUserAIProviders(); err != nil {
+		return err
+	}
+
+	providers, err := a.readUserAIProviders()
+	if err != nil {
+		return err
+	}
+
+	kept := []UserAIProvider{}
+	for _, provider := range providers {
+		if provider.ID != id {
+			kept = append(kept, provider)
+		}
+	}
+	if len(kept) == len(providers) {
+		return fmt.Errorf("未找到自定义提供商: %s", id)
+	}
+
+	if err := a.writeUserAIProviders(kept); err != nil {
+		return err
+	}
+
+	config, err := a.loadAIConfig()
+	if err == nil && config.Credentials[id] != nil {
+		delete(config.Credentials, id)
+		if err := a.SaveAIConfig(config); err != nil {
+			return err
+		}
+	}
+
+	return a.reloadUserAIProviders()
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// EnvironmentSummary 是附加到AI提问中的本机环境摘要，发送前由用户确认或修改
+type EnvironmentSummary struct {
+	Language string `json:"language"`
+	Text     string `json:"text"`
+}
+
+// 环境摘要中每种语言最多列出的包数量
+const environmentSummaryMaxPackages = 30
+
+// languageAliases 提问中常见的语言别名和包管理器名，对应 LanguageInfo.Name
+var languageAliases = map[string][]string{
+	"Go":         {"go", "golang", "go mod", "go get"},
+	"Python":     {"python", "pip", "pypi", "conda", "py"},
+	"Node.js":    {"node", "nodejs", "node.js", "npm", "yarn", "pnpm", "javascript", "js"},
+	"Java":       {"java", "maven", "mvn", "gradle", "jdk"},
+	"C# (.NET)":  {"c#", "csharp", ".net", "dotnet", "nuget"},
+	"Ruby":       {"ruby", "gem", "bundler", "rails"},
+	"PHP":        {"php", "composer"},
+	"Rust":       {"rust", "cargo", "rustup", "crate"},
+	"C/C++":      {"c++", "cpp", "gcc", "clang", "vcpkg", "conan", "cmake"},
+	"TypeScript": {"typescript", "ts", "tsc"},
+	"Haskell":    {"haskell", "ghc", "cabal", "stack", "ghcup"},
+	"Elixir":     {"elixir", "mix", "hex"},
+	"Dart":       {"dart", "flutter", "pub"},
+	"Kotlin":     {"kotlin"},
+	"Swift":      {"swift", "swiftpm"},
+	"SQL":        {"sql", "mysql", "postgres", "postgresql", "sqlite", "sqlserver"},
+}
+
+// cacheDetectedLanguages 保存最近一次语言检测的结果
+func (a *App) cacheDetectedLanguages(languages []LanguageInfo) {
+	a.detectMu.Lock()
+	defer a.detectMu.Unlock()
+
+	a.detectedLanguages = languages
+}
+
+// updateDetectedLanguage 用单个语言的检测结果更新缓存
+func (a *App) updateDetectedLanguage(info LanguageInfo) {
+	a.detectMu.Lock()
+	defer a.detectMu.Unlock()
+
+	for i, lang := range a.detectedLanguages {
+		if lang.Name == info.Name {
+			a.detectedLanguages[i] = info
+			return
+		}
+	}
+	if a.detectedLanguages != nil {
+		a.detectedLanguages = append(a.detectedLanguages, info)
+	}
+}
+
+// getDetectedLanguages 返回最近一次的检测结果，没有时执行一次检测
+func (a *App) getDetectedLanguages() []LanguageInfo {
+	a.detectMu.Lock()
+	languages := a.detectedLanguages
+	a.detectMu.Unlock()
+
+	if languages == nil {
+		languages = a.DetectLanguages()
+	}
+	return languages
+}
+
+// guessLanguageFromQuery 根据问题中的语言名或包管理器名推测提问涉及的语言
+func guessLanguageFromQuery(query string, languages []LanguageInfo) string {
+	lower := strings.ToLower(query)
+
+	names := make([]string, 0, len(languageAliases))
+	for name := range languageAliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, alias := range languageAliases[name] {
+			if containsWord(lower, alias) {
+				return name
+			}
+		}
+	}
+
+	// 其他语言按完整名称匹配，过短的名称容易误判，跳过
+	for _, lang := range languages {
+		if len(lang.Name) >= 3 && containsWord(lower, strings.ToLower(lang.Name)) {
+			return lang.Name
+		}
+	}
+
+	return ""
+}
+
+// containsWord 判断文本中是否包含独立的词（两侧不是字母或数字）
+func containsWord(text, word string) bool {
+	pattern := `(^|[^a-z0-9])` + regexp.QuoteMeta(word) + `($|[^a-z0-9])`
+	matched, _ := regexp.MatchString(pattern, text)
+	return matched
+}
+
+// GetEnvironmentSummary 生成附加到提问中的本机环境摘要
+// language 为空时根据问题内容推测涉及的语言，只有该语言会列出已安装的包
+func (a *App) GetEnvironmentSummary(query string, language string) EnvironmentSummary {
+	system := a.GetSystemInfo()
+	languages := a.getDetectedLanguages()
+
+	if language == "" {
+		language = guessLanguageFromQuery(query, languages)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("操作系统: %s, 架构: %s, CPU核数: %s\n", system.OS, system.Arch, system.CPUs))
+
+	installed := []LanguageInfo{}
+	for _, lang := range languages {
+		if lang.Installed {
+			installed = append(installed, lang)
+		}
+	}
+	sort.Slice(installed, func(i, j int) bool {
+		return installed[i].Name < installed[j].Name
+	})
+
+	sb.WriteString("已安装的语言:\n")
+	for _, lang := range installed {
+		// 版本输出可能有多行，只取第一行
+		version := strings.TrimSpace(strings.Split(lang.Version, "\n")[0])
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", lang.Name, version))
+	}
+
+	for _, lang := range installed {
+		if lang.Name != language {
+			continue
+		}
+
+		if lang.PackageManager != "" {
+			sb.WriteString(fmt.Sprintf("%s 包管理器: %s\n", lang.Name, lang.PackageManager))
+		}
+
+		if len(lang.Packages) > 0 {
+			sb.WriteString(fmt.Sprintf("%s 已安装的包:\n", lang.Name))
+			for i, pkg := range lang.Packages {
+				if i >= environmentSummaryMaxPackages {
+					sb.WriteString(fmt.Sprintf("- ……另有 %d 个包\n", len(lang.Packages)-i))
+					break
+				}
+				sb.WriteString(fmt.Sprintf("- %s %s\n", pkg.Name, pkg.Version))
+			}
+		}
+	}
+
+	return EnvironmentSummary{
+		Language: language,
+		Text:     strings.TrimSpace(sb.String()),
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AIErrorKind AI请求失败的类型
+type AIErrorKind string
+
+const (
+	AIErrorAuth        AIErrorKind = "auth"
+	AIErrorRateLimited AIErrorKind = "rate_limited"
+	AIErrorQuota       AIErrorKind = "quota_exceeded"
+	AIErrorBadRequest  AIErrorKind = "bad_request"
+	AIErrorTimeout     AIErrorKind = "timeout"
+	AIErrorNetwork     AIErrorKind = "network"
+	AIErrorServer      AIErrorKind = "server"
+	AIErrorCanceled    AIErrorKind = "canceled"
+	AIErrorUnknown     AIErrorKind = "unknown"
+)
+
+const (
+	// aiMaxRetries 可重试错误的最大重试次数
+	aiMaxRetries = 3
+	// aiRetryBaseDelay 指数退避的初始等待时间
+	aiRetryBaseDelay = time.Second
+	// aiRetryMaxDelay 单次等待的上限，Retry-After 超过该值时不再重试
+	aiRetryMaxDelay = 60 * time.Second
+)
+
+// AIError 分类后的AI请求错误
+type AIError struct {
+	Kind       AIErrorKind
+	StatusCode int
+	// RetryAfter 服务端通过 Retry-After 要求的等待时间
+	RetryAfter time.Duration
+	// Detail 提供商返回的原始错误信息
+	Detail string
+}
+
+func (e *AIError) Error() string {
+	message := aiErrorMessage(e.Kind, "zh")
+	if e.Detail != "" {
+		return message + " (" + e.Detail + ")"
+	}
+	return message
+}
+
+// Retryable 判断该错误是否值得自动重试
+func (e *AIError) Retryable() bool {
+	switch e.Kind {
+	case AIErrorRateLimited, AIErrorTimeout, AIErrorNetwork, AIErrorServer:
+		return true
+	}
+	return false
+}
+
+// aiErrorMessages 各类错误的本地化提示，包含用户可以采取的操作
+var aiErrorMessages = map[string]map[AIErrorKind]string{
+	"zh": {
+		AIErrorAuth:        "认证失败：API密钥无效或已过期，请在AI设置中检查密钥",
+		AIErrorRateLimited: "请求过于频繁，已被限流，请稍后再试",
+		AIErrorQuota:       "额度已用完或账户欠费，请到提供商控制台检查余额和配额",
+		AIErrorBadRequest:  "请求参数有误，请检查模型名称、端点地址和生成参数",
+		AIErrorTimeout:     "请求超时，请检查网络或稍后重试",
+		AIErrorNetwork:     "无法连接到AI服务，请检查网络、代理设置和端点地址",
+		AIErrorServer:      "AI服务暂时不可用，请稍后重试",
+		AIErrorCanceled:    "已取消生成",
+		AIErrorUnknown:     "AI请求失败",
+	},
+	"en": {
+		AIErrorAuth:        "Authentication failed: the API key is invalid or expired. Check it in the AI settings",
+		AIErrorRateLimited: "Too many requests, the provider is rate limiting. Please try again later",
+		AIErrorQuota:       "Quota exhausted or account in arrears. Check your balance in the provider console",
+		AIErrorBadRequest:  "The request was rejected. Check the model name, endpoint and generation settings",
+		AIErrorTimeout:     "The request timed out. Check your network or try again later",
+		AIErrorNetwork:     "Cannot reach the AI service. Check your network, proxy settings and endpoint",
+		AIErrorServer:      "The AI service is temporarily unavailable. Please try again later",
+		AIErrorCanceled:    "Generation canceled",
+		AIErrorUnknown:     "AI request failed",
+	},
+	"ru": {
+		AIErrorAuth:        "Ошибка аутентификации: API-ключ недействителен или истёк. Проверьте его в настройках ИИ",
+		AIErrorRateLimited: "Слишком много запросов, провайдер ограничил частоту. Повторите попытку позже",
+		AIErrorQuota:       "Квота исчерпана или на счёте задолженность. Проверьте баланс в консоли провайдера",
+		AIErrorBadRequest:  "Запрос отклонён. Проверьте имя модели, адрес и параметры генерации",
+		AIErrorTimeout:     "Время ожидания истекло. Проверьте сеть или повторите попытку позже",
+		AIErrorNetwork:     "Не удаётся подключиться к сервису ИИ. Проверьте сеть, прокси и адрес",
+		AIErrorServer:      "Сервис ИИ временно недоступен. Повторите попытку позже",
+		AIErrorCanceled:    "Генерация отменена",
+		AIErrorUnknown:     "Ошибка запроса к ИИ",
+	},
+}
+
+// aiErrorMessage 返回指定语言的错误提示，未知语言使用中文
+func aiErrorMessage(kind AIErrorKind, language string) string {
+	messages, ok := aiErrorMessages[language]
+	if !ok {
+		messages = aiErrorMessages["zh"]
+	}
+	if message, ok := messages[kind]; ok {
+		return message
+	}
+	return messages[AIErrorUnknown]
+}
+
+// classifyHTTPError 根据状态码和响应体对错误分类
+func classifyHTTPError(statusCode int, header http.Header, body []byte) *AIError {
+	aiErr := &AIError{
+		StatusCode: statusCode,
+		RetryAfter: parseRetryAfter(header.Get("Retry-After")),
+		Detail:     extractProviderErrorMessage(body),
+	}
+
+	lower := strings.ToLower(string(body))
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		aiErr.Kind = AIErrorAuth
+	case statusCode == http.StatusPaymentRequired:
+		aiErr.Kind = AIErrorQuota
+	case statusCode == http.StatusTooManyRequests:
+		// OpenAI等在额度用完时同样返回429
+		if strings.Contains(lower, "insufficient_quota") || strings.Contains(lower, "quota") || strings.Contains(lower, "billing") {
+			aiErr.Kind = AIErrorQuota
+		} else {
+			aiErr.Kind = AIErrorRateLimited
+		}
+	case statusCode == http.StatusRequestTimeout || statusCode == http.StatusGatewayTimeout:
+		aiErr.Kind = AIErrorTimeout
+	case statusCode >= 500:
+		aiErr.Kind = AIErrorServer
+	case statusCode >= 400:
+		aiErr.Kind = AIErrorBadRequest
+	default:
+		aiErr.Kind = AIErrorUnknown
+	}
+
+	if aiErr.Detail == "" {
+		aiErr.Detail = fmt.Sprintf("状态码: %d", statusCode)
+	}
+	return aiErr
+}
+
+// baiduErrorKind 按文心一言的错误码分类，这类错误在HTTP 200的响应体中返回
+func baiduErrorKind(code int) AIErrorKind {
+	switch code {
+	case 6, 13, 14, 110, 111:
+		return AIErrorAuth
+	case 4, 18:
+		return AIErrorRateLimited
+	case 17, 19:
+		return AIErrorQuota
+	case 2, 336100:
+		return AIErrorServer
+	}
+	return AIErrorBadRequest
+}
+
+// aliyunErrorKind 按DashScope的错误码分类
+func aliyunErrorKind(code string) AIErrorKind {
+	switch {
+	case code == "InvalidApiKey" || code == "AccessDenied":
+		return AIErrorAuth
+	case code == "Arrearage":
+		return AIErrorQuota
+	case strings.HasPrefix(code, "Throttling"):
+		return AIErrorRateLimited
+	case code == "InternalError":
+		return AIErrorServer
+	}
+	return AIErrorBadRequest
+}
+
+// anthropicErrorKind 按Anthropic流式 error 事件中的错误类型分类
+func anthropicErrorKind(errorType string) AIErrorKind {
+	switch errorType {
+	case "authentication_error", "permission_error":
+		return AIErrorAuth
+	case "rate_limit_error":
+		return AIErrorRateLimited
+	case "overloaded_error", "api_error":
+		return AIErrorServer
+	case "invalid_request_error", "not_found_error", "request_too_large":
+		return AIErrorBadRequest
+	}
+	return AIErrorUnknown
+}
+
+// toAIError 将任意错误转换为分类后的错误：
+// 网络层错误按超时/网络不可达分类，其他错误（如解析失败）归为未知
+func toAIError(err error) *AIError {
+	var aiErr *AIError
+	if errors.As(err, &aiErr) {
+		return aiErr
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return &AIError{Kind: AIErrorCanceled}
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return &AIError{Kind: AIErrorTimeout, Detail: err.Error()}
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) || errors.As(err, &netErr) {
+		return &AIError{Kind: AIErrorNetwork, Detail: err.Error()}
+	}
+
+	return &AIError{Kind: AIErrorUnknown, Detail: err.Error()}
+}
+
+// parseRetryAfter 解析 Retry-After 头，支持秒数和HTTP日期两种格式
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
+// extractProviderErrorMessage 从常见的错误响应格式中提取错误信息
+func extractProviderErrorMessage(body []byte) string {
+	var resp struct {
+		Error json.RawMessage `json:"error"`
+		// 阿里云、百度等使用顶层字段
+		Message  string `json:"message"`
+		ErrorMsg string `json:"error_msg"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		text := strings.TrimSpace(string(body))
+		if len([]rune(text)) > 200 {
+			text = string([]rune(text)[:200]) + "…"
+		}
+		return text
+	}
+
+	if len(resp.Error) > 0 {
+		var nested struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(resp.Error, &nested) == nil && nested.Message != "" {
+			return nested.Message
+		}
+		var text string
+		if json.Unmarshal(resp.Error, &text) == nil && text != "" {
+			return text
+		}
+	}
+	if resp.Message != "" {
+		return resp.Message
+	}
+	return resp.ErrorMsg
+}
+
+// retryDelay 计算第 attempt 次重试前的等待时间（从0开始），优先使用服务端要求的时间
+func retryDelay(attempt int, aiErr *AIError) time.Duration {
+	if aiErr.RetryAfter > 0 {
+		return aiErr.RetryAfter
+	}
+	delay := aiRetryBaseDelay << uint(attempt)
+	// 加入随机抖动，避免多个请求同时重试
+	return delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+}
+
+// withAIRetry 执行请求，遇到可重试的错误时按指数退避重试
+func withAIRetry(ctx context.Context, do func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := do()
+		if err == nil {
+			return nil
+		}
+
+		aiErr := toAIError(err)
+		if !aiErr.Retryable() || attempt >= aiMaxRetries {
+			return aiErr
+		}
+
+		delay := retryDelay(attempt, aiErr)
+		if delay > aiRetryMaxDelay {
+			return aiErr
+		}
+
+		fmt.Printf("AI请求失败 (%s)，%v 后进行第 %d 次重试\n", aiErr.Kind, delay, attempt+1)
+		select {
+		case <-ctx.Done():
+			return toAIError(ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+// aiErrorResponse 将错误转换为带本地化提示的响应
+func (a *App) aiErrorResponse(err error, provider string, content string) AIResponse {
+	aiErr := toAIError(err)
+
+	message := aiErrorMessage(aiErr.Kind, a.GetLanguageConfig().Language)
+	if aiErr.Detail != "" {
+		message += " (" + aiErr.Detail + ")"
+	}
+
+	return AIResponse{
+		Content:   content,
+		Success:   false,
+		Error:     message,
+		ErrorType: string(aiErr.Kind),
+		Provider:  provider,
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// localModelProvider 支持本机运行的模型服务：
+// 端点以 /api/chat 结尾时使用Ollama格式，否则按llama.cpp等OpenAI兼容服务处理
+type localModelProvider struct {
+	openAICompatibleProvider
+}
+
+// isOllamaEndpoint 判断端点是否为Ollama原生接口
+func isOllamaEndpoint(endpoint string) bool {
+	return strings.HasSuffix(strings.TrimRight(endpoint, "/"), "/api/chat")
+}
+
+// isLoopbackEndpoint 判断端点是否指向本机
+func isLoopbackEndpoint(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (p *localModelProvider) NewRequest(ctx context.Context, req ChatRequest, stream bool) (*http.Request, error) {
+	if !isOllamaEndpoint(req.Endpoint) {
+		return p.openAICompatibleProvider.NewRequest(ctx, req, stream)
+	}
+
+	options := map[string]interface{}{}
+	if req.Temperature != nil {
+		options["temperature"] = *req.Temperature
+	}
+	if req.MaxTokens > 0 {
+		options["num_predict"] = req.MaxTokens
+	}
+
+	// Ollama默认流式返回，需要显式关闭
+	body := map[string]interface{}{
+		"model":    req.Model,
+		"messages": withSystemMessage(req),
+		"stream":   stream,
+	}
+	if len(options) > 0 {
+		body["options"] = options
+	}
+
+	return newJSONRequest(ctx, p.info, req, body)
+}
+
+// SupportsTools 只对OpenAI兼容的本地服务启用工具调用
+func (p *localModelProvider) SupportsTools(req ChatRequest) bool {
+	return !isOllamaEndpoint(req.Endpoint)
+}
+
+// ollamaChatResult 是Ollama响应和逐行流式事件的共同格式
+type ollamaChatResult struct {
+	Message *struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done  bool   `json:"done"`
+	Error string `json:"error"`
+}
+
+func (p *localModelProvider) ParseResponse(body []byte) (string, error) {
+	var resp ollamaChatResult
+	if err := json.Unmarshal(body, &resp); err == nil && (resp.Message != nil || resp.Error != "") {
+		if resp.Error != "" {
+			return "", fmt.Errorf("%s", resp.Error)
+		}
+		return resp.Message.Content, nil
+	}
+	return p.openAICompatibleProvider.ParseResponse(body)
+}
+
+func (p *localModelProvider) ParseStreamEvent(data []byte) (string, bool, error) {
+	var event ollamaChatResult
+	if err := json.Unmarshal(data, &event); err == nil && (event.Message != nil || event.Error != "") {
+		if event.Error != "" {
+			return "", true, fmt.Errorf("%s", event.Error)
+		}
+		return event.Message.Content, event.Done, nil
+	}
+	return p.openAICompatibleProvider.ParseStreamEvent(data)
+}
+
+// ListModels Ollama通过 /api/tags 列出本地模型，其他服务使用 /v1/models
+func (p *localModelProvider) ListModels(ctx context.Context, req ChatRequest) ([]string, error) {
+	if !isOllamaEndpoint(req.Endpoint) {
+		return p.openAICompatibleProvider.ListModels(ctx, req)
+	}
+
+	tagsURL := strings.TrimSuffix(strings.TrimRight(req.Endpoint, "/"), "/api/chat") + "/api/tags"
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", tagsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := newHTTPClient(10 * time.Second).Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("无法连接本地模型服务: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取模型列表失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("获取模型列表失败 (状态码: %d): %s", resp.StatusCode, string(body))
+	}
+
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.Unmarshal(body, &tags); err != nil {
+		return nil, fmt.Errorf("解析模型列表失败: %v", err)
+	}
+
+	models := []string{}
+	for _, model := range tags.Models {
+		models = append(models, model.Name)
+	}
+	sort.Strings(models)
+	return models, nil
+}
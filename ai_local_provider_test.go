@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// newLocalModelApp 使用临时目录中的配置，选择本地模型提供商
+func newLocalModelApp(t *testing.T, offline bool) *App {
+	t.Helper()
+	useTempHome(t)
+
+	app := NewApp()
+	config := AIConfig{
+		SelectedProviderID: "local",
+		OfflineMode:        offline,
+		ProviderSettings: map[string]AIProviderSettings{
+			"local": {Model: "test-model", MaxTokens: 64},
+		},
+	}
+	if err := app.SaveAIConfig(config); err != nil {
+		t.Fatalf("保存AI配置失败: %v", err)
+	}
+	return app
+}
+
+// decodeRequestBody 读取请求体中的JSON
+func decodeRequestBody(t *testing.T, r *http.Request) map[string]interface{} {
+	t.Helper()
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Errorf("读取请求体失败: %v", err)
+		return nil
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		t.Errorf("请求体不是JSON: %s", data)
+	}
+	return body
+}
+
+func TestLocalProviderOllama(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("请求路径不正确: %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("本地模型不应发送API密钥")
+		}
+
+		body := decodeRequestBody(t, r)
+		if body["model"] != "test-model" || body["stream"] != false {
+			t.Errorf("模型或流式参数不正确: %v", body)
+		}
+		options, _ := body["options"].(map[string]interface{})
+		if options["num_predict"] != float64(64) {
+			t.Errorf("最大token数应通过 options.num_predict 传递: %v", body["options"])
+		}
+		messages, _ := body["messages"].([]interface{})
+		if len(messages) != 2 {
+			t.Errorf("应包含系统提示词和用户消息: %v", messages)
+		}
+
+		w.Write([]byte(`{"model":"test-model","message":{"role":"assistant","content":"你好"},"done":true,"prompt_eval_count":12,"eval_count":3}`))
+	}))
+	defer server.Close()
+
+	app := newLocalModelApp(t, false)
+	response := app.QueryAI("local", "", server.URL+"/api/chat", "hello")
+	if !response.Success || response.Content != "你好" {
+		t.Fatalf("Ollama响应解析失败: %+v", response)
+	}
+}
+
+func TestLocalProviderOllamaError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"error":"model 'test-model' not found"}`))
+	}))
+	defer server.Close()
+
+	app := newLocalModelApp(t, false)
+	response := app.QueryAI("local", "", server.URL+"/api/chat", "hello")
+	if response.Success || response.Error == "" {
+		t.Fatalf("Ollama返回的错误应报告为失败: %+v", response)
+	}
+}
+
+func TestLocalProviderOllamaStreamEvents(t *testing.T) {
+	provider, _ := findAIProvider("local")
+
+	content, done, err := provider.ParseStreamEvent([]byte(`{"message":{"role":"assistant","content":"部分"},"done":false}`))
+	if err != nil || content != "部分" || done {
+		t.Errorf("流式事件解析不正确: %q %v %v", content, done, err)
+	}
+	_, done, err = provider.ParseStreamEvent([]byte(`{"message":{"role":"assistant","content":""},"done":true}`))
+	if err != nil || !done {
+		t.Errorf("最后一个流式事件应结束: %v %v", done, err)
+	}
+}
+
+func TestLocalProviderLlamaCpp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("请求路径不正确: %s", r.URL.Path)
+		}
+
+		body := decodeRequestBody(t, r)
+		if body["model"] != "test-model" || body["max_tokens"] != float64(64) {
+			t.Errorf("OpenAI兼容请求参数不正确: %v", body)
+		}
+
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hi"}}],"usage":{"prompt_tokens":5,"completion_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	app := newLocalModelApp(t, false)
+	response := app.QueryAI("local", "", server.URL+"/v1/chat/completions", "hello")
+	if !response.Success || response.Content != "hi" {
+		t.Fatalf("llama.cpp响应解析失败: %+v", response)
+	}
+}
+
+func TestOfflineModeAllowsOnlyLoopback(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(`{"message":{"role":"assistant","content":"ok"},"done":true}`))
+	}))
+	defer server.Close()
+
+	app := newLocalModelApp(t, true)
+
+	// 本机上的本地模型可以使用
+	if response := app.QueryAI("local", "", server.URL+"/api/chat", "hello"); !response.Success {
+		t.Fatalf("离线模式下应允许本机模型: %+v", response)
+	}
+
+	// 指向其他主机的本地模型端点和云端提供商都应被拒绝，且不发出请求
+	before := atomic.LoadInt32(&requests)
+	if response := app.QueryAI("local", "", "http://192.0.2.10:11434/api/chat", "hello"); response.Success {
+		t.Errorf("离线模式下不应访问其他主机上的模型: %+v", response)
+	}
+	if response := app.QueryAI("openai", "sk-test", "", "hello"); response.Success {
+		t.Errorf("离线模式下不应访问云端提供商: %+v", response)
+	}
+	if atomic.LoadInt32(&requests) != before {
+		t.Errorf("被拒绝的请求不应发出")
+	}
+}
+
+func TestIsLoopbackEndpoint(t *testing.T) {
+	cases := map[string]bool{
+		"http://localhost:11434/api/chat":           true,
+		"http://127.0.0.1:8080/v1/chat/completions": true,
+		"http://[::1]:11434/api/chat":               true,
+		"http://192.168.1.5:11434/api/chat":         false,
+		"http://localhost.example.com/api/chat":     false,
+		"http://127.0.0.1.nip.io/api/chat":          false,
+		"not a url":                                 false,
+	}
+	for endpoint, expected := range cases {
+		if got := isLoopbackEndpoint(endpoint); got != expected {
+			t.Errorf("isLoopbackEndpoint(%q) = %v, 期望 %v", endpoint, got, expected)
+		}
+	}
+}
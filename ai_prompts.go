@@ -0,0 +1,345 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PromptParameter 模板中的一个参数，在模板内容中以 {{name}} 引用
+type PromptParameter struct {
+	Name      string `json:"name"`
+	Label     string `json:"label"`
+	Default   string `json:"default,omitempty"`
+	Multiline bool   `json:"multiline,omitempty"`
+	Optional  bool   `json:"optional,omitempty"`
+}
+
+// PromptTemplate 常用开发任务的提示词模板
+type PromptTemplate struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Languages 适用的编程语言（与 LanguageInfo.Name 一致），为空表示适用所有语言
+	Languages  []string          `json:"languages"`
+	Parameters []PromptParameter `json:"parameters"`
+	Template   string            `json:"template"`
+}
+
+// promptPlaceholder 匹配模板中的 {{参数名}}
+var promptPlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+
+// promptTemplateID 模板ID同时用作文件名
+var promptTemplateID = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// defaultSystemPrompt 返回与界面语言一致的默认系统提示词
+func (a *App) defaultSystemPrompt() string {
+	if prompt, ok := localizedSystemPrompts[a.GetLanguageConfig().Language]; ok {
+		return prompt
+	}
+	return defaultAISystemPrompt
+}
+
+// getPromptsDir 获取提示词模板目录，首次使用时写入内置模板供用户修改
+func (a *App) getPromptsDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "prompts"
+	}
+	dir := filepath.Join(homeDir, ".networ_tester", "prompts")
+
+	// 确保目录存在
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		os.MkdirAll(dir, 0755)
+		for _, template := range builtinPromptTemplates(a.GetLanguageConfig().Language) {
+			if err := writePromptTemplate(dir, template); err != nil {
+				fmt.Printf("写入内置模板失败: %v\n", err)
+			}
+		}
+	}
+
+	return dir
+}
+
+// writePromptTemplate 将模板写入 <id>.json
+func writePromptTemplate(dir string, template PromptTemplate) error {
+	data, err := json.MarshalIndent(template, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, template.ID+".json"), data, 0644)
+}
+
+// GetPromptTemplates 获取提示词模板，language 不为空时只返回适用于该语言的模板
+func (a *App) GetPromptTemplates(language string) []PromptTemplate {
+	templates := []PromptTemplate{}
+
+	files, _ := filepath.Glob(filepath.Join(a.getPromptsDir(), "*.json"))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+
+		var template PromptTemplate
+		if err := json.Unmarshal(data, &template); err != nil {
+			fmt.Printf("解析模板 %s 失败: %v\n", file, err)
+			continue
+		}
+		// 以文件名为准，避免手工编辑后ID与文件名不一致
+		template.ID = strings.TrimSuffix(filepath.Base(file), ".json")
+
+		if language != "" && !promptAppliesTo(template, language) {
+			continue
+		}
+		templates = append(templates, template)
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return templates
+}
+
+// promptAppliesTo 判断模板是否适用于指定语言
+func promptAppliesTo(template PromptTemplate, language string) bool {
+	if len(template.Languages) == 0 {
+		return true
+	}
+	for _, lang := range template.Languages {
+		if strings.EqualFold(lang, language) {
+			return true
+		}
+	}
+	return false
+}
+
+// SavePromptTemplate 新建或更新提示词模板
+func (a *App) SavePromptTemplate(template PromptTemplate) error {
+	template.ID = strings.TrimSpace(template.ID)
+	if !promptTemplateID.MatchString(template.ID) {
+		return fmt.Errorf("模板ID只能包含字母、数字、下划线和短横线")
+	}
+	if strings.TrimSpace(template.Name) == "" {
+		return fmt.Errorf("模板名称不能为空")
+	}
+	if strings.TrimSpace(template.Template) == "" {
+		return fmt.Errorf("模板内容不能为空")
+	}
+
+	// 模板中引用的参数必须已定义
+	defined := map[string]bool{}
+	for _, param := range template.Parameters {
+		defined[param.Name] = true
+	}
+	for _, match := range promptPlaceholder.FindAllStringSubmatch(template.Template, -1) {
+		if !defined[match[1]] {
+			return fmt.Errorf("模板引用了未定义的参数: %s", match[1])
+		}
+	}
+
+	return writePromptTemplate(a.getPromptsDir(), template)
+}
+
+// DeletePromptTemplate 删除提示词模板
+func (a *App) DeletePromptTemplate(id string) error {
+	if !promptTemplateID.MatchString(id) {
+		return fmt.Errorf("无效的模板ID: %s", id)
+	}
+
+	if err := os.Remove(filepath.Join(a.getPromptsDir(), id+".json")); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("未找到模板: %s", id)
+		}
+		return err
+	}
+	return nil
+}
+
+// RenderPromptTemplate 用参数值填充模板，返回可直接发送的提问
+// 未填写的参数使用默认值，必填参数为空时返回错误
+func (a *App) RenderPromptTemplate(id string, values map[string]string) (string, error) {
+	var template *PromptTemplate
+	for _, t := range a.GetPromptTemplates("") {
+		if t.ID == id {
+			template = &t
+			break
+		}
+	}
+	if template == nil {
+		return "", fmt.Errorf("未找到模板: %s", id)
+	}
+
+	resolved := map[string]string{}
+	for _, param := range template.Parameters {
+		value := strings.TrimSpace(values[param.Name])
+		if value == "" {
+			value = param.Default
+		}
+		if value == "" && !param.Optional {
+			label := param.Label
+			if label == "" {
+				label = param.Name
+			}
+			return "", fmt.Errorf("请填写参数: %s", label)
+		}
+		resolved[param.Name] = value
+	}
+
+	return promptPlaceholder.ReplaceAllStringFunc(template.Template, func(match string) string {
+		name := promptPlaceholder.FindStringSubmatch(match)[1]
+		return resolved[name]
+	}), nil
+}
+
+// builtinPromptTemplates 首次使用时写入的内置模板，按界面语言选择文案
+func builtinPromptTemplates(language string) []PromptTemplate {
+	if language == "en" {
+		return []PromptTemplate{
+			{
+				ID:          "explain-compiler-error",
+				Name:        "Explain a compiler error",
+				Description: "Explain the cause of a compiler or runtime error and how to fix it",
+				Parameters: []PromptParameter{
+					{Name: "language", Label: "Language"},
+					{Name: "error", Label: "Error output", Multiline: true},
+					{Name: "code", Label: "Related code", Multiline: true, Optional: true},
+				},
+				Template: "Explain the following {{language}} error, what causes it and how to fix it.\n\nError:\n```\n{{error}}\n```\n\nRelated code:\n```\n{{code}}\n```",
+			},
+			{
+				ID:          "translate-snippet",
+				Name:        "Translate a snippet",
+				Description: "Translate code from one language to another",
+				Parameters: []PromptParameter{
+					{Name: "from", Label: "Source language"},
+					{Name: "to", Label: "Target language"},
+					{Name: "code", Label: "Code", Multiline: true},
+				},
+				Template: "Translate the following {{from}} code to idiomatic {{to}}. Mention any libraries needed and behaviour that differs.\n\n```\n{{code}}\n```",
+			},
+			{
+				ID:          "write-unit-test",
+				Name:        "Write a unit test",
+				Description: "Write unit tests for a function",
+				Parameters: []PromptParameter{
+					{Name: "language", Label: "Language"},
+					{Name: "framework", Label: "Test framework", Optional: true},
+					{Name: "code", Label: "Function", Multiline: true},
+				},
+				Template: "Write unit tests in {{language}} for the following function using {{framework}} (use the standard framework if empty). Cover normal cases, edge cases and errors.\n\n```\n{{code}}\n```",
+			},
+			{
+				ID:          "compare-packages",
+				Name:        "Compare two packages",
+				Description: "Compare two packages for the same task",
+				Parameters: []PromptParameter{
+					{Name: "language", Label: "Language"},
+					{Name: "first", Label: "First package"},
+					{Name: "second", Label: "Second package"},
+				},
+				Template: "Compare the {{language}} packages {{first}} and {{second}}: features, performance, maintenance activity, licence and when to choose each.",
+			},
+		}
+	}
+
+	if language == "ru" {
+		return []PromptTemplate{
+			{
+				ID:          "explain-compiler-error",
+				Name:        "Объяснить ошибку компилятора",
+				Description: "Объяснить причину ошибки компиляции или выполнения и способ исправления",
+				Parameters: []PromptParameter{
+					{Name: "language", Label: "Язык"},
+					{Name: "error", Label: "Текст ошибки", Multiline: true},
+					{Name: "code", Label: "Связанный код", Multiline: true, Optional: true},
+				},
+				Template: "Объясните следующую ошибку {{language}}: в чём причина и как её исправить.\n\nОшибка:\n```\n{{error}}\n```\n\nСвязанный код:\n```\n{{code}}\n```",
+			},
+			{
+				ID:          "translate-snippet",
+				Name:        "Перевести фрагмент кода",
+				Description: "Перевести код с одного языка на другой",
+				Parameters: []PromptParameter{
+					{Name: "from", Label: "Исходный язык"},
+					{Name: "to", Label: "Целевой язык"},
+					{Name: "code", Label: "Код", Multiline: true},
+				},
+				Template: "Переведите следующий код с {{from}} на {{to}} в идиоматичном стиле. Укажите нужные библиотеки и отличия в поведении.\n\n```\n{{code}}\n```",
+			},
+			{
+				ID:          "write-unit-test",
+				Name:        "Написать модульный тест",
+				Description: "Написать модульные тесты для функции",
+				Parameters: []PromptParameter{
+					{Name: "language", Label: "Язык"},
+					{Name: "framework", Label: "Тестовый фреймворк", Optional: true},
+					{Name: "code", Label: "Функция", Multiline: true},
+				},
+				Template: "Напишите модульные тесты на {{language}} для следующей функции с использованием {{framework}} (если не указано — стандартный фреймворк). Покройте обычные, граничные и ошибочные случаи.\n\n```\n{{code}}\n```",
+			},
+			{
+				ID:          "compare-packages",
+				Name:        "Сравнить два пакета",
+				Description: "Сравнить два пакета для одной задачи",
+				Parameters: []PromptParameter{
+					{Name: "language", Label: "Язык"},
+					{Name: "first", Label: "Первый пакет"},
+					{Name: "second", Label: "Второй пакет"},
+				},
+				Template: "Сравните пакеты {{language}} {{first}} и {{second}}: возможности, производительность, активность поддержки, лицензия и когда выбирать каждый.",
+			},
+		}
+	}
+
+	return []PromptTemplate{
+		{
+			ID:          "explain-compiler-error",
+			Name:        "解释编译错误",
+			Description: "解释编译或运行时错误的原因和修复方法",
+			Parameters: []PromptParameter{
+				{Name: "language", Label: "语言"},
+				{Name: "error", Label: "错误信息", Multiline: true},
+				{Name: "code", Label: "相关代码", Multiline: true, Optional: true},
+			},
+			Template: "请解释下面的 {{language}} 错误的原因，并给出修复方法。\n\n错误信息：\n```\n{{error}}\n```\n\n相关代码：\n```\n{{code}}\n```",
+		},
+		{
+			ID:          "translate-snippet",
+			Name:        "翻译代码片段",
+			Description: "把代码从一种语言翻译为另一种语言",
+			Parameters: []PromptParameter{
+				{Name: "from", Label: "源语言"},
+				{Name: "to", Label: "目标语言"},
+				{Name: "code", Label: "代码", Multiline: true},
+			},
+			Template: "请把下面的 {{from}} 代码翻译为符合 {{to}} 习惯的写法，并说明需要的库以及行为上的差异。\n\n```\n{{code}}\n```",
+		},
+		{
+			ID:          "write-unit-test",
+			Name:        "编写单元测试",
+			Description: "为函数编写单元测试",
+			Parameters: []PromptParameter{
+				{Name: "language", Label: "语言"},
+				{Name: "framework", Label: "测试框架", Optional: true},
+				{Name: "code", Label: "函数", Multiline: true},
+			},
+			Template: "请使用 {{framework}}（为空时使用 {{language}} 的标准测试框架）为下面的 {{language}} 函数编写单元测试，覆盖正常情况、边界情况和错误情况。\n\n```\n{{code}}\n```",
+		},
+		{
+			ID:          "compare-packages",
+			Name:        "比较两个包",
+			Description: "比较完成同一任务的两个包",
+			Parameters: []PromptParameter{
+				{Name: "language", Label: "语言"},
+				{Name: "first", Label: "第一个包"},
+				{Name: "second", Label: "第二个包"},
+			},
+			Template: "请比较 {{language}} 的 {{first}} 和 {{second}} 两个包：功能、性能、维护活跃度、许可证，以及各自适合的场景。",
+		},
+	}
+}
@@ -0,0 +1,916 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultAISystemPrompt 默认的系统提示词
+const defaultAISystemPrompt = "你是一个专注于编程开发问题的AI助手，请提供准确、具体的编程帮助。"
+
+// localizedSystemPrompts 按界面语言选择的默认系统提示词
+var localizedSystemPrompts = map[string]string{
+	"zh": defaultAISystemPrompt,
+	"en": "You are an AI assistant focused on programming questions. Give accurate, concrete help and answer in English.",
+	"ru": "Вы — ИИ-помощник по вопросам программирования. Давайте точные и конкретные ответы на русском языке.",
+}
+
+// ChatMessage 表示一条对话消息
+// 工具调用相关字段的格式因提供商而异，由各提供商在构建请求时转换
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	// ToolCalls 助手消息中请求的工具调用
+	ToolCalls []AIToolCall `json:"-"`
+	// ToolCallID 角色为 tool 的消息对应的工具调用ID
+	ToolCallID string `json:"-"`
+}
+
+// ChatRequest 描述一次发送给AI提供商的对话请求
+type ChatRequest struct {
+	Endpoint     string
+	APIKey       string
+	SystemPrompt string
+	Messages     []ChatMessage
+	Model        string
+	Temperature  *float64
+	MaxTokens    int
+	ExtraHeaders map[string]string
+	// Tools 允许模型调用的工具，为空时不发送工具定义
+	Tools []AIToolSpec
+	// Credentials 提供商的结构化凭据（已填入默认值）
+	Credentials map[string]string
+	// AccessToken 通过 AITokenExchanger 换取的访问令牌
+	AccessToken string
+}
+
+// AIProvider 是AI提供商的统一接口
+// 新增提供商时只需实现该接口并在 aiProviderRegistry 中注册
+type AIProvider interface {
+	// Info 返回提供商的展示信息
+	Info() AIProviderInfo
+	// NewRequest 构建HTTP请求，stream为true时请求流式响应
+	NewRequest(ctx context.Context, req ChatRequest, stream bool) (*http.Request, error)
+	// ParseResponse 解析非流式响应体，返回回复内容
+	ParseResponse(body []byte) (string, error)
+	// ParseStreamEvent 解析一条流式事件的数据，返回增量文本和是否结束
+	ParseStreamEvent(data []byte) (string, bool, error)
+}
+
+// AIModelLister 由提供模型列表接口的提供商实现
+type AIModelLister interface {
+	ListModels(ctx context.Context, req ChatRequest) ([]string, error)
+}
+
+// AIToolCaller 由支持工具调用（function calling）的提供商实现
+type AIToolCaller interface {
+	// SupportsTools 判断该请求的端点是否支持工具调用
+	SupportsTools(req ChatRequest) bool
+	// ParseToolResponse 解析非流式响应，返回回复文本和模型请求的工具调用
+	ParseToolResponse(body []byte) (string, []AIToolCall, error)
+}
+
+// aiProviderRegistry 按展示顺序注册的AI提供商
+var aiProviderRegistry = []AIProvider{
+	&openAICompatibleProvider{
+		info: AIProviderInfo{
+			ID:            "openai",
+			Name:          "OpenAI (ChatGPT)",
+			EndpointURL:   "https://api.openai.com/v1/chat/completions",
+			DocumentURL:   "https://platform.openai.com/docs/api-reference",
+			ApiKeyHeader:  "Authorization",
+			ApiKeyPrefix:  "Bearer ",
+			ContextWindow: 16385,
+			DefaultModel:  "gpt-3.5-turbo",
+			Models:        []string{"gpt-4o", "gpt-4o-mini", "gpt-4-turbo", "gpt-3.5-turbo"},
+		},
+	},
+	&azureOpenAIProvider{
+		openAICompatibleProvider{
+			info: AIProviderInfo{
+				ID:            "azure_openai",
+				Name:          "Azure OpenAI",
+				EndpointURL:   "https://YOUR_RESOURCE_NAME.openai.azure.com/openai/deployments/YOUR_DEPLOYMENT_NAME/chat/completions?api-version=2023-05-15",
+				DocumentURL:   "https://learn.microsoft.com/zh-cn/azure/ai-services/openai/reference",
+				ApiKeyHeader:  "api-key",
+				ApiKeyPrefix:  "",
+				ContextWindow: 8192,
+				CredentialFields: []AICredentialField{
+					{Name: "resource", Label: "资源名称", Placeholder: "my-resource"},
+					{Name: "deployment", Label: "部署名称", Placeholder: "gpt-4o"},
+					{Name: "apiVersion", Label: "API版本", Default: "2024-02-01"},
+				},
+			},
+		},
+	},
+	&anthropicProvider{
+		info: AIProviderInfo{
+			ID:               "anthropic",
+			Name:             "Anthropic (Claude)",
+			EndpointURL:      "https://api.anthropic.com/v1/messages",
+			DocumentURL:      "https://docs.anthropic.com/claude/reference/getting-started-with-the-api",
+			ApiKeyHeader:     "x-api-key",
+			ApiKeyPrefix:     "",
+			ContextWindow:    200000,
+			DefaultModel:     "claude-3-haiku-20240307",
+			DefaultMaxTokens: 4096,
+			Models:           []string{"claude-3-5-sonnet-latest", "claude-3-5-haiku-latest", "claude-3-opus-latest", "claude-3-haiku-20240307"},
+		},
+	},
+	&baiduProvider{
+		info: AIProviderInfo{
+			ID:          "baidu",
+			Name:        "百度文心一言",
+			EndpointURL: "https://aip.baidubce.com/rpc/2.0/ai_custom/v1/wenxinworkshop/chat/completions",
+			DocumentURL: "https://cloud.baidu.com/doc/WENXINWORKSHOP/s/jlil56u11",
+			// 文心一言通过URL中的access_token认证，API Key和Secret Key用于换取令牌
+			ApiKeyHeader:  "",
+			ApiKeyPrefix:  "",
+			ContextWindow: 8000,
+			DefaultModel:  "completions",
+			Models:        []string{"completions", "completions_pro", "ernie-3.5-8k", "ernie-speed-128k"},
+			CredentialFields: []AICredentialField{
+				{Name: "secretKey", Label: "Secret Key", Placeholder: "留空时将API Key作为access_token直接使用", Secret: true},
+			},
+		},
+	},
+	&aliyunProvider{
+		info: AIProviderInfo{
+			ID:            "aliyun",
+			Name:          "阿里通义千问",
+			EndpointURL:   "https://dashscope.aliyuncs.com/api/v1/services/aigc/text-generation/generation",
+			DocumentURL:   "https://help.aliyun.com/document_detail/2400395.html",
+			ApiKeyHeader:  "Authorization",
+			ApiKeyPrefix:  "Bearer ",
+			ContextWindow: 30000,
+			DefaultModel:  "qwen-max",
+			Models:        []string{"qwen-max", "qwen-plus", "qwen-turbo", "qwen-long"},
+		},
+	},
+	&localModelProvider{
+		openAICompatibleProvider{
+			info: AIProviderInfo{
+				ID:            "local",
+				Name:          "本地模型 (Ollama / llama.cpp)",
+				EndpointURL:   "http://localhost:11434/api/chat",
+				DocumentURL:   "https://github.com/ollama/ollama/blob/main/docs/api.md",
+				ContextWindow: 8192,
+				DefaultModel:  "llama3.2",
+				Local:         true,
+			},
+		},
+	},
+	&openAICompatibleProvider{
+		info: AIProviderInfo{
+			ID:            "custom",
+			Name:          "自定义API",
+			EndpointURL:   "",
+			DocumentURL:   "",
+			ApiKeyHeader:  "Authorization",
+			ApiKeyPrefix:  "Bearer ",
+			ContextWindow: 8192,
+			DefaultModel:  "gpt-3.5-turbo",
+		},
+		rawFallback: true,
+	},
+}
+
+// findAIProvider 按ID查找已注册的AI提供商
+func findAIProvider(providerID string) (AIProvider, bool) {
+	for _, provider := range aiProviderRegistry {
+		if provider.Info().ID == providerID {
+			return provider, true
+		}
+	}
+	for _, provider := range userAIProviders() {
+		if provider.Info().ID == providerID {
+			return provider, true
+		}
+	}
+	return nil, false
+}
+
+// newJSONRequest 创建带JSON请求体和API密钥的POST请求
+func newJSONRequest(ctx context.Context, info AIProviderInfo, req ChatRequest, body interface{}) (*http.Request, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("准备请求失败: %v", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", req.Endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %v", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if info.ApiKeyHeader != "" && req.APIKey != "" {
+		httpReq.Header.Set(info.ApiKeyHeader, info.ApiKeyPrefix+req.APIKey)
+	}
+	for key, value := range req.ExtraHeaders {
+		httpReq.Header.Set(key, value)
+	}
+
+	return httpReq, nil
+}
+
+// listModelsFromEndpoint 请求OpenAI格式的模型列表接口（返回 {"data": [{"id": ...}]}）
+func listModelsFromEndpoint(ctx context.Context, url string, headers map[string]string) ([]string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range headers {
+		httpReq.Header.Set(key, value)
+	}
+
+	resp, err := newHTTPClient(15 * time.Second).Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("获取模型列表失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取模型列表失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("获取模型列表失败 (状态码: %d): %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析模型列表失败: %v", err)
+	}
+
+	models := []string{}
+	for _, model := range result.Data {
+		models = append(models, model.ID)
+	}
+	sort.Strings(models)
+	return models, nil
+}
+
+// authHeaders 返回包含API密钥和附加请求头的请求头集合
+func authHeaders(info AIProviderInfo, req ChatRequest) map[string]string {
+	headers := map[string]string{}
+	if info.ApiKeyHeader != "" && req.APIKey != "" {
+		headers[info.ApiKeyHeader] = info.ApiKeyPrefix + req.APIKey
+	}
+	for key, value := range req.ExtraHeaders {
+		headers[key] = value
+	}
+	return headers
+}
+
+// applyGenerationSettings 写入OpenAI格式的温度和最大token数
+func applyGenerationSettings(body map[string]interface{}, req ChatRequest) {
+	if req.Temperature != nil {
+		body["temperature"] = *req.Temperature
+	}
+	if req.MaxTokens > 0 {
+		body["max_tokens"] = req.MaxTokens
+	}
+}
+
+// withSystemMessage 在消息列表前加入系统提示词
+func withSystemMessage(req ChatRequest) []ChatMessage {
+	messages := make([]ChatMessage, 0, len(req.Messages)+1)
+	if req.SystemPrompt != "" {
+		messages = append(messages, ChatMessage{Role: "system", Content: req.SystemPrompt})
+	}
+	return append(messages, req.Messages...)
+}
+
+// openAICompatibleProvider 实现OpenAI Chat Completions格式的提供商
+type openAICompatibleProvider struct {
+	info AIProviderInfo
+	// rawFallback 为true时，无法解析的响应按原文返回
+	rawFallback bool
+}
+
+func (p *openAICompatibleProvider) Info() AIProviderInfo {
+	return p.info
+}
+
+func (p *openAICompatibleProvider) NewRequest(ctx context.Context, req ChatRequest, stream bool) (*http.Request, error) {
+	body := map[string]interface{}{
+		"messages": openAIMessages(req),
+	}
+	if req.Model != "" {
+		body["model"] = req.Model
+	}
+	applyGenerationSettings(body, req)
+	applyOpenAITools(body, req)
+	if stream {
+		body["stream"] = true
+		// OpenAI默认不在流式响应中返回用量
+		if p.info.ID == "openai" {
+			body["stream_options"] = map[string]bool{"include_usage": true}
+		}
+	}
+	return newJSONRequest(ctx, p.info, req, body)
+}
+
+// ListModels 请求与对话接口同一前缀下的 /models 接口
+func (p *openAICompatibleProvider) ListModels(ctx context.Context, req ChatRequest) ([]string, error) {
+	base := strings.TrimSuffix(req.Endpoint, "/chat/completions")
+	if base == req.Endpoint || base == "" {
+		return nil, fmt.Errorf("无法从端点推断模型列表地址: %s", req.Endpoint)
+	}
+	return listModelsFromEndpoint(ctx, base+"/models", authHeaders(p.info, req))
+}
+
+func (p *openAICompatibleProvider) ParseResponse(body []byte) (string, error) {
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || len(resp.Choices) == 0 {
+		if p.rawFallback {
+			return string(body), nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("解析响应失败: %v", err)
+		}
+		return "", fmt.Errorf("解析响应失败: 响应中没有回复内容")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+func (p *openAICompatibleProvider) SupportsTools(req ChatRequest) bool {
+	return true
+}
+
+func (p *openAICompatibleProvider) ParseToolResponse(body []byte) (string, []AIToolCall, error) {
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content   string `json:"content"`
+				ToolCalls []struct {
+					ID       string `json:"id"`
+					Function struct {
+						Name      string `json:"name"`
+						Arguments string `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || len(resp.Choices) == 0 {
+		content, err := p.ParseResponse(body)
+		return content, nil, err
+	}
+
+	message := resp.Choices[0].Message
+	calls := []AIToolCall{}
+	for _, call := range message.ToolCalls {
+		calls = append(calls, AIToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		})
+	}
+	return message.Content, calls, nil
+}
+
+// openAIMessages 构建OpenAI格式的消息列表，包括工具调用和工具结果
+func openAIMessages(req ChatRequest) []map[string]interface{} {
+	messages := []map[string]interface{}{}
+	for _, message := range withSystemMessage(req) {
+		item := map[string]interface{}{
+			"role":    message.Role,
+			"content": message.Content,
+		}
+		if message.Role == "tool" {
+			item["tool_call_id"] = message.ToolCallID
+		}
+		if len(message.ToolCalls) > 0 {
+			calls := []map[string]interface{}{}
+			for _, call := range message.ToolCalls {
+				calls = append(calls, map[string]interface{}{
+					"id":   call.ID,
+					"type": "function",
+					"function": map[string]string{
+						"name":      call.Name,
+						"arguments": call.Arguments,
+					},
+				})
+			}
+			item["tool_calls"] = calls
+		}
+		messages = append(messages, item)
+	}
+	return messages
+}
+
+// applyOpenAITools 写入OpenAI格式的工具定义
+func applyOpenAITools(body map[string]interface{}, req ChatRequest) {
+	if len(req.Tools) == 0 {
+		return
+	}
+
+	tools := []map[string]interface{}{}
+	for _, tool := range req.Tools {
+		tools = append(tools, map[string]interface{}{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":        tool.Name,
+				"description": tool.Description,
+				"parameters":  tool.Parameters,
+			},
+		})
+	}
+	body["tools"] = tools
+}
+
+func (p *openAICompatibleProvider) ParseStreamEvent(data []byte) (string, bool, error) {
+	var chunk struct {
+		Choices []struct {
+			Delta struct {
+				Content string `json:"content"`
+			} `json:"delta"`
+			FinishReason *string `json:"finish_reason"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return "", false, fmt.Errorf("解析流式响应失败: %v", err)
+	}
+	if len(chunk.Choices) == 0 {
+		return "", false, nil
+	}
+	choice := chunk.Choices[0]
+	return choice.Delta.Content, choice.FinishReason != nil && *choice.FinishReason != "", nil
+}
+
+// azureOpenAIProvider Azure OpenAI使用部署名确定模型，请求体中不包含model字段
+type azureOpenAIProvider struct {
+	openAICompatibleProvider
+}
+
+// ListModels Azure的模型由部署决定，没有可用的模型列表
+func (p *azureOpenAIProvider) ListModels(ctx context.Context, req ChatRequest) ([]string, error) {
+	return nil, fmt.Errorf("Azure OpenAI的模型由部署名决定，请在端点中指定部署")
+}
+
+// EndpointFromCredentials 根据资源名、部署名和API版本拼出对话接口地址
+func (p *azureOpenAIProvider) EndpointFromCredentials(credentials map[string]string) string {
+	resource := credentials["resource"]
+	deployment := credentials["deployment"]
+	if resource == "" || deployment == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://%s.openai.azure.com/openai/deployments/%s/chat/completions?api-version=%s",
+		url.PathEscape(resource), url.PathEscape(deployment), url.QueryEscape(credentials["apiVersion"]))
+}
+
+func (p *azureOpenAIProvider) NewRequest(ctx context.Context, req ChatRequest, stream bool) (*http.Request, error) {
+	body := map[string]interface{}{
+		"messages": openAIMessages(req),
+	}
+	applyGenerationSettings(body, req)
+	applyOpenAITools(body, req)
+	if stream {
+		body["stream"] = true
+	}
+	return newJSONRequest(ctx, p.info, req, body)
+}
+
+// anthropicProvider 实现Anthropic Messages API
+type anthropicProvider struct {
+	info AIProviderInfo
+}
+
+func (p *anthropicProvider) Info() AIProviderInfo {
+	return p.info
+}
+
+func (p *anthropicProvider) NewRequest(ctx context.Context, req ChatRequest, stream bool) (*http.Request, error) {
+	// Anthropic要求必须指定max_tokens
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = p.info.DefaultMaxTokens
+	}
+
+	body := map[string]interface{}{
+		"model":      req.Model,
+		"max_tokens": maxTokens,
+		"messages":   anthropicMessages(req.Messages),
+	}
+	if req.SystemPrompt != "" {
+		body["system"] = req.SystemPrompt
+	}
+	if req.Temperature != nil {
+		body["temperature"] = *req.Temperature
+	}
+	if len(req.Tools) > 0 {
+		tools := []map[string]interface{}{}
+		for _, tool := range req.Tools {
+			tools = append(tools, map[string]interface{}{
+				"name":         tool.Name,
+				"description":  tool.Description,
+				"input_schema": tool.Parameters,
+			})
+		}
+		body["tools"] = tools
+	}
+	if stream {
+		body["stream"] = true
+	}
+
+	httpReq, err := newJSONRequest(ctx, p.info, req, body)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("anthropic-version", "2023-06-01")
+	return httpReq, nil
+}
+
+// ListModels 请求Anthropic的 /v1/models 接口
+func (p *anthropicProvider) ListModels(ctx context.Context, req ChatRequest) ([]string, error) {
+	base := strings.TrimSuffix(req.Endpoint, "/messages")
+	headers := authHeaders(p.info, req)
+	headers["anthropic-version"] = "2023-06-01"
+	return listModelsFromEndpoint(ctx, base+"/models", headers)
+}
+
+func (p *anthropicProvider) ParseResponse(body []byte) (string, error) {
+	var resp struct {
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("解析响应失败: %v", err)
+	}
+	if len(resp.Content) == 0 {
+		return "", fmt.Errorf("解析响应失败: 响应中没有回复内容")
+	}
+	return resp.Content[0].Text, nil
+}
+
+func (p *anthropicProvider) SupportsTools(req ChatRequest) bool {
+	return true
+}
+
+func (p *anthropicProvider) ParseToolResponse(body []byte) (string, []AIToolCall, error) {
+	var resp struct {
+		Content []struct {
+			Type  string          `json:"type"`
+			Text  string          `json:"text"`
+			ID    string          `json:"id"`
+			Name  string          `json:"name"`
+			Input json.RawMessage `json:"input"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", nil, fmt.Errorf("解析响应失败: %v", err)
+	}
+
+	var text strings.Builder
+	calls := []AIToolCall{}
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "tool_use":
+			calls = append(calls, AIToolCall{
+				ID:        block.ID,
+				Name:      block.Name,
+				Arguments: string(block.Input),
+			})
+		}
+	}
+	return text.String(), calls, nil
+}
+
+// anthropicMessages 构建Anthropic格式的消息列表
+// 工具调用转换为 tool_use 内容块，连续的工具结果合并为一条用户消息中的 tool_result 内容块
+func anthropicMessages(messages []ChatMessage) []interface{} {
+	result := []interface{}{}
+	var toolResults []map[string]interface{}
+
+	flushToolResults := func() {
+		if len(toolResults) > 0 {
+			result = append(result, map[string]interface{}{
+				"role":    "user",
+				"content": toolResults,
+			})
+			toolResults = nil
+		}
+	}
+
+	for _, message := range messages {
+		if message.Role == "tool" {
+			toolResults = append(toolResults, map[string]interface{}{
+				"type":        "tool_result",
+				"tool_use_id": message.ToolCallID,
+				"content":     message.Content,
+			})
+			continue
+		}
+		flushToolResults()
+
+		if len(message.ToolCalls) == 0 {
+			result = append(result, message)
+			continue
+		}
+
+		blocks := []map[string]interface{}{}
+		if message.Content != "" {
+			blocks = append(blocks, map[string]interface{}{"type": "text", "text": message.Content})
+		}
+		for _, call := range message.ToolCalls {
+			input := json.RawMessage(call.Arguments)
+			if !json.Valid(input) {
+				input = json.RawMessage("{}")
+			}
+			blocks = append(blocks, map[string]interface{}{
+				"type":  "tool_use",
+				"id":    call.ID,
+				"name":  call.Name,
+				"input": input,
+			})
+		}
+		result = append(result, map[string]interface{}{
+			"role":    message.Role,
+			"content": blocks,
+		})
+	}
+	flushToolResults()
+
+	return result
+}
+
+func (p *anthropicProvider) ParseStreamEvent(data []byte) (string, bool, error) {
+	var event struct {
+		Type  string `json:"type"`
+		Delta struct {
+			Text string `json:"text"`
+		} `json:"delta"`
+		Error struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return "", false, fmt.Errorf("解析流式响应失败: %v", err)
+	}
+
+	switch event.Type {
+	case "content_block_delta":
+		return event.Delta.Text, false, nil
+	case "message_stop":
+		return "", true, nil
+	case "error":
+		return "", true, &AIError{Kind: anthropicErrorKind(event.Error.Type), Detail: event.Error.Message}
+	}
+	return "", false, nil
+}
+
+// baiduProvider 实现百度文心一言API
+type baiduProvider struct {
+	info AIProviderInfo
+}
+
+func (p *baiduProvider) Info() AIProviderInfo {
+	return p.info
+}
+
+func (p *baiduProvider) NewRequest(ctx context.Context, req ChatRequest, stream bool) (*http.Request, error) {
+	// 文心一言通过URL的最后一段选择模型
+	if req.Model != "" && req.Endpoint == p.info.EndpointURL {
+		req.Endpoint = req.Endpoint[:strings.LastIndex(req.Endpoint, "/")+1] + req.Model
+	}
+
+	// 没有配置Secret Key时API Key本身就是access_token
+	accessToken := req.AccessToken
+	if accessToken == "" {
+		accessToken = req.APIKey
+	}
+	if accessToken != "" {
+		endpoint, err := url.Parse(req.Endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("端点地址无效: %v", err)
+		}
+		query := endpoint.Query()
+		query.Set("access_token", accessToken)
+		endpoint.RawQuery = query.Encode()
+		req.Endpoint = endpoint.String()
+	}
+
+	body := map[string]interface{}{
+		"messages": req.Messages,
+	}
+	if req.SystemPrompt != "" {
+		body["system"] = req.SystemPrompt
+	}
+	if req.Temperature != nil {
+		body["temperature"] = *req.Temperature
+	}
+	if req.MaxTokens > 0 {
+		body["max_output_tokens"] = req.MaxTokens
+	}
+	if stream {
+		body["stream"] = true
+	}
+	return newJSONRequest(ctx, p.info, req, body)
+}
+
+// baiduTokenURL 百度智能云OAuth换取access_token的接口
+const baiduTokenURL = "https://aip.baidubce.com/oauth/2.0/token"
+
+// ExchangeToken 使用API Key和Secret Key换取access_token，未配置Secret Key时直接使用API Key
+func (p *baiduProvider) ExchangeToken(ctx context.Context, apiKey string, credentials map[string]string) (string, time.Duration, error) {
+	secretKey := credentials["secretKey"]
+	if secretKey == "" {
+		return apiKey, 24 * time.Hour, nil
+	}
+
+	query := url.Values{}
+	query.Set("grant_type", "client_credentials")
+	query.Set("client_id", apiKey)
+	query.Set("client_secret", secretKey)
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", baiduTokenURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", 0, fmt.Errorf("创建请求失败: %v", err)
+	}
+
+	client := newHTTPClient(15 * time.Second)
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, fmt.Errorf("读取响应失败: %v", err)
+	}
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return "", 0, classifyHTTPError(resp.StatusCode, resp.Header, body)
+		}
+		return "", 0, fmt.Errorf("解析令牌响应失败: %v", err)
+	}
+
+	// invalid_client 表示API Key或Secret Key错误
+	if result.Error != "" {
+		kind := AIErrorBadRequest
+		if result.Error == "invalid_client" || result.Error == "unauthorized_client" {
+			kind = AIErrorAuth
+		}
+		return "", 0, &AIError{Kind: kind, StatusCode: resp.StatusCode, Detail: "换取access_token失败: " + result.ErrorDescription}
+	}
+	if result.AccessToken == "" {
+		return "", 0, &AIError{Kind: AIErrorUnknown, StatusCode: resp.StatusCode, Detail: "换取access_token失败: 响应中没有令牌"}
+	}
+
+	expiresIn := time.Duration(result.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = 24 * time.Hour
+	}
+	return result.AccessToken, expiresIn, nil
+}
+
+// baiduResult 是文心一言响应和流式事件的共同格式
+type baiduResult struct {
+	Result    string `json:"result"`
+	IsEnd     bool   `json:"is_end"`
+	ErrorCode int    `json:"error_code"`
+	ErrorMsg  string `json:"error_msg"`
+}
+
+func (p *baiduProvider) ParseResponse(body []byte) (string, error) {
+	var resp baiduResult
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("解析响应失败: %v", err)
+	}
+	if resp.ErrorCode != 0 {
+		return "", &AIError{Kind: baiduErrorKind(resp.ErrorCode), Detail: fmt.Sprintf("%d: %s", resp.ErrorCode, resp.ErrorMsg)}
+	}
+	return resp.Result, nil
+}
+
+func (p *baiduProvider) ParseStreamEvent(data []byte) (string, bool, error) {
+	var event baiduResult
+	if err := json.Unmarshal(data, &event); err != nil {
+		return "", false, fmt.Errorf("解析流式响应失败: %v", err)
+	}
+	if event.ErrorCode != 0 {
+		return "", true, &AIError{Kind: baiduErrorKind(event.ErrorCode), Detail: fmt.Sprintf("%d: %s", event.ErrorCode, event.ErrorMsg)}
+	}
+	return event.Result, event.IsEnd, nil
+}
+
+// aliyunProvider 实现阿里云DashScope（通义千问）API
+type aliyunProvider struct {
+	info AIProviderInfo
+}
+
+func (p *aliyunProvider) Info() AIProviderInfo {
+	return p.info
+}
+
+func (p *aliyunProvider) NewRequest(ctx context.Context, req ChatRequest, stream bool) (*http.Request, error) {
+	parameters := map[string]interface{}{
+		"result_format": "message",
+	}
+	if req.Temperature != nil {
+		parameters["temperature"] = *req.Temperature
+	}
+	if req.MaxTokens > 0 {
+		parameters["max_tokens"] = req.MaxTokens
+	}
+	if stream {
+		// 增量输出，每个事件只包含新生成的文本
+		parameters["incremental_output"] = true
+	}
+
+	body := map[string]interface{}{
+		"model": req.Model,
+		"input": map[string]interface{}{
+			"messages": withSystemMessage(req),
+		},
+		"parameters": parameters,
+	}
+
+	httpReq, err := newJSONRequest(ctx, p.info, req, body)
+	if err != nil {
+		return nil, err
+	}
+	if stream {
+		httpReq.Header.Set("X-DashScope-SSE", "enable")
+	}
+	return httpReq, nil
+}
+
+// ListModels 请求DashScope的OpenAI兼容模式模型列表接口
+func (p *aliyunProvider) ListModels(ctx context.Context, req ChatRequest) ([]string, error) {
+	return listModelsFromEndpoint(ctx, "https://dashscope.aliyuncs.com/compatible-mode/v1/models", authHeaders(p.info, req))
+}
+
+// aliyunResult 是DashScope响应和流式事件的共同格式
+type aliyunResult struct {
+	Output struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+	} `json:"output"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (p *aliyunProvider) ParseResponse(body []byte) (string, error) {
+	var resp aliyunResult
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("解析响应失败: %v", err)
+	}
+	if resp.Code != "" {
+		return "", &AIError{Kind: aliyunErrorKind(resp.Code), Detail: resp.Code + ": " + resp.Message}
+	}
+	if len(resp.Output.Choices) == 0 {
+		return "", fmt.Errorf("解析响应失败: 响应中没有回复内容")
+	}
+	return resp.Output.Choices[0].Message.Content, nil
+}
+
+func (p *aliyunProvider) ParseStreamEvent(data []byte) (string, bool, error) {
+	var event aliyunResult
+	if err := json.Unmarshal(data, &event); err != nil {
+		return "", false, fmt.Errorf("解析流式响应失败: %v", err)
+	}
+	if event.Code != "" {
+		return "", true, &AIError{Kind: aliyunErrorKind(event.Code), Detail: event.Code + ": " + event.Message}
+	}
+	if len(event.Output.Choices) == 0 {
+		return "", false, nil
+	}
+	choice := event.Output.Choices[0]
+	return choice.Message.Content, choice.FinishReason != "" && choice.FinishReason != "null", nil
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RedactionRule 内置的脱敏规则
+// 正则包含捕获组时只替换第一个捕获组（如 password=xxx 中的 xxx），否则替换整个匹配
+type RedactionRule struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+// RedactionPattern 用户自定义的脱敏规则
+type RedactionPattern struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+// RedactionConfig 脱敏设置
+type RedactionConfig struct {
+	// Enabled 为false时发送前不做任何脱敏
+	Enabled bool `json:"enabled"`
+	// DisabledRules 关闭的内置规则ID
+	DisabledRules  []string           `json:"disabledRules"`
+	CustomPatterns []RedactionPattern `json:"customPatterns"`
+	// BuiltinRules 仅用于返回给前端展示，不写入磁盘
+	BuiltinRules []RedactionRule `json:"builtinRules,omitempty"`
+}
+
+// RedactionMatch 一处被脱敏的内容
+type RedactionMatch struct {
+	Rule        string `json:"rule"`
+	Original    string `json:"original"`
+	Placeholder string `json:"placeholder"`
+}
+
+// RedactionResult 脱敏结果
+type RedactionResult struct {
+	Text    string           `json:"text"`
+	Matches []RedactionMatch `json:"matches"`
+}
+
+// builtinRedactionRules 内置规则按优先级排列，重叠时保留靠前规则的匹配
+var builtinRedactionRules = []RedactionRule{
+	{ID: "private_key", Name: "私钥", Pattern: `-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`},
+	{ID: "jwt", Name: "JWT", Pattern: `\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`},
+	{ID: "aws_access_key", Name: "AWS访问密钥", Pattern: `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`},
+	{ID: "aws_secret_key", Name: "AWS私有访问密钥", Pattern: `(?i)aws_secret_access_key["']?\s*[=:]\s*["']?([A-Za-z0-9/+=]{40})`},
+	{ID: "api_key", Name: "API密钥", Pattern: `\b(?:sk-(?:proj-|ant-)?[A-Za-z0-9_-]{20,}|ghp_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{22,}|glpat-[A-Za-z0-9_-]{20,}|xox[abprs]-[A-Za-z0-9-]{10,}|AIza[0-9A-Za-z_-]{35})`},
+	{ID: "bearer_token", Name: "Bearer令牌", Pattern: `(?i)\bbearer\s+([A-Za-z0-9._~+/-]{16,}=*)`},
+	{ID: "connection_string", Name: "连接字符串密码", Pattern: `\b[a-zA-Z][a-zA-Z0-9+.-]*://[^\s:/@]+:(\S+)@[^\s@/:]+`},
+	{ID: "secret_assignment", Name: "密码/令牌赋值", Pattern: `(?i)\b(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|client[_-]?secret)["']?\s*[=:]\s*["']?([^\s"'&;,]{4,})`},
+	{ID: "email", Name: "邮箱地址", Pattern: `\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`},
+	{ID: "internal_ip", Name: "内网IP", Pattern: `\b(?:10\.\d{1,3}\.\d{1,3}\.\d{1,3}|192\.168\.\d{1,3}\.\d{1,3}|172\.(?:1[6-9]|2\d|3[01])\.\d{1,3}\.\d{1,3})\b`},
+	{ID: "internal_host", Name: "内网主机名", Pattern: `(?i)\b[a-z0-9-]+(?:\.[a-z0-9-]+)*\.(?:internal|intranet|intra|corp|lan|local)\b`},
+}
+
+// compiledRedactionRule 编译后的脱敏规则
+type compiledRedactionRule struct {
+	id   string
+	name string
+	re   *regexp.Regexp
+}
+
+// getRedactionConfigPath 获取脱敏设置文件路径
+func (a *App) getRedactionConfigPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "ai_redaction.json"
+	}
+	return filepath.Join(homeDir, ".networ_tester", "ai_redaction.json")
+}
+
+// GetRedactionConfig 获取脱敏设置，文件不存在时默认开启所有内置规则
+func (a *App) GetRedactionConfig() RedactionConfig {
+	config := RedactionConfig{Enabled: true}
+
+	if data, err := os.ReadFile(a.getRedactionConfigPath()); err == nil {
+		if err := json.Unmarshal(data, &config); err != nil {
+			fmt.Printf("解析脱敏设置失败: %v\n", err)
+			config = RedactionConfig{Enabled: true}
+		}
+	}
+
+	if config.DisabledRules == nil {
+		config.DisabledRules = []string{}
+	}
+	if config.CustomPatterns == nil {
+		config.CustomPatterns = []RedactionPattern{}
+	}
+	config.BuiltinRules = builtinRedactionRules
+	return config
+}
+
+// SaveRedactionConfig 保存脱敏设置，自定义规则必须是有效的正则表达式
+func (a *App) SaveRedactionConfig(config RedactionConfig) error {
+	for i, pattern := range config.CustomPatterns {
+		if strings.TrimSpace(pattern.Pattern) == "" {
+			return fmt.Errorf("第 %d 条自定义规则的正则表达式为空", i+1)
+		}
+		if _, err := regexp.Compile(pattern.Pattern); err != nil {
+			return fmt.Errorf("自定义规则 %s 的正则表达式无效: %v", pattern.Name, err)
+		}
+		if strings.TrimSpace(pattern.Name) == "" {
+			config.CustomPatterns[i].Name = fmt.Sprintf("自定义规则%d", i+1)
+		}
+	}
+	config.BuiltinRules = nil
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	os.MkdirAll(filepath.Dir(a.getRedactionConfigPath()), 0755)
+	return os.WriteFile(a.getRedactionConfigPath(), data, 0644)
+}
+
+// redactionRules 按设置编译生效的规则，自定义规则排在内置规则之前
+func (a *App) redactionRules() []compiledRedactionRule {
+	config := a.GetRedactionConfig()
+	if !config.Enabled {
+		return nil
+	}
+
+	disabled := map[string]bool{}
+	for _, id := range config.DisabledRules {
+		disabled[id] = true
+	}
+
+	rules := []compiledRedactionRule{}
+	for i, pattern := range config.CustomPatterns {
+		re, err := regexp.Compile(pattern.Pattern)
+		if err != nil {
+			fmt.Printf("跳过无效的自定义脱敏规则 %s: %v\n", pattern.Name, err)
+			continue
+		}
+		rules = append(rules, compiledRedactionRule{id: fmt.Sprintf("custom_%d", i+1), name: pattern.Name, re: re})
+	}
+	for _, rule := range builtinRedactionRules {
+		if !disabled[rule.ID] {
+			rules = append(rules, compiledRedactionRule{id: rule.ID, name: rule.Name, re: regexp.MustCompile(rule.Pattern)})
+		}
+	}
+	return rules
+}
+
+// redactText 用占位符替换敏感内容，相同的原文使用相同的占位符，方便模型理解上下文
+// placeholders 在多条消息之间共享，为nil时只在本段文本内保持一致
+func redactText(text string, rules []compiledRedactionRule, placeholders map[string]string) RedactionResult {
+	result := RedactionResult{Text: text, Matches: []RedactionMatch{}}
+	if len(rules) == 0 || text == "" {
+		return result
+	}
+	if placeholders == nil {
+		placeholders = map[string]string{}
+	}
+
+	type span struct {
+		start, end int
+		rule       compiledRedactionRule
+	}
+	spans := []span{}
+	overlaps := func(start, end int) bool {
+		for _, s := range spans {
+			if start < s.end && s.start < end {
+				return true
+			}
+		}
+		return false
+	}
+
+	// 按规则优先级收集匹配，与已有匹配重叠的跳过
+	for _, rule := range rules {
+		for _, loc := range rule.re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[0], loc[1]
+			if len(loc) >= 4 && loc[2] >= 0 {
+				start, end = loc[2], loc[3]
+			}
+			if start == end || overlaps(start, end) {
+				continue
+			}
+			spans = append(spans, span{start: start, end: end, rule: rule})
+		}
+	}
+	if len(spans) == 0 {
+		return result
+	}
+
+	// 同一段敏感内容在其他位置出现时（如日志里重复打印的密码）也一并替换
+	found := len(spans)
+	for _, s := range spans[:found] {
+		original := text[s.start:s.end]
+		for offset := 0; ; {
+			index := strings.Index(text[offset:], original)
+			if index < 0 {
+				break
+			}
+			start := offset + index
+			if !overlaps(start, start+len(original)) {
+				spans = append(spans, span{start: start, end: start + len(original), rule: s.rule})
+			}
+			offset = start + len(original)
+		}
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var builder strings.Builder
+	last := 0
+	for _, s := range spans {
+		original := text[s.start:s.end]
+		placeholder, ok := placeholders[original]
+		if !ok {
+			kind := "[REDACTED_" + strings.ToUpper(s.rule.id) + "_"
+			count := 1
+			for _, existing := range placeholders {
+				if strings.HasPrefix(existing, kind) {
+					count++
+				}
+			}
+			placeholder = fmt.Sprintf("%s%d]", kind, count)
+			placeholders[original] = placeholder
+		}
+
+		builder.WriteString(text[last:s.start])
+		builder.WriteString(placeholder)
+		last = s.end
+
+		result.Matches = append(result.Matches, RedactionMatch{
+			Rule:        s.rule.name,
+			Original:    original,
+			Placeholder: placeholder,
+		})
+	}
+	builder.WriteString(text[last:])
+	result.Text = builder.String()
+	return result
+}
+
+// PreviewRedaction 预览发送前会被脱敏的内容
+func (a *App) PreviewRedaction(text string) RedactionResult {
+	return redactText(text, a.redactionRules(), nil)
+}
+
+// redactMessages 对将要发送的用户消息脱敏，返回新的消息列表，不修改原列表
+func (a *App) redactMessages(messages []ChatMessage) []ChatMessage {
+	rules := a.redactionRules()
+	if len(rules) == 0 {
+		return messages
+	}
+
+	placeholders := map[string]string{}
+	redacted := make([]ChatMessage, len(messages))
+	for i, message := range messages {
+		redacted[i] = message
+		if message.Role == "user" {
+			redacted[i].Content = redactText(message.Content, rules, placeholders).Text
+		}
+	}
+	return redacted
+}
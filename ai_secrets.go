@@ -0,0 +1,456 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// 加密密钥的来源
+const (
+	keySourceMachine    = "machine"
+	keySourcePassphrase = "passphrase"
+)
+
+// storedAIConfig 是写入磁盘的AI配置，API密钥只以密文形式保存
+type storedAIConfig struct {
+	AIConfig
+	EncryptedAPIKey string `json:"encryptedApiKey,omitempty"`
+	// EncryptedCredentials 加密后的提供商机密凭据（JSON格式的 提供商ID -> 字段 -> 值）
+	EncryptedCredentials string `json:"encryptedCredentials,omitempty"`
+	KeySource            string `json:"keySource,omitempty"`
+	Salt                 string `json:"salt,omitempty"`
+}
+
+// getSecretKeyPath 获取本机密钥文件路径
+func (a *App) getSecretKeyPath() string {
+	return filepath.Join(filepath.Dir(a.getAIConfigPath()), "secret.key")
+}
+
+// machineSecretSize 本机密钥的长度（AES-256）
+const machineSecretSize = 32
+
+// loadMachineSecret 读取本机密钥，不存在时生成一个仅当前用户可读写的新密钥
+// 已存在但长度不正确的密钥文件不会被覆盖，否则之前加密的数据将永远无法解密
+func (a *App) loadMachineSecret() ([]byte, error) {
+	a.secretMu.Lock()
+	defer a.secretMu.Unlock()
+
+	path := a.getSecretKeyPath()
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return validMachineSecret(path, data)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	secret := make([]byte, machineSecretSize)
+	if _, err := io.ReadFull(rand.Reader, secret); err != nil {
+		return nil, err
+	}
+
+	// O_EXCL 保证不会覆盖其他进程同时创建的密钥
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			return validMachineSecret(path, data)
+		}
+		return nil, err
+	}
+
+	if _, err := file.Write(secret); err != nil {
+		file.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+// validMachineSecret 检查密钥文件内容的长度
+func validMachineSecret(path string, data []byte) ([]byte, error) {
+	if len(data) != machineSecretSize {
+		return nil, fmt.Errorf("密钥文件 %s 已损坏（长度为%d字节，应为%d字节），请恢复该文件或删除后重新设置密钥", path, len(data), machineSecretSize)
+	}
+	return data, nil
+}
+
+// deriveEncryptionKey 根据密钥来源生成AES-256密钥
+func (a *App) deriveEncryptionKey(source string, salt []byte) ([]byte, error) {
+	secret, err := a.loadMachineSecret()
+	if err != nil {
+		return nil, fmt.Errorf("读取本机密钥失败: %v", err)
+	}
+
+	if source != keySourcePassphrase {
+		return secret, nil
+	}
+
+	a.secretMu.Lock()
+	defer a.secretMu.Unlock()
+
+	passphrase := a.aiPassphrase
+	if passphrase == "" {
+		return nil, fmt.Errorf("API密钥已使用口令加密，请先输入口令解锁")
+	}
+
+	// 口令和盐都未变化时直接使用缓存的密钥
+	if a.derivedKey != nil && a.derivedKeyFrom == passphrase && a.derivedKeySalt == string(salt) {
+		return a.derivedKey, nil
+	}
+
+	// 口令模式下也混入本机密钥，配置文件被拷贝到其他机器后无法单凭口令解密
+	key, err := scrypt.Key([]byte(passphrase), append(salt, secret...), 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	a.derivedKey = key
+	a.derivedKeyFrom = passphrase
+	a.derivedKeySalt = string(salt)
+	return key, nil
+}
+
+// encryptSecret 使用AES-GCM加密，返回base64编码的 nonce+密文
+func encryptSecret(key []byte, plaintext string) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptSecret 解密encryptSecret生成的密文
+func decryptSecret(key []byte, encoded string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("密文格式无效")
+	}
+
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("解密失败，口令错误或密钥文件已更改")
+	}
+
+	return string(plaintext), nil
+}
+
+// maskAPIKey 生成API密钥的脱敏预览
+func maskAPIKey(key string) string {
+	if key == "" {
+		return ""
+	}
+	if len(key) <= 8 {
+		return "****"
+	}
+	return key[:4] + "****" + key[len(key)-4:]
+}
+
+// isMaskedAPIKey 判断传入的密钥是否为脱敏预览
+func isMaskedAPIKey(key string) bool {
+	return strings.Contains(key, "****")
+}
+
+// isSecretCredential 判断提供商的凭据字段是否需要加密保存
+func isSecretCredential(providerID string, field string) bool {
+	provider, ok := findAIProvider(providerID)
+	if !ok {
+		return false
+	}
+	for _, f := range provider.Info().CredentialFields {
+		if f.Name == field {
+			return f.Secret
+		}
+	}
+	return false
+}
+
+// splitSecretCredentials 将凭据分为可明文保存的部分和需要加密的机密部分
+func splitSecretCredentials(credentials map[string]map[string]string) (plain map[string]map[string]string, secrets map[string]map[string]string) {
+	plain = map[string]map[string]string{}
+	secrets = map[string]map[string]string{}
+	for providerID, fields := range credentials {
+		for name, value := range fields {
+			target := plain
+			if isSecretCredential(providerID, name) {
+				if value == "" {
+					continue
+				}
+				target = secrets
+			}
+			if target[providerID] == nil {
+				target[providerID] = map[string]string{}
+			}
+			target[providerID][name] = value
+		}
+	}
+	return plain, secrets
+}
+
+// mergeCredentials 将解密后的机密凭据合并回配置
+func mergeCredentials(config *AIConfig, secrets map[string]map[string]string) {
+	if config.Credentials == nil {
+		config.Credentials = map[string]map[string]string{}
+	}
+	for providerID, fields := range secrets {
+		if config.Credentials[providerID] == nil {
+			config.Credentials[providerID] = map[string]string{}
+		}
+		for name, value := range fields {
+			config.Credentials[providerID][name] = value
+		}
+	}
+}
+
+// maskSecretCredentials 返回机密凭据已脱敏的副本
+func maskSecretCredentials(credentials map[string]map[string]string) map[string]map[string]string {
+	masked := map[string]map[string]string{}
+	for providerID, fields := range credentials {
+		masked[providerID] = map[string]string{}
+		for name, value := range fields {
+			if isSecretCredential(providerID, name) {
+				value = maskAPIKey(value)
+			}
+			masked[providerID][name] = value
+		}
+	}
+	return masked
+}
+
+// readStoredAIConfig 读取磁盘上的AI配置
+func (a *App) readStoredAIConfig() (storedAIConfig, error) {
+	stored := storedAIConfig{
+		AIConfig: AIConfig{SelectedProviderID: "openai"},
+	}
+
+	data, err := os.ReadFile(a.getAIConfigPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return stored, nil
+		}
+		return stored, err
+	}
+
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return storedAIConfig{AIConfig: AIConfig{SelectedProviderID: "openai"}}, err
+	}
+
+	return stored, nil
+}
+
+// writeStoredAIConfig 加密API密钥和机密凭据并写入磁盘，文件仅当前用户可读写
+// stored.Credentials 中的机密字段应为明文，写入前会被移出并加密
+func (a *App) writeStoredAIConfig(stored storedAIConfig, plainKey string) error {
+	if stored.KeySource == "" {
+		stored.KeySource = keySourceMachine
+	}
+
+	var secrets map[string]map[string]string
+	stored.Credentials, secrets = splitSecretCredentials(stored.Credentials)
+
+	if plainKey != "" || len(secrets) > 0 {
+		var salt []byte
+		if stored.KeySource == keySourcePassphrase {
+			salt = make([]byte, 16)
+			if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+				return err
+			}
+			stored.Salt = base64.StdEncoding.EncodeToString(salt)
+		} else {
+			stored.Salt = ""
+		}
+
+		key, err := a.deriveEncryptionKey(stored.KeySource, salt)
+		if err != nil {
+			return err
+		}
+
+		stored.EncryptedAPIKey = ""
+		if plainKey != "" {
+			encrypted, err := encryptSecret(key, plainKey)
+			if err != nil {
+				return err
+			}
+			stored.EncryptedAPIKey = encrypted
+		}
+
+		stored.EncryptedCredentials = ""
+		if len(secrets) > 0 {
+			data, err := json.Marshal(secrets)
+			if err != nil {
+				return err
+			}
+			encrypted, err := encryptSecret(key, string(data))
+			if err != nil {
+				return err
+			}
+			stored.EncryptedCredentials = encrypted
+		}
+	} else {
+		stored.EncryptedAPIKey = ""
+		stored.EncryptedCredentials = ""
+		stored.Salt = ""
+	}
+
+	// 明文密钥从不写入磁盘
+	stored.APIKey = ""
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := a.getAIConfigPath()
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+
+	// 旧版本以0644创建的文件不会被WriteFile修改权限
+	return os.Chmod(path, 0600)
+}
+
+// loadAIConfig 读取AI配置并解密API密钥，仅供后端内部使用
+// 旧版本保存的明文密钥会在首次读取时自动迁移为密文
+func (a *App) loadAIConfig() (AIConfig, error) {
+	stored, err := a.readStoredAIConfig()
+	if err != nil {
+		return stored.AIConfig, err
+	}
+
+	// 迁移明文配置
+	if stored.APIKey != "" && stored.EncryptedAPIKey == "" {
+		plainKey := stored.APIKey
+		stored.KeySource = keySourceMachine
+		if err := a.writeStoredAIConfig(stored, plainKey); err != nil {
+			fmt.Printf("迁移明文API密钥失败: %v\n", err)
+		}
+		stored.APIKey = plainKey
+		return stored.AIConfig, nil
+	}
+
+	if stored.EncryptedAPIKey == "" && stored.EncryptedCredentials == "" {
+		return stored.AIConfig, nil
+	}
+
+	salt, _ := base64.StdEncoding.DecodeString(stored.Salt)
+	key, err := a.deriveEncryptionKey(stored.KeySource, salt)
+	if err != nil {
+		return stored.AIConfig, err
+	}
+
+	if stored.EncryptedAPIKey != "" {
+		plainKey, err := decryptSecret(key, stored.EncryptedAPIKey)
+		if err != nil {
+			return stored.AIConfig, err
+		}
+		stored.APIKey = plainKey
+	}
+
+	if stored.EncryptedCredentials != "" {
+		data, err := decryptSecret(key, stored.EncryptedCredentials)
+		if err != nil {
+			return stored.AIConfig, err
+		}
+		var secrets map[string]map[string]string
+		if err := json.Unmarshal([]byte(data), &secrets); err != nil {
+			return stored.AIConfig, fmt.Errorf("凭据格式无效: %v", err)
+		}
+		mergeCredentials(&stored.AIConfig, secrets)
+	}
+
+	return stored.AIConfig, nil
+}
+
+// resolveAPIKey 前端只持有脱敏预览，空值或预览值时使用已保存配置中的密钥
+func resolveAPIKey(apiKey string, config AIConfig) string {
+	if apiKey != "" && !isMaskedAPIKey(apiKey) {
+		return apiKey
+	}
+	return config.APIKey
+}
+
+// UnlockAIKeys 输入口令以解锁使用口令加密的API密钥，口令只保存在内存中
+func (a *App) UnlockAIKeys(passphrase string) error {
+	a.secretMu.Lock()
+	a.aiPassphrase = passphrase
+	a.secretMu.Unlock()
+
+	if _, err := a.loadAIConfig(); err != nil {
+		a.secretMu.Lock()
+		a.aiPassphrase = ""
+		a.secretMu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// SetAIKeyPassphrase 设置或清除加密API密钥使用的口令
+// 口令为空时改为使用本机密钥文件加密
+func (a *App) SetAIKeyPassphrase(passphrase string) error {
+	config, err := a.loadAIConfig()
+	if err != nil {
+		return err
+	}
+
+	stored, err := a.readStoredAIConfig()
+	if err != nil {
+		return err
+	}
+
+	a.secretMu.Lock()
+	a.aiPassphrase = passphrase
+	a.secretMu.Unlock()
+
+	if passphrase == "" {
+		stored.KeySource = keySourceMachine
+	} else {
+		stored.KeySource = keySourcePassphrase
+	}
+
+	// 重新加密时需要带上已解密的机密凭据
+	stored.Credentials = config.Credentials
+	return a.writeStoredAIConfig(stored, config.APIKey)
+}
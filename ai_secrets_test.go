@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestLoadMachineSecretCreatesPrivateKey(t *testing.T) {
+	useTempHome(t)
+	app := NewApp()
+
+	secret, err := app.loadMachineSecret()
+	if err != nil {
+		t.Fatalf("生成本机密钥失败: %v", err)
+	}
+	if len(secret) != machineSecretSize {
+		t.Fatalf("密钥长度应为%d字节，实际 %d", machineSecretSize, len(secret))
+	}
+
+	info, err := os.Stat(app.getSecretKeyPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("密钥文件权限应为0600，实际 %v", info.Mode().Perm())
+	}
+
+	again, err := app.loadMachineSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(secret, again) {
+		t.Error("再次读取时应返回同一个密钥")
+	}
+}
+
+func TestLoadMachineSecretRejectsCorruptKey(t *testing.T) {
+	useTempHome(t)
+	app := NewApp()
+
+	corrupt := []byte("too-short")
+	if err := os.WriteFile(app.getSecretKeyPath(), corrupt, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.loadMachineSecret(); err == nil {
+		t.Fatal("长度不正确的密钥文件应返回错误")
+	}
+
+	// 损坏的密钥文件不能被静默覆盖
+	data, err := os.ReadFile(app.getSecretKeyPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, corrupt) {
+		t.Error("密钥文件不应被重新生成")
+	}
+}
+
+func TestResolveAPIKey(t *testing.T) {
+	config := AIConfig{APIKey: "sk-saved-key-1234"}
+
+	if key := resolveAPIKey("", config); key != config.APIKey {
+		t.Errorf("空值应使用已保存的密钥，实际 %q", key)
+	}
+	if key := resolveAPIKey(maskAPIKey(config.APIKey), config); key != config.APIKey {
+		t.Errorf("脱敏预览应使用已保存的密钥，实际 %q", key)
+	}
+	if key := resolveAPIKey("sk-new-key-5678", config); key != "sk-new-key-5678" {
+		t.Errorf("新输入的密钥应直接使用，实际 %q", key)
+	}
+}
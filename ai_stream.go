@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// AIStreamEvent 是推送给前端的流式事件
+type AIStreamEvent struct {
+	RequestID string `json:"requestId"`
+	Delta     string `json:"delta"`
+	Done      bool   `json:"done"`
+	Error     string `json:"error,omitempty"`
+}
+
+// aiStreamEventName 前端通过 EventsOn 订阅的事件名
+const aiStreamEventName = "ai:stream"
+
+// StreamAI 以流式方式向AI发送查询，生成的文本通过 ai:stream 事件逐段推送给前端
+// requestID 由前端生成，用于区分并发请求以及调用 CancelAI 取消生成
+func (a *App) StreamAI(requestID string, providerID string, apiKey string, customEndpoint string, query string) AIResponse {
+	return a.streamMessages(requestID, providerID, apiKey, customEndpoint, []ChatMessage{
+		{Role: "user", Content: query},
+	})
+}
+
+// streamMessages 以流式方式发送完整的消息列表
+func (a *App) streamMessages(requestID string, providerID string, apiKey string, customEndpoint string, messages []ChatMessage) AIResponse {
+	provider, chatReq, errResp := a.prepareAIRequest(providerID, apiKey, customEndpoint, messages)
+	if errResp != nil {
+		a.emitAIStream(AIStreamEvent{RequestID: requestID, Done: true, Error: errResp.Error})
+		return *errResp
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.registerStream(requestID, cancel)
+	defer a.unregisterStream(requestID)
+
+	content, err := a.runAIStream(ctx, provider, chatReq, func(delta string) {
+		a.emitAIStream(AIStreamEvent{RequestID: requestID, Delta: delta})
+	})
+
+	response := AIResponse{
+		Content:  content,
+		Success:  true,
+		Provider: provider.Info().Name,
+	}
+	if err != nil {
+		// 已生成的内容保留，方便用户查看中断前的回复
+		response = a.aiErrorResponse(err, provider.Info().Name, content)
+	}
+
+	a.emitAIStream(AIStreamEvent{RequestID: requestID, Done: true, Error: response.Error})
+	return response
+}
+
+// CancelAI 取消正在进行的流式请求，已生成的内容会保留
+func (a *App) CancelAI(requestID string) bool {
+	a.streamMu.Lock()
+	cancel, ok := a.streams[requestID]
+	a.streamMu.Unlock()
+
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// runAIStream 发送流式请求并逐段回调增量文本，返回完整内容
+// 只有在收到响应之前失败才会重试，开始接收内容后出错直接返回
+func (a *App) runAIStream(ctx context.Context, provider AIProvider, chatReq ChatRequest, onDelta func(string)) (content string, err error) {
+	started := time.Now()
+	var usage AIUsage
+	defer func() {
+		// 失败的请求只记录提供商实际返回的用量，没有返回时不估算
+		estimated := usage == AIUsage{} && err == nil
+		if estimated {
+			usage = estimateRequestUsage(chatReq, content)
+		}
+		a.recordAIUsage(provider.Info().ID, chatReq, usage, estimated, started, err == nil)
+		a.invalidateAccessToken(chatReq, err)
+	}()
+
+	// 流式响应时间不固定，只限制等待响应头的时间，整体由ctx控制
+	transport := currentTransport().Clone()
+	transport.ResponseHeaderTimeout = 30 * time.Second
+	client := &http.Client{Transport: transport}
+	defer transport.CloseIdleConnections()
+
+	var resp *http.Response
+	err = withAIRetry(ctx, func() error {
+		req, err := provider.NewRequest(ctx, chatReq, true)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "text/event-stream")
+
+		resp, err = client.Do(req)
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return classifyHTTPError(resp.StatusCode, resp.Header, body)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// 不支持流式的服务会直接返回完整JSON
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", fmt.Errorf("读取响应失败: %v", err)
+		}
+		usage, _ = parseUsage(body)
+		content, err := provider.ParseResponse(body)
+		if err != nil {
+			return "", err
+		}
+		onDelta(content)
+		return content, nil
+	}
+
+	content, usage, err = readAIStream(resp.Body, provider, onDelta)
+	return content, err
+}
+
+// readAIStream 解析SSE（data: 前缀）或逐行JSON的分块响应，同时收集事件中上报的token用量
+// 生成结束后继续读取到流结束，因为部分提供商（如OpenAI）在最后单独发送用量
+func readAIStream(body io.Reader, provider AIProvider, onDelta func(string)) (string, AIUsage, error) {
+	var content strings.Builder
+	var usage AIUsage
+	finished := false
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// 跳过空行、注释以及event/id/retry等SSE字段
+		if line == "" || strings.HasPrefix(line, ":") ||
+			strings.HasPrefix(line, "event:") || strings.HasPrefix(line, "id:") || strings.HasPrefix(line, "retry:") {
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		if eventUsage, ok := parseUsage([]byte(data)); ok {
+			usage = mergeUsage(usage, eventUsage)
+		}
+		if finished {
+			continue
+		}
+
+		delta, done, err := provider.ParseStreamEvent([]byte(data))
+		if err != nil {
+			return content.String(), usage, err
+		}
+		if delta != "" {
+			content.WriteString(delta)
+			onDelta(delta)
+		}
+		finished = done
+	}
+
+	if err := scanner.Err(); err != nil {
+		return content.String(), usage, fmt.Errorf("读取流式响应失败: %v", err)
+	}
+
+	return content.String(), usage, nil
+}
+
+// emitAIStream 向前端推送流式事件
+func (a *App) emitAIStream(event AIStreamEvent) {
+	if a.ctx == nil {
+		return
+	}
+	wailsruntime.EventsEmit(a.ctx, aiStreamEventName, event)
+}
+
+func (a *App) registerStream(requestID string, cancel context.CancelFunc) {
+	a.streamMu.Lock()
+	defer a.streamMu.Unlock()
+
+	if a.streams == nil {
+		a.streams = make(map[string]context.CancelFunc)
+	}
+	a.streams[requestID] = cancel
+}
+
+func (a *App) unregisterStream(requestID string) {
+	a.streamMu.Lock()
+	defer a.streamMu.Unlock()
+
+	if cancel, ok := a.streams[requestID]; ok {
+		cancel()
+		delete(a.streams, requestID)
+	}
+}
@@ -0,0 +1,495 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// AIToolSpec 描述提供给模型的一个工具，Parameters 为JSON Schema
+type AIToolSpec struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// AIToolCall 模型请求的一次工具调用，Arguments 为JSON字符串
+type AIToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// AIToolEvent 推送给前端的工具调用状态
+type AIToolEvent struct {
+	RequestID string `json:"requestId"`
+	CallID    string `json:"callId"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	// Status: confirm（等待用户确认）、running、done、rejected、error
+	Status string `json:"status"`
+	Result string `json:"result,omitempty"`
+	// Command 确认时显示将要执行的命令
+	Command string `json:"command,omitempty"`
+}
+
+// aiTool 是一个可由模型调用的应用功能
+type aiTool struct {
+	spec AIToolSpec
+	// modifiesSystem 为true时，执行前需要用户确认
+	modifiesSystem bool
+	// preview 返回确认时显示的命令，参数无效时返回错误且不请求确认
+	preview func(args map[string]interface{}) (string, error)
+	run     func(ctx context.Context, args map[string]interface{}) (interface{}, error)
+}
+
+const (
+	// aiToolEventName 前端订阅的工具调用事件名
+	aiToolEventName = "ai:tool"
+	// aiMaxToolRounds 一次回答中最多进行的工具调用轮数
+	aiMaxToolRounds = 6
+	// aiToolResultLimit 返回给模型的工具结果最大字符数
+	aiToolResultLimit = 6000
+	// aiToolConfirmTimeout 等待用户确认的最长时间
+	aiToolConfirmTimeout = 5 * time.Minute
+)
+
+// searchablePackageManagers SearchPackage 支持的包管理器
+var searchablePackageManagers = []string{"npm", "pip", "gem", "cargo", "composer", "nuget", "maven", "go", "dub", "hex", "nimble", "brew"}
+
+// aiTools 返回可供模型调用的工具
+func (a *App) aiTools() []aiTool {
+	languageNames := []string{}
+	for _, detector := range a.languageDetectors() {
+		languageNames = append(languageNames, detector.name)
+	}
+
+	// 可以在应用内安装的包管理器，项目依赖类的包管理器不提供给模型
+	installManagers := []string{}
+	for _, manager := range searchablePackageManagers {
+		if _, _, err := buildPackageArgs(manager, "install", "example", "", false); err == nil {
+			installManagers = append(installManagers, manager)
+		}
+	}
+
+	return []aiTool{
+		{
+			spec: AIToolSpec{
+				Name:        "search_package",
+				Description: "在包注册表中搜索包，返回真实的包名、最新版本、描述和链接。回答版本号或安装方式前应先调用。",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"package_manager": map[string]interface{}{"type": "string", "enum": searchablePackageManagers},
+						"package_name":    map[string]interface{}{"type": "string", "description": "包名或关键词"},
+					},
+					"required": []string{"package_manager", "package_name"},
+				},
+			},
+			run: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+				packages := a.searchPackageRegistry(stringArg(args, "package_manager"), stringArg(args, "package_name"))
+				if len(packages) > 10 {
+					packages = packages[:10]
+				}
+				return packages, nil
+			},
+		},
+		{
+			spec: AIToolSpec{
+				Name:        "detect_language",
+				Description: "检测用户电脑上某种编程语言是否已安装，返回版本、包管理器和已安装的包。",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"language": map[string]interface{}{"type": "string", "enum": languageNames},
+					},
+					"required": []string{"language"},
+				},
+			},
+			run: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+				info, err := a.DetectLanguage(stringArg(args, "language"))
+				if err != nil {
+					return nil, err
+				}
+				return compactLanguageInfo(info), nil
+			},
+		},
+		{
+			spec: AIToolSpec{
+				Name:        "get_package_tutorials",
+				Description: "获取包管理器的安装、搜索、更新命令和官方文档链接。",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"package_manager": map[string]interface{}{"type": "string", "description": "包管理器名称，留空返回全部"},
+					},
+				},
+			},
+			run: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+				keyword := strings.ToLower(stringArg(args, "package_manager"))
+				tutorials := []PackageTutorial{}
+				for _, tutorial := range a.GetPackageTutorials() {
+					if keyword == "" || strings.Contains(strings.ToLower(tutorial.Name), keyword) {
+						tutorials = append(tutorials, tutorial)
+					}
+				}
+				return tutorials, nil
+			},
+		},
+		{
+			spec: AIToolSpec{
+				Name:        "install_package",
+				Description: "在用户电脑上全局安装包。会修改系统，执行前需要用户确认；安装在包操作队列中执行，用户可以取消和回滚。",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"package_manager": map[string]interface{}{"type": "string", "enum": installManagers},
+						"package_name":    map[string]interface{}{"type": "string"},
+						"version":         map[string]interface{}{"type": "string", "description": "要安装的版本，留空安装最新版本"},
+					},
+					"required": []string{"package_manager", "package_name"},
+				},
+			},
+			modifiesSystem: true,
+			preview: func(args map[string]interface{}) (string, error) {
+				command, err := a.PreviewPackageCommand(installPackageRequest(args))
+				if err != nil {
+					return "", err
+				}
+				if !command.Supported {
+					return "", fmt.Errorf("无法在应用内执行: %s", command.Reason)
+				}
+				return command.Command, nil
+			},
+			run: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+				job, err := a.enqueuePackageJob(installPackageRequest(args), "", nil)
+				if err != nil {
+					return nil, err
+				}
+				return a.waitPackageJob(ctx, job)
+			},
+		},
+	}
+}
+
+// stringArg 读取工具参数中的字符串
+func stringArg(args map[string]interface{}, key string) string {
+	value, _ := args[key].(string)
+	return strings.TrimSpace(value)
+}
+
+// compactLanguageInfo 只保留回答问题需要的语言信息，减少发送给模型的内容
+func compactLanguageInfo(info LanguageInfo) map[string]interface{} {
+	packages := []string{}
+	for i, pkg := range info.Packages {
+		if i >= 100 {
+			packages = append(packages, fmt.Sprintf("……另有 %d 个包", len(info.Packages)-i))
+			break
+		}
+		packages = append(packages, strings.TrimSpace(pkg.Name+" "+pkg.Version))
+	}
+
+	return map[string]interface{}{
+		"name":            info.Name,
+		"installed":       info.Installed,
+		"version":         info.Version,
+		"packageManager":  info.PackageManager,
+		"missingDeps":     info.MissingDeps,
+		"downloadUrl":     info.DownloadURL,
+		"installTutorial": info.InstallTutorial,
+		"packages":        packages,
+	}
+}
+
+// installPackageRequest 把 install_package 工具的参数转换为包操作请求
+func installPackageRequest(args map[string]interface{}) PackageJobRequest {
+	return PackageJobRequest{
+		Manager: stringArg(args, "package_manager"),
+		Name:    stringArg(args, "package_name"),
+		Version: stringArg(args, "version"),
+		Action:  "install",
+	}
+}
+
+// toolSpecs 返回所有工具的定义
+func toolSpecs(tools []aiTool) []AIToolSpec {
+	specs := make([]AIToolSpec, 0, len(tools))
+	for _, tool := range tools {
+		specs = append(specs, tool.spec)
+	}
+	return specs
+}
+
+// runToolConversation 在支持工具调用的提供商上进行对话：
+// 模型请求工具时执行工具并把结果发回，直到模型给出最终回答
+// 提供商不支持工具调用时返回 handled=false，由调用方改用普通流式请求
+func (a *App) runToolConversation(requestID string, providerID string, apiKey string, customEndpoint string, messages []ChatMessage) (response AIResponse, toolMessages []ConversationMessage, handled bool) {
+	provider, chatReq, errResp := a.prepareAIRequest(providerID, apiKey, customEndpoint, messages)
+	if errResp != nil {
+		return AIResponse{}, nil, false
+	}
+	caller, ok := provider.(AIToolCaller)
+	if !ok || !caller.SupportsTools(chatReq) || a.toolsDisabledFor(providerID) {
+		return AIResponse{}, nil, false
+	}
+
+	tools := a.aiTools()
+	chatReq.Tools = toolSpecs(tools)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.registerStream(requestID, cancel)
+	defer a.unregisterStream(requestID)
+
+	response = AIResponse{Provider: provider.Info().Name}
+
+	for round := 0; round < aiMaxToolRounds; round++ {
+		body, err := a.doAIRequest(ctx, provider, chatReq, 60*time.Second)
+		if err == nil {
+			var content string
+			var calls []AIToolCall
+			content, calls, err = caller.ParseToolResponse(body)
+
+			if err == nil && len(calls) == 0 {
+				response.Content = content
+				response.Success = true
+				a.emitAIStream(AIStreamEvent{RequestID: requestID, Delta: content})
+				a.emitAIStream(AIStreamEvent{RequestID: requestID, Done: true})
+				return response, toolMessages, true
+			}
+
+			if err == nil {
+				chatReq.Messages = append(chatReq.Messages, ChatMessage{Role: "assistant", Content: content, ToolCalls: calls})
+				for _, call := range calls {
+					result := a.executeAITool(ctx, requestID, tools, call)
+					chatReq.Messages = append(chatReq.Messages, ChatMessage{Role: "tool", Content: result, ToolCallID: call.ID})
+					toolMessages = append(toolMessages, ConversationMessage{
+						Role:          "tool",
+						Content:       result,
+						ToolName:      call.Name,
+						ToolArguments: call.Arguments,
+						CreatedAt:     time.Now().Format(time.RFC3339),
+					})
+				}
+				continue
+			}
+		}
+
+		// 部分OpenAI兼容服务不接受 tools 参数并返回400，此时改用不带工具的普通请求
+		if round == 0 && isToolsRejected(err) {
+			return AIResponse{}, nil, false
+		}
+
+		response = a.aiErrorResponse(err, response.Provider, "")
+		a.emitAIStream(AIStreamEvent{RequestID: requestID, Done: true, Error: response.Error})
+		return response, toolMessages, true
+	}
+
+	response.Error = fmt.Sprintf("工具调用超过 %d 轮，已停止", aiMaxToolRounds)
+	a.emitAIStream(AIStreamEvent{RequestID: requestID, Done: true, Error: response.Error})
+	return response, toolMessages, true
+}
+
+// toolsDisabledFor 判断用户是否在提供商设置中关闭了工具调用
+func (a *App) toolsDisabledFor(providerID string) bool {
+	config, _ := a.loadAIConfig()
+	return config.ProviderSettings[providerID].DisableTools
+}
+
+// isToolsRejected 判断错误是否为端点拒绝了请求参数（通常是不支持 tools）
+func isToolsRejected(err error) bool {
+	var aiErr *AIError
+	if !errors.As(err, &aiErr) {
+		return false
+	}
+	return aiErr.StatusCode == http.StatusBadRequest || aiErr.StatusCode == http.StatusUnprocessableEntity
+}
+
+// executeAITool 执行一次工具调用，返回发送给模型的结果文本
+// 修改系统的工具需要用户在前端确认后才会执行
+func (a *App) executeAITool(ctx context.Context, requestID string, tools []aiTool, call AIToolCall) string {
+	event := AIToolEvent{RequestID: requestID, CallID: call.ID, Name: call.Name, Arguments: call.Arguments}
+
+	finish := func(status string, result string) string {
+		event.Status = status
+		event.Result = truncateToolResult(result)
+		a.emitAIToolEvent(event)
+		return event.Result
+	}
+
+	var tool *aiTool
+	for i := range tools {
+		if tools[i].spec.Name == call.Name {
+			tool = &tools[i]
+			break
+		}
+	}
+	if tool == nil {
+		return finish("error", "错误: 未知工具 "+call.Name)
+	}
+
+	args := map[string]interface{}{}
+	if strings.TrimSpace(call.Arguments) != "" {
+		if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+			return finish("error", "错误: 参数不是有效的JSON: "+err.Error())
+		}
+	}
+
+	if tool.modifiesSystem {
+		if tool.preview != nil {
+			command, err := tool.preview(args)
+			if err != nil {
+				return finish("error", "错误: "+err.Error())
+			}
+			event.Command = command
+		}
+		event.Status = "confirm"
+		a.emitAIToolEvent(event)
+		if !a.waitToolConfirmation(ctx, call.ID) {
+			return finish("rejected", "用户拒绝执行该操作")
+		}
+	}
+
+	event.Status = "running"
+	a.emitAIToolEvent(event)
+
+	result, err := tool.run(ctx, args)
+	if err != nil {
+		// 输出中可能包含有用的错误信息，一并返回
+		if output, ok := result.(string); ok && output != "" {
+			return finish("error", "错误: "+err.Error()+"\n"+output)
+		}
+		return finish("error", "错误: "+err.Error())
+	}
+
+	if text, ok := result.(string); ok {
+		return finish("done", text)
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return finish("error", "错误: "+err.Error())
+	}
+	return finish("done", string(data))
+}
+
+// truncateToolResult 限制工具结果长度，避免占满上下文窗口
+func truncateToolResult(result string) string {
+	runes := []rune(result)
+	if len(runes) <= aiToolResultLimit {
+		return result
+	}
+	return string(runes[:aiToolResultLimit]) + "……（结果过长，已截断）"
+}
+
+// waitToolConfirmation 等待用户确认工具调用，超时或取消时视为拒绝
+func (a *App) waitToolConfirmation(ctx context.Context, callID string) bool {
+	ch := make(chan bool, 1)
+
+	a.toolMu.Lock()
+	if a.toolConfirms == nil {
+		a.toolConfirms = make(map[string]chan bool)
+	}
+	a.toolConfirms[callID] = ch
+	a.toolMu.Unlock()
+
+	defer func() {
+		a.toolMu.Lock()
+		delete(a.toolConfirms, callID)
+		a.toolMu.Unlock()
+	}()
+
+	select {
+	case approved := <-ch:
+		return approved
+	case <-ctx.Done():
+		return false
+	case <-time.After(aiToolConfirmTimeout):
+		return false
+	}
+}
+
+// ConfirmAIToolCall 确认或拒绝等待中的工具调用
+func (a *App) ConfirmAIToolCall(callID string, approved bool) bool {
+	a.toolMu.Lock()
+	ch, ok := a.toolConfirms[callID]
+	a.toolMu.Unlock()
+
+	if ok {
+		select {
+		case ch <- approved:
+		default:
+		}
+	}
+	return ok
+}
+
+// emitAIToolEvent 向前端推送工具调用状态
+func (a *App) emitAIToolEvent(event AIToolEvent) {
+	if a.ctx == nil {
+		return
+	}
+	wailsruntime.EventsEmit(a.ctx, aiToolEventName, event)
+}
+
+// doAIRequest 发送非流式请求并返回响应体，可重试的错误会按指数退避自动重试
+// timeout 为单次尝试的超时时间
+func (a *App) doAIRequest(ctx context.Context, provider AIProvider, chatReq ChatRequest, timeout time.Duration) ([]byte, error) {
+	client := newHTTPClient(timeout)
+
+	started := time.Now()
+	var respBody []byte
+	err := withAIRetry(ctx, func() error {
+		// 请求体读取后不能复用，每次尝试重新构建
+		req, err := provider.NewRequest(ctx, chatReq, false)
+		if err != nil {
+			return err
+		}
+
+		// 发送请求
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		// 读取响应
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+
+		// 检查状态码
+		if resp.StatusCode != http.StatusOK {
+			return classifyHTTPError(resp.StatusCode, resp.Header, body)
+		}
+
+		respBody = body
+		return nil
+	})
+
+	// 记录用量，提供商没有返回用量时按回复内容估算
+	// 失败的请求只记录提供商实际返回的用量，没有返回时不估算
+	usage, ok := parseUsage(respBody)
+	estimated := !ok && err == nil
+	if estimated {
+		content, _ := provider.ParseResponse(respBody)
+		usage = estimateRequestUsage(chatReq, content)
+	}
+	a.recordAIUsage(provider.Info().ID, chatReq, usage, estimated, started, err == nil)
+
+	// 令牌失效的错误可能在HTTP 200的响应体中返回（如文心一言的110/111）
+	if err == nil && chatReq.AccessToken != "" {
+		_, parseErr := provider.ParseResponse(respBody)
+		a.invalidateAccessToken(chatReq, parseErr)
+	} else {
+		a.invalidateAccessToken(chatReq, err)
+	}
+
+	return respBody, err
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// newToolsApp 使用本地OpenAI兼容端点并开启工具调用
+func newToolsApp(t *testing.T, disableTools bool) *App {
+	t.Helper()
+	useTempHome(t)
+
+	app := NewApp()
+	config := AIConfig{
+		SelectedProviderID: "local",
+		EnableTools:        true,
+		ProviderSettings: map[string]AIProviderSettings{
+			"local": {Model: "test-model", DisableTools: disableTools},
+		},
+	}
+	if err := app.SaveAIConfig(config); err != nil {
+		t.Fatalf("保存AI配置失败: %v", err)
+	}
+	return app
+}
+
+// noToolsServer 模拟不接受 tools 参数的OpenAI兼容服务
+func noToolsServer(t *testing.T, withTools *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := decodeRequestBody(t, r)
+		if _, ok := body["tools"]; ok {
+			atomic.AddInt32(withTools, 1)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"tools is not supported"}}`))
+			return
+		}
+		if body["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"ok\"},\"finish_reason\":\"stop\"}]}\n\ndata: [DONE]\n\n"))
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+}
+
+func TestToolConversationFallsBackWhenToolsRejected(t *testing.T) {
+	var withTools int32
+	server := noToolsServer(t, &withTools)
+	defer server.Close()
+
+	app := newToolsApp(t, false)
+	conversation, err := app.CreateConversation("test", "local")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := app.SendConversationMessage("r1", conversation.ID, "local", "", server.URL+"/v1/chat/completions", "hello")
+	if !response.Success || response.Content != "ok" {
+		t.Fatalf("端点拒绝工具参数后应改用普通请求: %+v", response)
+	}
+	if atomic.LoadInt32(&withTools) != 1 {
+		t.Errorf("应先尝试一次带工具的请求，实际 %d 次", withTools)
+	}
+}
+
+func TestToolConversationDisabledPerProvider(t *testing.T) {
+	var withTools int32
+	server := noToolsServer(t, &withTools)
+	defer server.Close()
+
+	app := newToolsApp(t, true)
+	conversation, err := app.CreateConversation("test", "local")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := app.SendConversationMessage("r1", conversation.ID, "local", "", server.URL+"/v1/chat/completions", "hello")
+	if !response.Success || response.Content != "ok" {
+		t.Fatalf("请求失败: %+v", response)
+	}
+	if atomic.LoadInt32(&withTools) != 0 {
+		t.Errorf("关闭工具调用后不应发送工具定义")
+	}
+}
@@ -0,0 +1,410 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AIUsage 一次请求的token用量
+type AIUsage struct {
+	PromptTokens     int `json:"promptTokens"`
+	CompletionTokens int `json:"completionTokens"`
+}
+
+// AIUsageRecord 记录一次AI请求的用量、耗时和模型
+type AIUsageRecord struct {
+	Time             string `json:"time"`
+	ProviderID       string `json:"providerId"`
+	Model            string `json:"model"`
+	PromptTokens     int    `json:"promptTokens"`
+	CompletionTokens int    `json:"completionTokens"`
+	// Estimated 为true表示提供商没有返回用量，按文本长度估算
+	Estimated bool  `json:"estimated,omitempty"`
+	LatencyMs int64 `json:"latencyMs"`
+	Success   bool  `json:"success"`
+}
+
+// AIModelPrice 模型的价格，单位为每百万token
+type AIModelPrice struct {
+	Model           string  `json:"model"`
+	PromptPrice     float64 `json:"promptPrice"`
+	CompletionPrice float64 `json:"completionPrice"`
+	Currency        string  `json:"currency"`
+}
+
+// AIPricing 用户可编辑的价格表和每月预算
+type AIPricing struct {
+	// MonthlyBudget 每月预算，0表示不限制；超出后发送前提醒，不会阻止请求
+	MonthlyBudget float64        `json:"monthlyBudget"`
+	Currency      string         `json:"currency"`
+	Prices        []AIModelPrice `json:"prices"`
+}
+
+// AIUsageTotal 一段时间内的用量汇总，Cost 按币种分别累计
+type AIUsageTotal struct {
+	Key              string             `json:"key"`
+	Requests         int                `json:"requests"`
+	Failed           int                `json:"failed"`
+	PromptTokens     int                `json:"promptTokens"`
+	CompletionTokens int                `json:"completionTokens"`
+	AvgLatencyMs     int64              `json:"avgLatencyMs"`
+	Cost             map[string]float64 `json:"cost"`
+	totalLatency     int64
+}
+
+// AIUsageReport 某个月的用量报告
+type AIUsageReport struct {
+	Month   string         `json:"month"`
+	Total   AIUsageTotal   `json:"total"`
+	Daily   []AIUsageTotal `json:"daily"`
+	ByModel []AIUsageTotal `json:"byModel"`
+	Budget  AIBudgetStatus `json:"budget"`
+}
+
+// AIBudgetStatus 本月预算的使用情况
+type AIBudgetStatus struct {
+	MonthlyBudget float64 `json:"monthlyBudget"`
+	Currency      string  `json:"currency"`
+	Spent         float64 `json:"spent"`
+	// Warning 已用超过预算的80%，Exceeded 已超出预算
+	Warning  bool   `json:"warning"`
+	Exceeded bool   `json:"exceeded"`
+	Message  string `json:"message,omitempty"`
+}
+
+// aiBudgetWarningRatio 达到预算的该比例时开始提醒
+const aiBudgetWarningRatio = 0.8
+
+// defaultAIPrices 内置的参考价格，用户可在价格表中修改
+var defaultAIPrices = []AIModelPrice{
+	{Model: "gpt-4o-mini", PromptPrice: 0.15, CompletionPrice: 0.6, Currency: "USD"},
+	{Model: "gpt-4o", PromptPrice: 2.5, CompletionPrice: 10, Currency: "USD"},
+	{Model: "gpt-4-turbo", PromptPrice: 10, CompletionPrice: 30, Currency: "USD"},
+	{Model: "gpt-3.5-turbo", PromptPrice: 0.5, CompletionPrice: 1.5, Currency: "USD"},
+	{Model: "claude-3-5-sonnet", PromptPrice: 3, CompletionPrice: 15, Currency: "USD"},
+	{Model: "claude-3-5-haiku", PromptPrice: 0.8, CompletionPrice: 4, Currency: "USD"},
+	{Model: "claude-3-opus", PromptPrice: 15, CompletionPrice: 75, Currency: "USD"},
+	{Model: "claude-3-haiku", PromptPrice: 0.25, CompletionPrice: 1.25, Currency: "USD"},
+	{Model: "qwen-max", PromptPrice: 20, CompletionPrice: 60, Currency: "CNY"},
+	{Model: "qwen-plus", PromptPrice: 0.8, CompletionPrice: 2, Currency: "CNY"},
+	{Model: "qwen-turbo", PromptPrice: 0.3, CompletionPrice: 0.6, Currency: "CNY"},
+	{Model: "ernie-speed-128k", PromptPrice: 0, CompletionPrice: 0, Currency: "CNY"},
+}
+
+// parseUsage 从响应体或流式事件中提取token用量，兼容常见提供商的格式：
+// OpenAI/文心一言 usage.prompt_tokens，Anthropic/通义千问 usage.input_tokens，
+// Anthropic message_start 的 message.usage，以及Ollama的 prompt_eval_count/eval_count
+func parseUsage(data []byte) (AIUsage, bool) {
+	type usageFields struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		InputTokens      int `json:"input_tokens"`
+		OutputTokens     int `json:"output_tokens"`
+	}
+	var payload struct {
+		Usage   *usageFields `json:"usage"`
+		Message *struct {
+			Usage *usageFields `json:"usage"`
+		} `json:"message"`
+		PromptEvalCount int `json:"prompt_eval_count"`
+		EvalCount       int `json:"eval_count"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return AIUsage{}, false
+	}
+
+	fields := payload.Usage
+	if fields == nil && payload.Message != nil {
+		fields = payload.Message.Usage
+	}
+	if fields != nil {
+		usage := AIUsage{
+			PromptTokens:     fields.PromptTokens + fields.InputTokens,
+			CompletionTokens: fields.CompletionTokens + fields.OutputTokens,
+		}
+		return usage, usage.PromptTokens > 0 || usage.CompletionTokens > 0
+	}
+
+	if payload.PromptEvalCount > 0 || payload.EvalCount > 0 {
+		return AIUsage{PromptTokens: payload.PromptEvalCount, CompletionTokens: payload.EvalCount}, true
+	}
+	return AIUsage{}, false
+}
+
+// mergeUsage 合并流式事件中分段上报的用量（如Anthropic分别上报输入和输出）
+func mergeUsage(total AIUsage, usage AIUsage) AIUsage {
+	if usage.PromptTokens > total.PromptTokens {
+		total.PromptTokens = usage.PromptTokens
+	}
+	if usage.CompletionTokens > total.CompletionTokens {
+		total.CompletionTokens = usage.CompletionTokens
+	}
+	return total
+}
+
+// estimateRequestUsage 提供商没有返回用量时按文本长度估算
+func estimateRequestUsage(chatReq ChatRequest, content string) AIUsage {
+	prompt := estimateTokens(chatReq.SystemPrompt)
+	for _, message := range chatReq.Messages {
+		prompt += estimateTokens(message.Content)
+	}
+	return AIUsage{PromptTokens: prompt, CompletionTokens: estimateTokens(content)}
+}
+
+// getUsageDir 获取用量记录目录
+func (a *App) getUsageDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "usage"
+	}
+	dir := filepath.Join(homeDir, ".networ_tester", "usage")
+
+	// 确保目录存在
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		os.MkdirAll(dir, 0755)
+	}
+
+	return dir
+}
+
+// recordAIUsage 将一次请求的用量追加到当月的记录文件（每行一条JSON）
+func (a *App) recordAIUsage(providerID string, chatReq ChatRequest, usage AIUsage, estimated bool, started time.Time, success bool) {
+	model := chatReq.Model
+	if model == "" {
+		model = providerID
+	}
+
+	record := AIUsageRecord{
+		Time:             started.Format(time.RFC3339),
+		ProviderID:       providerID,
+		Model:            model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		Estimated:        estimated,
+		LatencyMs:        time.Since(started).Milliseconds(),
+		Success:          success,
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+
+	a.usageMu.Lock()
+	defer a.usageMu.Unlock()
+
+	path := filepath.Join(a.getUsageDir(), "usage-"+started.Format("2006-01")+".jsonl")
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Printf("记录AI用量失败: %v\n", err)
+		return
+	}
+	defer file.Close()
+
+	file.Write(append(data, '\n'))
+}
+
+// loadUsageRecords 读取某个月（2006-01格式）的用量记录
+func (a *App) loadUsageRecords(month string) ([]AIUsageRecord, error) {
+	a.usageMu.Lock()
+	defer a.usageMu.Unlock()
+
+	file, err := os.Open(filepath.Join(a.getUsageDir(), "usage-"+month+".jsonl"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []AIUsageRecord{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	records := []AIUsageRecord{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record AIUsageRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err == nil {
+			records = append(records, record)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// getAIPricingPath 获取价格表文件路径
+func (a *App) getAIPricingPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "ai_pricing.json"
+	}
+	return filepath.Join(homeDir, ".networ_tester", "ai_pricing.json")
+}
+
+// GetAIPricing 获取价格表和预算，文件不存在时返回内置价格
+func (a *App) GetAIPricing() AIPricing {
+	pricing := AIPricing{Currency: "USD", Prices: defaultAIPrices}
+
+	data, err := os.ReadFile(a.getAIPricingPath())
+	if err != nil {
+		return pricing
+	}
+	if err := json.Unmarshal(data, &pricing); err != nil {
+		fmt.Printf("解析价格表失败: %v\n", err)
+		return AIPricing{Currency: "USD", Prices: defaultAIPrices}
+	}
+	return pricing
+}
+
+// SaveAIPricing 保存价格表和预算
+func (a *App) SaveAIPricing(pricing AIPricing) error {
+	if pricing.MonthlyBudget < 0 {
+		return fmt.Errorf("预算不能为负数")
+	}
+	for _, price := range pricing.Prices {
+		if strings.TrimSpace(price.Model) == "" {
+			return fmt.Errorf("价格表中的模型名称不能为空")
+		}
+		if price.PromptPrice < 0 || price.CompletionPrice < 0 {
+			return fmt.Errorf("模型 %s 的价格不能为负数", price.Model)
+		}
+	}
+	if pricing.Currency == "" {
+		pricing.Currency = "USD"
+	}
+
+	data, err := json.MarshalIndent(pricing, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	os.MkdirAll(filepath.Dir(a.getAIPricingPath()), 0755)
+	return os.WriteFile(a.getAIPricingPath(), data, 0644)
+}
+
+// findModelPrice 查找模型价格：先精确匹配，再按最长前缀匹配（如 claude-3-5-sonnet 匹配 claude-3-5-sonnet-latest）
+func findModelPrice(prices []AIModelPrice, model string) (AIModelPrice, bool) {
+	var best AIModelPrice
+	found := false
+	for _, price := range prices {
+		if strings.EqualFold(price.Model, model) {
+			return price, true
+		}
+		if strings.HasPrefix(strings.ToLower(model), strings.ToLower(price.Model)) && len(price.Model) > len(best.Model) {
+			best = price
+			found = true
+		}
+	}
+	return best, found
+}
+
+// addRecord 将一条记录计入汇总
+// 失败的请求同样计入提供商返回的token和费用，只是不计入平均耗时（超时等失败会拉高平均值）
+func (t *AIUsageTotal) addRecord(record AIUsageRecord, prices []AIModelPrice) {
+	t.Requests++
+	t.PromptTokens += record.PromptTokens
+	t.CompletionTokens += record.CompletionTokens
+	if record.Success {
+		t.totalLatency += record.LatencyMs
+		t.AvgLatencyMs = t.totalLatency / int64(t.Requests-t.Failed)
+	} else {
+		t.Failed++
+	}
+
+	if price, ok := findModelPrice(prices, record.Model); ok {
+		if t.Cost == nil {
+			t.Cost = map[string]float64{}
+		}
+		t.Cost[price.Currency] += (float64(record.PromptTokens)*price.PromptPrice + float64(record.CompletionTokens)*price.CompletionPrice) / 1e6
+	}
+}
+
+// GetAIUsageReport 获取某个月（2006-01格式，为空时为本月）的用量报告，按天和模型汇总
+func (a *App) GetAIUsageReport(month string) (AIUsageReport, error) {
+	if month == "" {
+		month = time.Now().Format("2006-01")
+	}
+	if _, err := time.Parse("2006-01", month); err != nil {
+		return AIUsageReport{}, fmt.Errorf("无效的月份: %s", month)
+	}
+
+	records, err := a.loadUsageRecords(month)
+	if err != nil {
+		return AIUsageReport{}, err
+	}
+
+	pricing := a.GetAIPricing()
+	report := AIUsageReport{Month: month, Total: AIUsageTotal{Key: month, Cost: map[string]float64{}}}
+
+	daily := map[string]*AIUsageTotal{}
+	byModel := map[string]*AIUsageTotal{}
+	for _, record := range records {
+		report.Total.addRecord(record, pricing.Prices)
+
+		day := record.Time
+		if len(day) >= 10 {
+			day = day[:10]
+		}
+		if daily[day] == nil {
+			daily[day] = &AIUsageTotal{Key: day, Cost: map[string]float64{}}
+		}
+		daily[day].addRecord(record, pricing.Prices)
+
+		if byModel[record.Model] == nil {
+			byModel[record.Model] = &AIUsageTotal{Key: record.Model, Cost: map[string]float64{}}
+		}
+		byModel[record.Model].addRecord(record, pricing.Prices)
+	}
+
+	for _, total := range daily {
+		report.Daily = append(report.Daily, *total)
+	}
+	sort.Slice(report.Daily, func(i, j int) bool {
+		return report.Daily[i].Key < report.Daily[j].Key
+	})
+
+	for _, total := range byModel {
+		report.ByModel = append(report.ByModel, *total)
+	}
+	sort.Slice(report.ByModel, func(i, j int) bool {
+		return report.ByModel[i].Requests > report.ByModel[j].Requests
+	})
+
+	report.Budget = budgetStatus(pricing, report.Total)
+	return report, nil
+}
+
+// CheckAIBudget 在发送请求前检查本月预算，超出时只提醒不阻止
+func (a *App) CheckAIBudget() AIBudgetStatus {
+	report, err := a.GetAIUsageReport("")
+	if err != nil {
+		return AIBudgetStatus{}
+	}
+	return report.Budget
+}
+
+// budgetStatus 根据本月花费计算预算状态，只统计与预算相同币种的花费
+func budgetStatus(pricing AIPricing, total AIUsageTotal) AIBudgetStatus {
+	status := AIBudgetStatus{
+		MonthlyBudget: pricing.MonthlyBudget,
+		Currency:      pricing.Currency,
+		Spent:         total.Cost[pricing.Currency],
+	}
+	if pricing.MonthlyBudget <= 0 {
+		return status
+	}
+
+	switch {
+	case status.Spent >= pricing.MonthlyBudget:
+		status.Exceeded = true
+		status.Warning = true
+		status.Message = fmt.Sprintf("本月AI花费约 %.2f %s，已超出预算 %.2f %s", status.Spent, status.Currency, pricing.MonthlyBudget, status.Currency)
+	case status.Spent >= pricing.MonthlyBudget*aiBudgetWarningRatio:
+		status.Warning = true
+		status.Message = fmt.Sprintf("本月AI花费约 %.2f %s，已达到预算 %.2f %s 的 %.0f%%", status.Spent, status.Currency, pricing.MonthlyBudget, status.Currency, status.Spent/pricing.MonthlyBudget*100)
+	}
+	return status
+}
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestUsageTotalCountsFailedRequestTokens(t *testing.T) {
+	prices := []AIModelPrice{{Model: "gpt-4o", PromptPrice: 1, CompletionPrice: 2, Currency: "USD"}}
+
+	var total AIUsageTotal
+	total.addRecord(AIUsageRecord{Model: "gpt-4o", PromptTokens: 1000, CompletionTokens: 500, LatencyMs: 200, Success: true}, prices)
+	total.addRecord(AIUsageRecord{Model: "gpt-4o", PromptTokens: 2000, CompletionTokens: 1000, LatencyMs: 60000, Success: false}, prices)
+	// 提供商没有返回用量的失败请求不估算token
+	total.addRecord(AIUsageRecord{Model: "gpt-4o", LatencyMs: 30000, Success: false}, prices)
+
+	if total.Requests != 3 || total.Failed != 2 {
+		t.Errorf("请求数或失败数不正确: %+v", total)
+	}
+	if total.PromptTokens != 3000 || total.CompletionTokens != 1500 {
+		t.Errorf("失败的请求应计入提供商返回的token: %+v", total)
+	}
+	if total.AvgLatencyMs != 200 {
+		t.Errorf("失败的请求不应计入平均耗时: %d", total.AvgLatencyMs)
+	}
+	if cost := total.Cost["USD"]; cost != 0.006 {
+		t.Errorf("失败的请求应计入费用: %v", cost)
+	}
+}
@@ -4,17 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"runtime"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // PackageInfo 存储包信息
@@ -25,6 +24,14 @@ type PackageInfo struct {
 	Installed   bool   `json:"installed"`
 	InstallLink string `json:"installLink"`
 	DownloadURL string `json:"downloadUrl"`
+	// AI建议的字段单独保存，不覆盖来自包注册表的链接
+	AISuggestedDownloadURL string `json:"aiSuggestedDownloadUrl,omitempty"`
+	AISuggestedInstallLink string `json:"aiSuggestedInstallLink,omitempty"`
+	// 以下字段用于跨注册表搜索时的合并排序
+	Manager   string  `json:"manager,omitempty"`
+	Downloads int64   `json:"downloads,omitempty"`
+	UpdatedAt string  `json:"updatedAt,omitempty"`
+	Score     float64 `json:"score,omitempty"`
 }
 
 // LanguageInfo 存储编程语言的信息
@@ -52,16 +59,69 @@ type PackageTutorial struct {
 
 // App 应用程序结构体
 type App struct {
+	ctx context.Context
+
+	// 正在进行的流式AI请求，用于取消
+	streamMu sync.Mutex
+	streams  map[string]context.CancelFunc
+
+	// 保护会话文件的读写
+	convMu sync.Mutex
+
+	// 最近一次语言检测结果，用于生成AI环境摘要
+	detectMu          sync.Mutex
+	detectedLanguages []LanguageInfo
+
+	// 等待用户确认的工具调用
+	toolMu       sync.Mutex
+	toolConfirms map[string]chan bool
+
+	// 保护用量记录文件的读写
+	usageMu sync.Mutex
+
+	// 缓存的提供商访问令牌
+	tokenMu      sync.Mutex
+	accessTokens map[string]cachedAccessToken
+
+	// 用于解锁API密钥的口令，只保存在内存中
+	secretMu     sync.Mutex
+	aiPassphrase string
+	// 口令派生的密钥缓存，避免每次读取配置都重新执行scrypt
+	derivedKey     []byte
+	derivedKeySalt string
+	derivedKeyFrom string
+
+	// 包安装、升级、卸载任务队列
+	jobMu    sync.Mutex
+	jobs     []*PackageJob
+	jobQueue chan *PackageJob
+
+	// 保护包操作记录文件的读写
+	journalMu sync.Mutex
+
+	// 正在进行的漏洞数据下载或导入，用于取消
+	osvUpdateMu     sync.Mutex
+	osvUpdateCancel context.CancelFunc
 }
 
 // NewApp 创建一个新的App实例
 func NewApp() *App {
-	return &App{}
+	return &App{
+		streams: make(map[string]context.CancelFunc),
+	}
 }
 
 // 应用程序启动时调用
 func (a *App) startup(ctx context.Context) {
+	a.ctx = ctx
 	fmt.Println("应用程序已启动")
+
+	if err := a.reloadNetworkSettings(); err != nil {
+		fmt.Printf("网络设置无效，使用系统代理: %v\n", err)
+	}
+	if err := a.reloadUserAIProviders(); err != nil {
+		fmt.Printf("加载自定义AI提供商失败: %v\n", err)
+	}
 }
 
 // TestFunction 测试函数，用于验证前后端通信
@@ -92,10 +152,19 @@ func (a *App) GetSystemInfo() SystemInfo {
 
 // 添加超时上下文和错误处理辅助函数
 func executeCommandWithTimeout(name string, args ...string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	return executeCommandWithEnv(5*time.Second, nil, name, args...)
+}
+
+// executeCommandWithEnv 使用指定的超时和附加环境变量执行命令
+// 命令的标准输入为空，需要交互输入（如密码提示）的命令会直接失败而不是挂起
+func executeCommandWithEnv(timeout time.Duration, env []string, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, name, args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	// 在Windows系统上隐藏命令窗口
 	if runtime.GOOS == "windows" {
@@ -120,83 +189,93 @@ func commandExists(name string) bool {
 	return err == nil
 }
 
+// languageDetector 将语言名称与对应的检测函数关联，名称与 LanguageInfo.Name 一致
+type languageDetector struct {
+	name   string
+	detect func() LanguageInfo
+}
+
+// languageDetectors 返回所有要检测的语言
+func (a *App) languageDetectors() []languageDetector {
+	return []languageDetector{
+		{"Go", a.detectGo},
+		{"Python", a.detectPython},
+		{"Node.js", a.detectNode},
+		{"Java", a.detectJava},
+		{"C# (.NET)", a.detectCSharp},
+		{"Ruby", a.detectRubyWithPackages},
+		{"PHP", a.detectPHP},
+		{"Rust", a.detectRust},
+		{"C/C++", a.detectCppWithPackages},
+		{"Swift", a.detectSwiftWithPackages},
+		{"Kotlin", a.detectKotlinWithPackages},
+		{"Dart", a.detectDartWithPackages},
+		{"TypeScript", a.detectTypeScriptWithPackages},
+		{"Perl", a.detectPerlWithPackages},
+		{"Lua", a.detectLuaWithPackages},
+		{"R", a.detectRWithPackages},
+		{"MATLAB", a.detectMatlabWithPackages},
+		{"Scala", a.detectScalaWithPackages},
+		{"Haskell", a.detectHaskellWithPackages},
+		{"Objective-C", a.detectObjectiveCWithPackages},
+		{"Groovy", a.detectGroovyWithPackages},
+		{"Clojure", a.detectClojureWithPackages},
+		{"Elixir", a.detectElixirWithPackages},
+		{"F#", a.detectFSharpWithPackages},
+		{"Julia", a.detectJuliaWithPackages},
+		{"Prolog", a.detectPrologWithPackages},
+		{"Assembly", a.detectAssemblyWithPackages},
+		{"COBOL", a.detectCOBOLWithPackages},
+		{"Fortran", a.detectFortranWithPackages},
+		{"Delphi/Pascal", a.detectDelphiWithPackages},
+		{"Lisp", a.detectLispWithPackages},
+		{"Scheme", a.detectSchemeWithPackages},
+		{"Crystal", a.detectCrystalWithPackages},
+		{"Nim", a.detectNimWithPackages},
+		{"D", a.detectDWithPackages},
+		{"VHDL", a.detectVHDLWithPackages},
+		{"Erlang", a.detectErlangWithPackages},
+		{"Smalltalk", a.detectSmalltalkWithPackages},
+		{"OCaml", a.detectOCamlWithPackages},
+		{"Tcl", a.detectTclWithPackages},
+		{"Bash", a.detectBashWithPackages},
+		{"PowerShell", a.detectPowerShellWithPackages},
+		{"VBA", a.detectVBAWithPackages},
+		{"SQL", a.detectSQLWithPackages},
+		{"HTML/CSS", a.detectHTMLWithPackages},
+		{"Apex", a.detectApexWithPackages},
+		{"Solidity", a.detectSolidityWithPackages},
+		{"WebAssembly", a.detectWebAssemblyWithPackages},
+		{"Zig", a.detectZigWithPackages},
+		{"Haxe", a.detectHaxeWithPackages},
+		{"ABAP", a.detectABAPWithPackages},
+		{"ActionScript", a.detectActionScriptWithPackages},
+		{"APL", a.detectAPLWithPackages},
+		{"Ballerina", a.detectBallerinaWithPackages},
+		{"BASIC", a.detectBASICWithPackages},
+		{"Boo", a.detectBooWithPackages},
+		{"Ceylon", a.detectCeylonWithPackages},
+		{"CoffeeScript", a.detectCoffeeScriptWithPackages},
+		{"Elm", a.detectElmWithPackages},
+		{"Hack", a.detectHackWithPackages},
+		{"J", a.detectJWithPackages},
+		{"Jython", a.detectJythonWithPackages},
+		{"LOLCODE", a.detectLOLCODEWithPackages},
+		{"PureScript", a.detectPureScriptWithPackages},
+		{"Q#", a.detectQSharpWithPackages},
+		{"Red", a.detectRedWithPackages},
+		{"ReScript", a.detectReScriptWithPackages},
+		{"Scratch", a.detectScratchWithPackages},
+		{"Vala", a.detectValaWithPackages},
+		{"XSLT", a.detectXSLTWithPackages},
+	}
+}
+
 // DetectLanguages 并行检测系统中安装的编程语言
 func (a *App) DetectLanguages() []LanguageInfo {
 	fmt.Println("开始检测编程语言...")
 
-	// 定义所有要检测的语言函数
-	detectors := []func() LanguageInfo{
-		a.detectGo,
-		a.detectPython,
-		a.detectNode,
-		a.detectJava,
-		a.detectCSharp,
-		a.detectRubyWithPackages,
-		a.detectPHP,
-		a.detectRust,
-		a.detectCppWithPackages,
-		a.detectSwiftWithPackages,
-		a.detectKotlinWithPackages,
-		a.detectDartWithPackages,
-		a.detectTypeScriptWithPackages,
-		a.detectPerlWithPackages,
-		a.detectLuaWithPackages,
-		a.detectRWithPackages,
-		a.detectMatlabWithPackages,
-		a.detectScalaWithPackages,
-		a.detectHaskellWithPackages,
-		a.detectObjectiveCWithPackages,
-		a.detectGroovyWithPackages,
-		a.detectClojureWithPackages,
-		a.detectElixirWithPackages,
-		a.detectFSharpWithPackages,
-		a.detectJuliaWithPackages,
-		a.detectPrologWithPackages,
-		a.detectAssemblyWithPackages,
-		a.detectCOBOLWithPackages,
-		a.detectFortranWithPackages,
-		a.detectDelphiWithPackages,
-		a.detectLispWithPackages,
-		a.detectSchemeWithPackages,
-		a.detectCrystalWithPackages,
-		a.detectNimWithPackages,
-		a.detectDWithPackages,
-		a.detectVHDLWithPackages,
-		a.detectErlangWithPackages,
-		a.detectSmalltalkWithPackages,
-		a.detectOCamlWithPackages,
-		a.detectTclWithPackages,
-		a.detectBashWithPackages,
-		a.detectPowerShellWithPackages,
-		a.detectVBAWithPackages,
-		a.detectSQLWithPackages,
-		a.detectHTMLWithPackages,
-		a.detectApexWithPackages,
-		a.detectSolidityWithPackages,
-		a.detectWebAssemblyWithPackages,
-		a.detectZigWithPackages,
-		a.detectHaxeWithPackages,
-		a.detectABAPWithPackages,
-		a.detectActionScriptWithPackages,
-		a.detectAPLWithPackages,
-		a.detectBallerinaWithPackages,
-		a.detectBASICWithPackages,
-		a.detectBooWithPackages,
-		a.detectCeylonWithPackages,
-		a.detectCoffeeScriptWithPackages,
-		a.detectElmWithPackages,
-		a.detectHackWithPackages,
-		a.detectJWithPackages,
-		a.detectJythonWithPackages,
-		a.detectLOLCODEWithPackages,
-		a.detectPureScriptWithPackages,
-		a.detectQSharpWithPackages,
-		a.detectRedWithPackages,
-		a.detectReScriptWithPackages,
-		a.detectScratchWithPackages,
-		a.detectValaWithPackages,
-		a.detectXSLTWithPackages,
-	}
+	detectors := a.languageDetectors()
 
 	// 创建一个通道来接收检测结果
 	resultChan := make(chan LanguageInfo, len(detectors))
@@ -224,7 +303,7 @@ func (a *App) DetectLanguages() []LanguageInfo {
 
 			// 打印检测进度信息
 			fmt.Printf("已检测: %s\n", result.Name)
-		}(detector)
+		}(detector.detect)
 	}
 
 	// 启动一个goroutine等待所有检测完成并关闭结果通道
@@ -240,9 +319,22 @@ func (a *App) DetectLanguages() []LanguageInfo {
 	}
 
 	fmt.Printf("已完成所有语言检测，共检测 %d 种语言\n", len(languages))
+	a.cacheDetectedLanguages(languages)
 	return languages
 }
 
+// DetectLanguage 只检测指定名称的语言（不区分大小写）
+func (a *App) DetectLanguage(name string) (LanguageInfo, error) {
+	for _, detector := range a.languageDetectors() {
+		if strings.EqualFold(detector.name, strings.TrimSpace(name)) {
+			info := detector.detect()
+			a.updateDetectedLanguage(info)
+			return info, nil
+		}
+	}
+	return LanguageInfo{}, fmt.Errorf("不支持检测的语言: %s", name)
+}
+
 // GetPackageTutorials 获取包管理器教程
 func (a *App) GetPackageTutorials() []PackageTutorial {
 	tutorials := []PackageTutorial{
@@ -362,14 +454,48 @@ func (a *App) GetPackageTutorials() []PackageTutorial {
 	return tutorials
 }
 
-// AIProvider 存储AI提供商信息
-type AIProvider struct {
+// AIProviderInfo 存储AI提供商信息
+type AIProviderInfo struct {
 	ID           string `json:"id"`
 	Name         string `json:"name"`
 	EndpointURL  string `json:"endpointUrl"`
 	DocumentURL  string `json:"documentUrl"`
 	ApiKeyHeader string `json:"apiKeyHeader"`
 	ApiKeyPrefix string `json:"apiKeyPrefix"`
+	// ContextWindow 模型的上下文窗口大小（token数），用于裁剪对话历史
+	ContextWindow int `json:"contextWindow"`
+	// 可选模型和默认生成参数
+	Models           []string `json:"models"`
+	DefaultModel     string   `json:"defaultModel"`
+	DefaultMaxTokens int      `json:"defaultMaxTokens"`
+	DefaultPrompt    string   `json:"defaultPrompt"`
+	// Local 为true时表示本机运行的模型服务，不需要API密钥
+	Local bool `json:"local"`
+	// CredentialFields 除API密钥外该提供商需要的凭据字段
+	CredentialFields []AICredentialField `json:"credentialFields,omitempty"`
+	// UserDefined 为true时表示来自用户的提供商注册文件
+	UserDefined bool `json:"userDefined,omitempty"`
+}
+
+// AICredentialField 描述提供商的一个凭据字段，Secret 字段加密保存且只以脱敏形式返回给前端
+type AICredentialField struct {
+	Name        string `json:"name"`
+	Label       string `json:"label"`
+	Placeholder string `json:"placeholder,omitempty"`
+	Default     string `json:"default,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	Secret      bool   `json:"secret,omitempty"`
+}
+
+// AIProviderSettings 存储单个提供商的模型和生成参数
+type AIProviderSettings struct {
+	Model        string            `json:"model"`
+	Temperature  *float64          `json:"temperature"`
+	MaxTokens    int               `json:"maxTokens"`
+	SystemPrompt string            `json:"systemPrompt"`
+	ExtraHeaders map[string]string `json:"extraHeaders"`
+	// DisableTools 该提供商（或所选模型）不支持工具调用时关闭工具
+	DisableTools bool `json:"disableTools,omitempty"`
 }
 
 // AIConfig 存储AI配置信息
@@ -377,14 +503,31 @@ type AIConfig struct {
 	SelectedProviderID string `json:"selectedProviderId"`
 	APIKey             string `json:"apiKey"`
 	CustomEndpoint     string `json:"customEndpoint"`
+	// PackageEnrichment 是否在搜索包时使用AI补充信息，默认关闭
+	PackageEnrichment bool `json:"packageEnrichment"`
+	// OfflineMode 离线/隐私模式，开启后不会发出任何AI请求
+	OfflineMode bool `json:"offlineMode"`
+	// ProviderSettings 按提供商ID保存的模型和生成参数
+	ProviderSettings map[string]AIProviderSettings `json:"providerSettings"`
+	// EnableTools 是否允许AI在回答时调用应用内的工具（包搜索、语言检测等）
+	EnableTools bool `json:"enableTools"`
+	// Credentials 按提供商ID保存的结构化凭据（如Azure资源名、百度Secret Key）
+	Credentials map[string]map[string]string `json:"credentials"`
+	// 以下字段仅用于返回给前端，不写入磁盘
+	HasAPIKey bool `json:"hasApiKey,omitempty"`
+	KeyLocked bool `json:"keyLocked,omitempty"`
+	// PassphraseProtected 密钥是否使用口令加密
+	PassphraseProtected bool `json:"passphraseProtected,omitempty"`
 }
 
 // AIResponse 存储AI响应信息
 type AIResponse struct {
-	Content  string `json:"content"`
-	Error    string `json:"error"`
-	Success  bool   `json:"success"`
-	Provider string `json:"provider"`
+	Content string `json:"content"`
+	Error   string `json:"error"`
+	// ErrorType 错误分类（auth、rate_limited、quota_exceeded等），成功时为空
+	ErrorType string `json:"errorType,omitempty"`
+	Success   bool   `json:"success"`
+	Provider  string `json:"provider"`
 }
 
 // ThemeConfig 存储主题配置
@@ -517,60 +660,45 @@ func (a *App) getThemeConfigPath() string {
 }
 
 // GetAIProviders 获取支持的AI提供商列表
-func (a *App) GetAIProviders() []AIProvider {
-	providers := []AIProvider{
-		{
-			ID:           "openai",
-			Name:         "OpenAI (ChatGPT)",
-			EndpointURL:  "https://api.openai.com/v1/chat/completions",
-			DocumentURL:  "https://platform.openai.com/docs/api-reference",
-			ApiKeyHeader: "Authorization",
-			ApiKeyPrefix: "Bearer ",
-		},
-		{
-			ID:           "azure_openai",
-			Name:         "Azure OpenAI",
-			EndpointURL:  "https://YOUR_RESOURCE_NAME.openai.azure.com/openai/deployments/YOUR_DEPLOYMENT_NAME/chat/completions?api-version=2023-05-15",
-			DocumentURL:  "https://learn.microsoft.com/zh-cn/azure/ai-services/openai/reference",
-			ApiKeyHeader: "api-key",
-			ApiKeyPrefix: "",
-		},
-		{
-			ID:           "anthropic",
-			Name:         "Anthropic (Claude)",
-			EndpointURL:  "https://api.anthropic.com/v1/messages",
-			DocumentURL:  "https://docs.anthropic.com/claude/reference/getting-started-with-the-api",
-			ApiKeyHeader: "x-api-key",
-			ApiKeyPrefix: "",
-		},
-		{
-			ID:           "baidu",
-			Name:         "百度文心一言",
-			EndpointURL:  "https://aip.baidubce.com/rpc/2.0/ai_custom/v1/wenxinworkshop/chat/completions",
-			DocumentURL:  "https://cloud.baidu.com/doc/WENXINWORKSHOP/s/jlil56u11",
-			ApiKeyHeader: "Authorization",
-			ApiKeyPrefix: "",
-		},
-		{
-			ID:           "aliyun",
-			Name:         "阿里通义千问",
-			EndpointURL:  "https://dashscope.aliyuncs.com/api/v1/services/aigc/text-generation/generation",
-			DocumentURL:  "https://help.aliyun.com/document_detail/2400395.html",
-			ApiKeyHeader: "Authorization",
-			ApiKeyPrefix: "Bearer ",
-		},
-		{
-			ID:           "custom",
-			Name:         "自定义API",
-			EndpointURL:  "",
-			DocumentURL:  "",
-			ApiKeyHeader: "Authorization",
-			ApiKeyPrefix: "Bearer ",
-		},
+func (a *App) GetAIProviders() []AIProviderInfo {
+	// 每次重新读取注册文件，手动编辑后无需重启
+	// 网络设置只在启动和保存时加载，这里不重建共享Transport，避免中断进行中的请求
+	if err := a.reloadUserAIProviders(); err != nil {
+		fmt.Printf("加载自定义AI提供商失败: %v\n", err)
+	}
+
+	providers := []AIProviderInfo{}
+	for _, provider := range append(append([]AIProvider{}, aiProviderRegistry...), userAIProviders()...) {
+		info := provider.Info()
+		info.DefaultPrompt = a.defaultSystemPrompt()
+		providers = append(providers, info)
 	}
 	return providers
 }
 
+// ListAIModels 从提供商的模型列表接口获取可用模型
+// 提供商不支持查询时返回内置的模型列表
+func (a *App) ListAIModels(providerID string, apiKey string, customEndpoint string) ([]string, error) {
+	provider, chatReq, errResp := a.prepareAIRequest(providerID, apiKey, customEndpoint, nil)
+	if errResp != nil {
+		return nil, fmt.Errorf("%s", errResp.Error)
+	}
+
+	lister, ok := provider.(AIModelLister)
+	if !ok {
+		return provider.Info().Models, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	models, err := lister.ListModels(ctx, chatReq)
+	if err != nil {
+		return provider.Info().Models, err
+	}
+	return models, nil
+}
+
 // 获取配置文件路径
 func (a *App) getAIConfigPath() string {
 	homeDir, err := os.UserHomeDir()
@@ -588,341 +716,246 @@ func (a *App) getAIConfigPath() string {
 }
 
 // SaveAIConfig 保存AI配置
+// API密钥和机密凭据加密后保存；传入空值或脱敏预览时保留原有的值
 func (a *App) SaveAIConfig(config AIConfig) error {
-	configPath := a.getAIConfigPath()
-	data, err := json.MarshalIndent(config, "", "  ")
+	stored, err := a.readStoredAIConfig()
 	if err != nil {
-		return err
+		stored = storedAIConfig{}
 	}
 
-	return os.WriteFile(configPath, data, 0644)
-}
-
-// GetAIConfig 获取AI配置
-func (a *App) GetAIConfig() AIConfig {
-	configPath := a.getAIConfigPath()
-
-	// 如果配置文件不存在，返回默认配置
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return AIConfig{
-			SelectedProviderID: "openai",
-			APIKey:             "",
-			CustomEndpoint:     "",
-		}
+	existing, err := a.loadAIConfig()
+	if err != nil && (stored.EncryptedAPIKey != "" || stored.EncryptedCredentials != "") {
+		return err
 	}
 
-	// 读取配置文件
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return AIConfig{
-			SelectedProviderID: "openai",
-			APIKey:             "",
-			CustomEndpoint:     "",
-		}
+	plainKey := config.APIKey
+	if plainKey == "" || isMaskedAPIKey(plainKey) {
+		plainKey = existing.APIKey
 	}
 
-	// 解析配置
-	var config AIConfig
-	if err := json.Unmarshal(data, &config); err != nil {
-		return AIConfig{
-			SelectedProviderID: "openai",
-			APIKey:             "",
-			CustomEndpoint:     "",
+	for providerID, fields := range config.Credentials {
+		for name, value := range fields {
+			if isSecretCredential(providerID, name) && (value == "" || isMaskedAPIKey(value)) {
+				fields[name] = existing.Credentials[providerID][name]
+			}
 		}
 	}
 
+	config.HasAPIKey = false
+	config.KeyLocked = false
+	config.PassphraseProtected = false
+	stored.AIConfig = config
+
+	return a.writeStoredAIConfig(stored, plainKey)
+}
+
+// GetAIConfig 获取AI配置
+// 返回给前端的API密钥只包含脱敏预览
+func (a *App) GetAIConfig() AIConfig {
+	stored, _ := a.readStoredAIConfig()
+
+	config, err := a.loadAIConfig()
+	if err != nil {
+		// 密钥使用口令加密且尚未解锁，或配置损坏
+		config = stored.AIConfig
+		config.APIKey = ""
+		config.HasAPIKey = stored.EncryptedAPIKey != ""
+		config.KeyLocked = stored.EncryptedAPIKey != "" || stored.EncryptedCredentials != ""
+		config.PassphraseProtected = stored.KeySource == keySourcePassphrase
+		return config
+	}
+
+	config.HasAPIKey = config.APIKey != ""
+	config.APIKey = maskAPIKey(config.APIKey)
+	config.Credentials = maskSecretCredentials(config.Credentials)
+	config.PassphraseProtected = stored.KeySource == keySourcePassphrase
 	return config
 }
 
-// QueryAI 向AI发送查询
-func (a *App) QueryAI(providerID string, apiKey string, customEndpoint string, query string) AIResponse {
-	// 获取提供商信息
-	var selectedProvider AIProvider
-	found := false
-
-	for _, provider := range a.GetAIProviders() {
-		if provider.ID == providerID {
-			selectedProvider = provider
-			found = true
-			break
-		}
-	}
+// prepareAIRequest 查找提供商并准备对话请求，失败时返回错误响应
+func (a *App) prepareAIRequest(providerID string, apiKey string, customEndpoint string, messages []ChatMessage) (AIProvider, ChatRequest, *AIResponse) {
+	return a.prepareAIRequestWithCredentials(providerID, apiKey, customEndpoint, nil, messages)
+}
 
+// prepareAIRequestWithCredentials 同 prepareAIRequest，overrides 不为nil时代替已保存的凭据（用于保存前测试连接）
+func (a *App) prepareAIRequestWithCredentials(providerID string, apiKey string, customEndpoint string, overrides map[string]string, messages []ChatMessage) (AIProvider, ChatRequest, *AIResponse) {
+	// 获取提供商信息
+	provider, found := findAIProvider(providerID)
 	if !found {
-		return AIResponse{
+		return nil, ChatRequest{}, &AIResponse{
 			Success: false,
 			Error:   "未找到指定的AI提供商",
 		}
 	}
 
-	// 使用自定义端点（如果有）
-	endpoint := selectedProvider.EndpointURL
-	if providerID == "custom" && customEndpoint != "" {
-		endpoint = customEndpoint
-	} else if providerID == "azure_openai" && customEndpoint != "" {
+	// 配置只读取一次：口令模式下每次读取都要重新派生密钥
+	config, _ := a.loadAIConfig()
+	credentials := credentialsWithDefaults(provider.Info(), resolveCredentials(providerID, config.Credentials[providerID], overrides))
+
+	// 使用自定义端点（如果有），其次使用根据凭据生成的端点
+	endpoint := provider.Info().EndpointURL
+	if builder, ok := provider.(AICredentialEndpoint); ok {
+		if built := builder.EndpointFromCredentials(credentials); built != "" {
+			endpoint = built
+		}
+	}
+	if (providerID == "custom" || providerID == "azure_openai" || provider.Info().Local) && customEndpoint != "" {
 		endpoint = customEndpoint
 	}
 
-	// 准备请求
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+	// 离线/隐私模式下只允许访问本机上的本地模型
+	if config.OfflineMode && !(provider.Info().Local && isLoopbackEndpoint(endpoint)) {
+		return nil, ChatRequest{}, &AIResponse{
+			Success: false,
+			Error:   "已开启离线/隐私模式，只能使用本机上的本地模型",
+		}
 	}
 
-	var reqBody []byte
-	var err error
-
-	// 根据不同提供商准备请求体
-	switch providerID {
-	case "openai":
-		reqBody, err = json.Marshal(map[string]interface{}{
-			"model": "gpt-3.5-turbo",
-			"messages": []map[string]string{
-				{
-					"role":    "system",
-					"content": "你是一个专注于编程开发问题的AI助手，请提供准确、具体的编程帮助。",
-				},
-				{
-					"role":    "user",
-					"content": query,
-				},
-			},
-		})
-	case "azure_openai":
-		reqBody, err = json.Marshal(map[string]interface{}{
-			"messages": []map[string]string{
-				{
-					"role":    "system",
-					"content": "你是一个专注于编程开发问题的AI助手，请提供准确、具体的编程帮助。",
-				},
-				{
-					"role":    "user",
-					"content": query,
-				},
-			},
-		})
-	case "anthropic":
-		reqBody, err = json.Marshal(map[string]interface{}{
-			"model":      "claude-3-haiku-20240307",
-			"max_tokens": 1000,
-			"messages": []map[string]string{
-				{
-					"role":    "user",
-					"content": query,
-				},
-			},
-			"system": "你是一个专注于编程开发问题的AI助手，请提供准确、具体的编程帮助。",
-		})
-	case "baidu":
-		reqBody, err = json.Marshal(map[string]interface{}{
-			"messages": []map[string]string{
-				{
-					"role":    "user",
-					"content": query,
-				},
-			},
-		})
-	case "aliyun":
-		reqBody, err = json.Marshal(map[string]interface{}{
-			"model": "qwen-max",
-			"input": map[string]interface{}{
-				"messages": []map[string]string{
-					{
-						"role":    "system",
-						"content": "你是一个专注于编程开发问题的AI助手，请提供准确、具体的编程帮助。",
-					},
-					{
-						"role":    "user",
-						"content": query,
-					},
-				},
-			},
-		})
-	case "custom":
-		// 对于自定义API，使用OpenAI格式作为默认
-		reqBody, err = json.Marshal(map[string]interface{}{
-			"model": "gpt-3.5-turbo",
-			"messages": []map[string]string{
-				{
-					"role":    "system",
-					"content": "你是一个专注于编程开发问题的AI助手，请提供准确、具体的编程帮助。",
-				},
-				{
-					"role":    "user",
-					"content": query,
-				},
-			},
-		})
+	// 本地模型不需要API密钥；自定义提供商使用各自保存的密钥，不使用全局密钥
+	if provider.Info().Local {
+		apiKey = ""
+	} else if provider.Info().UserDefined {
+		apiKey = credentials["apiKey"]
+	} else {
+		apiKey = resolveAPIKey(apiKey, config)
+	}
+
+	systemPrompt, maxTokens := a.requestPromptSettings(provider, providerID, config)
+	req := ChatRequest{
+		Endpoint:     endpoint,
+		APIKey:       apiKey,
+		SystemPrompt: systemPrompt,
+		// 发送前对用户消息中的密钥、密码等敏感内容脱敏
+		Messages:    a.redactMessages(messages),
+		Model:       provider.Info().DefaultModel,
+		MaxTokens:   maxTokens,
+		Credentials: credentials,
+	}
+
+	// 需要换取访问令牌的提供商先获取令牌
+	if exchanger, ok := provider.(AITokenExchanger); ok && apiKey != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		token, err := a.getAccessToken(ctx, provider, exchanger, apiKey, credentials)
+		cancel()
+		if err != nil {
+			response := a.aiErrorResponse(err, provider.Info().Name, "")
+			return nil, ChatRequest{}, &response
+		}
+		req.AccessToken = token
 	}
 
-	if err != nil {
-		return AIResponse{
-			Success: false,
-			Error:   "准备请求失败: " + err.Error(),
+	// 应用用户为该提供商保存的模型和生成参数
+	if settings, ok := config.ProviderSettings[providerID]; ok {
+		if settings.Model != "" {
+			req.Model = settings.Model
 		}
+		req.Temperature = settings.Temperature
 	}
 
-	// 创建请求
-	req, err := http.NewRequest("POST", endpoint, strings.NewReader(string(reqBody)))
-	if err != nil {
-		return AIResponse{
-			Success: false,
-			Error:   "创建请求失败: " + err.Error(),
+	// 提供商自带的请求头在前，用户设置的请求头可以覆盖
+	req.ExtraHeaders = map[string]string{}
+	if defaults, ok := provider.(AIDefaultHeaders); ok {
+		for key, value := range defaults.DefaultHeaders() {
+			req.ExtraHeaders[key] = value
 		}
 	}
+	for key, value := range config.ProviderSettings[providerID].ExtraHeaders {
+		req.ExtraHeaders[key] = value
+	}
 
-	// 设置请求头
-	req.Header.Set("Content-Type", "application/json")
+	return provider, req, nil
+}
 
-	// 添加API密钥
-	apiKeyValue := selectedProvider.ApiKeyPrefix + apiKey
-	req.Header.Set(selectedProvider.ApiKeyHeader, apiKeyValue)
+// requestPromptSettings 返回请求实际使用的系统提示词和最大回复token数
+// 用户为提供商保存的设置优先，其次是界面语言对应的默认提示词和提供商的默认值
+func (a *App) requestPromptSettings(provider AIProvider, providerID string, config AIConfig) (string, int) {
+	systemPrompt := a.defaultSystemPrompt()
+	maxTokens := provider.Info().DefaultMaxTokens
 
-	// 发送请求
-	resp, err := client.Do(req)
-	if err != nil {
-		return AIResponse{
-			Success: false,
-			Error:   "发送请求失败: " + err.Error(),
+	if settings, ok := config.ProviderSettings[providerID]; ok {
+		if settings.MaxTokens > 0 {
+			maxTokens = settings.MaxTokens
 		}
-	}
-	defer resp.Body.Close()
-
-	// 读取响应
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return AIResponse{
-			Success: false,
-			Error:   "读取响应失败: " + err.Error(),
+		if settings.SystemPrompt != "" {
+			systemPrompt = settings.SystemPrompt
 		}
 	}
+	return systemPrompt, maxTokens
+}
 
-	// 检查状态码
-	if resp.StatusCode != http.StatusOK {
-		return AIResponse{
-			Success: false,
-			Error:   fmt.Sprintf("API返回错误 (状态码: %d): %s", resp.StatusCode, string(respBody)),
-		}
+// QueryAI 向AI发送查询
+func (a *App) QueryAI(providerID string, apiKey string, customEndpoint string, query string) AIResponse {
+	provider, chatReq, errResp := a.prepareAIRequest(providerID, apiKey, customEndpoint, []ChatMessage{
+		{Role: "user", Content: query},
+	})
+	if errResp != nil {
+		return *errResp
 	}
 
-	// 解析响应
-	var content string
+	respBody, err := a.doAIRequest(context.Background(), provider, chatReq, 30*time.Second)
+	if err != nil {
+		return a.aiErrorResponse(err, provider.Info().Name, "")
+	}
 
-	// 根据不同提供商解析响应
-	switch providerID {
-	case "openai", "azure_openai":
-		var openaiResp struct {
-			Choices []struct {
-				Message struct {
-					Content string `json:"content"`
-				} `json:"message"`
-			} `json:"choices"`
-		}
-		if err := json.Unmarshal(respBody, &openaiResp); err != nil || len(openaiResp.Choices) == 0 {
-			return AIResponse{
-				Success: false,
-				Error:   "解析响应失败: " + err.Error(),
-			}
-		}
-		content = openaiResp.Choices[0].Message.Content
-	case "anthropic":
-		var anthropicResp struct {
-			Content []struct {
-				Text string `json:"text"`
-			} `json:"content"`
-		}
-		if err := json.Unmarshal(respBody, &anthropicResp); err != nil || len(anthropicResp.Content) == 0 {
-			return AIResponse{
-				Success: false,
-				Error:   "解析响应失败: " + err.Error(),
-			}
-		}
-		content = anthropicResp.Content[0].Text
-	case "baidu":
-		var baiduResp struct {
-			Result string `json:"result"`
-		}
-		if err := json.Unmarshal(respBody, &baiduResp); err != nil {
-			return AIResponse{
-				Success: false,
-				Error:   "解析响应失败: " + err.Error(),
-			}
-		}
-		content = baiduResp.Result
-	case "aliyun":
-		var aliyunResp struct {
-			Output struct {
-				Choices []struct {
-					Message struct {
-						Content string `json:"content"`
-					} `json:"message"`
-				} `json:"choices"`
-			} `json:"output"`
-		}
-		if err := json.Unmarshal(respBody, &aliyunResp); err != nil || len(aliyunResp.Output.Choices) == 0 {
-			return AIResponse{
-				Success: false,
-				Error:   "解析响应失败: " + err.Error(),
-			}
-		}
-		content = aliyunResp.Output.Choices[0].Message.Content
-	case "custom":
-		// 尝试使用OpenAI格式解析
-		var openaiResp struct {
-			Choices []struct {
-				Message struct {
-					Content string `json:"content"`
-				} `json:"message"`
-			} `json:"choices"`
-		}
-		if err := json.Unmarshal(respBody, &openaiResp); err != nil || len(openaiResp.Choices) == 0 {
-			// 如果失败，返回原始响应
-			content = string(respBody)
-		} else {
-			content = openaiResp.Choices[0].Message.Content
-		}
+	content, err := provider.ParseResponse(respBody)
+	if err != nil {
+		return a.aiErrorResponse(err, provider.Info().Name, "")
 	}
 
 	return AIResponse{
 		Content:  content,
 		Success:  true,
-		Provider: selectedProvider.Name,
+		Provider: provider.Info().Name,
 	}
 }
 
 // SearchPackage 搜索指定包管理器中的包
+// 开启AI补充包信息时在后台查询，结果通过 package:enriched 事件推送，不阻塞搜索
 func (a *App) SearchPackage(packageManager string, packageName string) []PackageInfo {
+	packages := a.searchPackageRegistry(packageManager, packageName)
+	if len(packages) > 0 {
+		go a.enrichPackageInfoWithAI(append([]PackageInfo{}, packages...), packageManager, packageName)
+	}
+	return packages
+}
+
+// searchPackageRegistry 在注册表中搜索包并添加安装链接，不使用AI
+func (a *App) searchPackageRegistry(packageManager string, packageName string) []PackageInfo {
 	fmt.Printf("搜索包: %s (使用 %s)\n", packageName, packageManager)
 
 	if packageName == "" {
 		return []PackageInfo{}
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
 	var packages []PackageInfo
 	var err error
 
 	switch packageManager {
 	case "npm":
-		packages, err = a.searchNpmPackage(packageName)
+		packages, err = a.searchNpmPackage(ctx, packageName)
 	case "pip":
-		packages, err = a.searchPipPackage(packageName)
+		packages, err = a.searchPipPackage(ctx, packageName)
 	case "gem":
-		packages, err = a.searchGemPackage(packageName)
+		packages, err = a.searchGemPackage(ctx, packageName)
 	case "cargo":
-		packages, err = a.searchCargoPackage(packageName)
+		packages, err = a.searchCargoPackage(ctx, packageName)
 	case "composer":
-		packages, err = a.searchComposerPackage(packageName)
+		packages, err = a.searchComposerPackage(ctx, packageName)
 	case "nuget":
-		packages, err = a.searchNuGetPackage(packageName)
+		packages, err = a.searchNuGetPackage(ctx, packageName)
 	case "maven":
-		packages, err = a.searchMavenPackage(packageName)
+		packages, err = a.searchMavenPackage(ctx, packageName)
 	case "go":
-		packages, err = a.searchGoPackage(packageName)
+		packages, err = a.searchGoPackage(ctx, packageName)
 	case "dub":
-		packages, err = a.searchDubPackage(packageName)
+		packages, err = a.searchDubPackage(ctx, packageName)
 	case "hex":
-		packages, err = a.searchHexPackage(packageName)
+		packages, err = a.searchHexPackage(ctx, packageName)
 	case "nimble":
-		packages, err = a.searchNimblePackage(packageName)
+		packages, err = a.searchNimblePackage(ctx, packageName)
 	case "brew":
 		packages, err = a.searchBrewPackage(packageName)
 	default:
@@ -939,9 +972,6 @@ func (a *App) SearchPackage(packageManager string, packageName string) []Package
 		a.addPackageLinks(&packages[i], packageManager)
 	}
 
-	// 尝试使用AI获取更多信息
-	a.enrichPackageInfoWithAI(packages, packageManager, packageName)
-
 	return packages
 }
 
@@ -993,685 +1023,114 @@ func (a *App) addPackageLinks(pkg *PackageInfo, packageManager string) {
 	}
 }
 
-// enrichPackageInfoWithAI 使用AI API丰富包信息
-func (a *App) enrichPackageInfoWithAI(packages []PackageInfo, packageManager, packageName string) {
-	// 只处理前5个包，避免过多的API调用
-	if len(packages) > 5 {
-		packages = packages[:5]
-	}
-
-	// 随机选择一个API
-	apis := []struct {
-		name   string
-		apiKey string
-		url    string
-	}{
-		{
-			name:   "通义千问",
-			apiKey: "sk-07ef4701031d41668beebb521e80eaf0",
-			url:    "https://dashscope.aliyuncs.com/api/v1/services/aigc/text-generation/generation",
-		},
-		{
-			name:   "DeepSeek",
-			apiKey: "sk-0b2be14756fe4195a7bc2bcb78d19f8f",
-			url:    "https://api.deepseek.com/v1/chat/completions",
-		},
-	}
+// packageEnrichmentEventName AI补充包信息完成后推送的事件
+const packageEnrichmentEventName = "package:enriched"
 
-	// 随机选择一个API
-	apiIndex := time.Now().Unix() % int64(len(apis))
-	selectedAPI := apis[apiIndex]
+// packageEnrichmentTimeout AI补充包信息的总时限，超时后放弃而不重试
+const packageEnrichmentTimeout = 15 * time.Second
 
-	for i, pkg := range packages {
-		// 构建查询
-		query := fmt.Sprintf("请提供%s包管理器中%s包的最新下载链接和安装命令，只返回JSON格式，包含downloadUrl和installCommand字段", packageManager, pkg.Name)
-
-		// 调用AI API获取信息
-		result := a.callPackageInfoAPI(selectedAPI.name, selectedAPI.apiKey, selectedAPI.url, query)
-		if result != nil {
-			// 尝试解析结果
-			downloadURL, _ := result["downloadUrl"].(string)
-			installCommand, _ := result["installCommand"].(string)
-
-			// 如果有有效信息，则更新包
-			if downloadURL != "" && downloadURL != "unknown" {
-				packages[i].DownloadURL = downloadURL
-			}
-			if installCommand != "" && installCommand != "unknown" {
-				packages[i].InstallLink = installCommand
-			}
-		}
-	}
+// PackageEnrichmentEvent AI补充的包信息，只包含有建议的包
+type PackageEnrichmentEvent struct {
+	Manager  string        `json:"manager"`
+	Query    string        `json:"query"`
+	Packages []PackageInfo `json:"packages"`
 }
 
-// callPackageInfoAPI 调用AI API获取包信息
-func (a *App) callPackageInfoAPI(apiName, apiKey, apiURL, query string) map[string]interface{} {
-	client := &http.Client{
-		Timeout: 5 * time.Second,
-	}
-
-	var reqBody []byte
-	var err error
-
-	// 根据不同API构建请求体
-	switch apiName {
-	case "通义千问":
-		reqBody, err = json.Marshal(map[string]interface{}{
-			"model": "qwen-max",
-			"input": map[string]interface{}{
-				"messages": []map[string]string{
-					{
-						"role":    "system",
-						"content": "你是一个编程助手，请提供准确的包信息。只返回JSON格式，包含downloadUrl和installCommand字段。",
-					},
-					{
-						"role":    "user",
-						"content": query,
-					},
-				},
-			},
-		})
-	case "DeepSeek":
-		reqBody, err = json.Marshal(map[string]interface{}{
-			"model": "deepseek-chat",
-			"messages": []map[string]string{
-				{
-					"role":    "system",
-					"content": "你是一个编程助手，请提供准确的包信息。只返回JSON格式，包含downloadUrl和installCommand字段。",
-				},
-				{
-					"role":    "user",
-					"content": query,
-				},
-			},
-		})
-	default:
-		return nil
-	}
-
-	if err != nil {
-		fmt.Printf("准备AI请求失败: %v\n", err)
-		return nil
-	}
-
-	// 创建请求
-	req, err := http.NewRequest("POST", apiURL, strings.NewReader(string(reqBody)))
-	if err != nil {
-		fmt.Printf("创建AI请求失败: %v\n", err)
-		return nil
-	}
-
-	// 设置请求头
-	req.Header.Set("Content-Type", "application/json")
-
-	// 添加API密钥
-	switch apiName {
-	case "通义千问":
-		req.Header.Set("Authorization", "Bearer "+apiKey)
-	case "DeepSeek":
-		req.Header.Set("Authorization", "Bearer "+apiKey)
-	}
-
-	// 发送请求
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Printf("发送AI请求失败: %v\n", err)
-		return nil
-	}
-	defer resp.Body.Close()
-
-	// 读取响应
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Printf("读取AI响应失败: %v\n", err)
-		return nil
-	}
-
-	// 检查状态码
-	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("AI API返回错误 (状态码: %d): %s\n", resp.StatusCode, string(respBody))
-		return nil
-	}
-
-	// 解析响应
-	var content string
-
-	// 根据不同API解析响应
-	switch apiName {
-	case "通义千问":
-		var aliyunResp struct {
-			Output struct {
-				Choices []struct {
-					Message struct {
-						Content string `json:"content"`
-					} `json:"message"`
-				} `json:"choices"`
-			} `json:"output"`
-		}
-		if err := json.Unmarshal(respBody, &aliyunResp); err != nil || len(aliyunResp.Output.Choices) == 0 {
-			fmt.Printf("解析通义千问响应失败: %v\n", err)
-			return nil
-		}
-		content = aliyunResp.Output.Choices[0].Message.Content
-	case "DeepSeek":
-		var deepseekResp struct {
-			Choices []struct {
-				Message struct {
-					Content string `json:"content"`
-				} `json:"message"`
-			} `json:"choices"`
-		}
-		if err := json.Unmarshal(respBody, &deepseekResp); err != nil || len(deepseekResp.Choices) == 0 {
-			fmt.Printf("解析DeepSeek响应失败: %v\n", err)
-			return nil
-		}
-		content = deepseekResp.Choices[0].Message.Content
-	}
-
-	// 尝试从内容中提取JSON
-	jsonStart := strings.Index(content, "{")
-	jsonEnd := strings.LastIndex(content, "}")
-	if jsonStart >= 0 && jsonEnd > jsonStart {
-		jsonStr := content[jsonStart : jsonEnd+1]
-		var result map[string]interface{}
-		if err := json.Unmarshal([]byte(jsonStr), &result); err == nil {
-			return result
-		}
-	}
-
-	return nil
-}
-
-// searchNpmPackage 搜索npm包
-func (a *App) searchNpmPackage(packageName string) ([]PackageInfo, error) {
-	output, err := executeCommandWithTimeout("npm", "search", "--json", packageName)
-	if err != nil {
-		return nil, err
-	}
-
-	var results []map[string]interface{}
-	if err := json.Unmarshal([]byte(output), &results); err != nil {
-		// 尝试解析非JSON输出
-		lines := strings.Split(output, "\n")
-		packages := []PackageInfo{}
-
-		for _, line := range lines {
-			if strings.Contains(line, packageName) {
-				fields := strings.Fields(line)
-				if len(fields) >= 2 {
-					packages = append(packages, PackageInfo{
-						Name:        fields[0],
-						Version:     fields[1],
-						Description: strings.Join(fields[2:], " "),
-					})
-				}
-			}
-		}
-
-		return packages, nil
-	}
-
-	packages := []PackageInfo{}
-	for _, result := range results {
-		name, _ := result["name"].(string)
-		version, _ := result["version"].(string)
-		description, _ := result["description"].(string)
-
-		packages = append(packages, PackageInfo{
-			Name:        name,
-			Version:     version,
-			Description: description,
-		})
-
-		// 限制结果数量
-		if len(packages) >= 20 {
-			break
-		}
-	}
-
-	return packages, nil
-}
-
-// searchPipPackage 搜索pip包
-func (a *App) searchPipPackage(packageName string) ([]PackageInfo, error) {
-	// pip search已被弃用，使用PyPI API
-	url := fmt.Sprintf("https://pypi.org/pypi/%s/json", packageName)
-	resp, err := http.Get(url)
-	if err != nil {
-		// 尝试使用pip命令
-		output, err := executeCommandWithTimeout("pip", "search", packageName)
-		if err != nil {
-			return nil, err
-		}
-
-		lines := strings.Split(output, "\n")
-		packages := []PackageInfo{}
-
-		for _, line := range lines {
-			if strings.Contains(strings.ToLower(line), strings.ToLower(packageName)) {
-				parts := strings.SplitN(line, " - ", 2)
-				if len(parts) == 2 {
-					nameVer := strings.TrimSpace(parts[0])
-					desc := strings.TrimSpace(parts[1])
-
-					nameParts := strings.Split(nameVer, " ")
-					name := nameParts[0]
-					version := ""
-					if len(nameParts) > 1 {
-						version = strings.Trim(nameParts[1], "()")
-					}
-
-					packages = append(packages, PackageInfo{
-						Name:        name,
-						Version:     version,
-						Description: desc,
-					})
-
-					if len(packages) >= 20 {
-						break
-					}
-				}
-			}
-		}
-
-		return packages, nil
+// enrichPackageInfoWithAI 使用AI API丰富包信息，一次请求查询前5个包
+// 使用当前选择的提供商解析后的凭据，没有可用凭据或处于离线模式时不发送请求
+func (a *App) enrichPackageInfoWithAI(packages []PackageInfo, packageManager, packageName string) {
+	config, err := a.loadAIConfig()
+	if err != nil || !config.PackageEnrichment {
+		return
 	}
-	defer resp.Body.Close()
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
+	// 只处理前5个包，避免回复过长
+	if len(packages) > 5 {
+		packages = packages[:5]
 	}
 
-	info, ok := result["info"].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("无效的PyPI响应格式")
+	names := make([]string, len(packages))
+	for i, pkg := range packages {
+		names[i] = pkg.Name
 	}
+	query := fmt.Sprintf("请分别提供%s包管理器中以下包的最新下载链接和安装命令：%s。只返回一个JSON对象，键为包名，值为包含downloadUrl和installCommand字段的对象", packageManager, strings.Join(names, ", "))
 
-	name, _ := info["name"].(string)
-	version, _ := info["version"].(string)
-	summary, _ := info["summary"].(string)
-
-	return []PackageInfo{
-		{
-			Name:        name,
-			Version:     version,
-			Description: summary,
-		},
-	}, nil
-}
-
-// searchGemPackage 搜索Ruby gem包
-func (a *App) searchGemPackage(packageName string) ([]PackageInfo, error) {
-	output, err := executeCommandWithTimeout("gem", "search", "-r", packageName)
-	if err != nil {
-		return nil, err
+	provider, chatReq, errResp := a.prepareAIRequest(config.SelectedProviderID, config.APIKey, config.CustomEndpoint, []ChatMessage{
+		{Role: "user", Content: query},
+	})
+	if errResp != nil {
+		return
 	}
-
-	lines := strings.Split(output, "\n")
-	packages := []PackageInfo{}
-
-	for _, line := range lines {
-		if strings.Contains(line, packageName) {
-			parts := strings.SplitN(line, " ", 2)
-			if len(parts) == 2 {
-				name := strings.TrimSpace(parts[0])
-				versionDesc := strings.TrimSpace(parts[1])
-
-				version := ""
-				description := ""
-
-				// 提取版本号
-				versionMatch := regexp.MustCompile(`\((.*?)\)`).FindStringSubmatch(versionDesc)
-				if len(versionMatch) > 1 {
-					version = versionMatch[1]
-				}
-
-				// 提取描述
-				descMatch := regexp.MustCompile(`\) (.*)`).FindStringSubmatch(versionDesc)
-				if len(descMatch) > 1 {
-					description = descMatch[1]
-				}
-
-				packages = append(packages, PackageInfo{
-					Name:        name,
-					Version:     version,
-					Description: description,
-				})
-
-				if len(packages) >= 20 {
-					break
-				}
-			}
-		}
+	if !provider.Info().Local && chatReq.APIKey == "" && chatReq.AccessToken == "" {
+		return
 	}
 
-	return packages, nil
-}
+	ctx, cancel := context.WithTimeout(context.Background(), packageEnrichmentTimeout)
+	defer cancel()
 
-// searchCargoPackage 搜索Rust cargo包
-func (a *App) searchCargoPackage(packageName string) ([]PackageInfo, error) {
-	output, err := executeCommandWithTimeout("cargo", "search", packageName, "--limit", "20")
+	respBody, err := a.doAIRequest(ctx, provider, chatReq, packageEnrichmentTimeout)
 	if err != nil {
-		return nil, err
+		fmt.Printf("AI补充包信息失败: %v\n", err)
+		return
 	}
-
-	lines := strings.Split(output, "\n")
-	packages := []PackageInfo{}
-
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
-			continue
-		}
-
-		// 匹配 "name = "0.1.0" # 描述" 格式
-		parts := strings.SplitN(line, " = ", 2)
-		if len(parts) == 2 {
-			name := strings.TrimSpace(parts[0])
-
-			// 提取版本和描述
-			versionDesc := strings.TrimSpace(parts[1])
-			version := ""
-			description := ""
-
-			// 提取版本
-			versionMatch := regexp.MustCompile(`"([^"]+)"`).FindStringSubmatch(versionDesc)
-			if len(versionMatch) > 1 {
-				version = versionMatch[1]
-			}
-
-			// 提取描述
-			descMatch := regexp.MustCompile(`#\s*(.*)`).FindStringSubmatch(versionDesc)
-			if len(descMatch) > 1 {
-				description = descMatch[1]
-			}
-
-			packages = append(packages, PackageInfo{
-				Name:        name,
-				Version:     version,
-				Description: description,
-			})
-		}
-	}
-
-	return packages, nil
-}
-
-// searchComposerPackage 搜索PHP Composer包
-func (a *App) searchComposerPackage(packageName string) ([]PackageInfo, error) {
-	output, err := executeCommandWithTimeout("composer", "search", packageName)
+	content, err := provider.ParseResponse(respBody)
 	if err != nil {
-		return nil, err
+		fmt.Printf("AI补充包信息失败: %v\n", err)
+		return
 	}
 
-	lines := strings.Split(output, "\n")
-	packages := []PackageInfo{}
-
-	for _, line := range lines {
-		if strings.Contains(line, packageName) {
-			parts := strings.SplitN(line, " ", 2)
-			if len(parts) == 2 {
-				name := strings.TrimSpace(parts[0])
-				description := strings.TrimSpace(parts[1])
-
-				packages = append(packages, PackageInfo{
-					Name:        name,
-					Description: description,
-				})
-
-				if len(packages) >= 20 {
-					break
-				}
-			}
-		}
+	result := extractJSONObject(content)
+	if result == nil {
+		return
 	}
 
-	return packages, nil
-}
-
-// searchNuGetPackage 搜索.NET NuGet包
-func (a *App) searchNuGetPackage(packageName string) ([]PackageInfo, error) {
-	output, err := executeCommandWithTimeout("dotnet", "nuget", "search", packageName)
-	if err != nil {
-		return nil, err
-	}
-
-	lines := strings.Split(output, "\n")
-	packages := []PackageInfo{}
-	inResultSection := false
-
-	for _, line := range lines {
-		// 跳过标题行
-		if strings.Contains(line, "------") {
-			inResultSection = true
+	enriched := []PackageInfo{}
+	for _, pkg := range packages {
+		suggestion, _ := result[pkg.Name].(map[string]interface{})
+		if suggestion == nil {
 			continue
 		}
 
-		if inResultSection && strings.TrimSpace(line) != "" {
-			fields := strings.Fields(line)
-			if len(fields) >= 3 {
-				name := fields[0]
-				version := fields[1]
-				description := strings.Join(fields[2:], " ")
-
-				packages = append(packages, PackageInfo{
-					Name:        name,
-					Version:     version,
-					Description: description,
-				})
-
-				if len(packages) >= 20 {
-					break
-				}
-			}
-		}
-	}
-
-	return packages, nil
-}
-
-// searchMavenPackage 搜索Java Maven包
-func (a *App) searchMavenPackage(packageName string) ([]PackageInfo, error) {
-	// 使用Maven Central API
-	url := fmt.Sprintf("https://search.maven.org/solrsearch/select?q=%s&rows=20&wt=json", packageName)
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
-	}
-
-	response, ok := result["response"].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("无效的Maven响应格式")
-	}
-
-	docs, ok := response["docs"].([]interface{})
-	if !ok {
-		return nil, fmt.Errorf("无效的Maven响应文档格式")
-	}
+		downloadURL, _ := suggestion["downloadUrl"].(string)
+		installCommand, _ := suggestion["installCommand"].(string)
 
-	packages := []PackageInfo{}
-	for _, doc := range docs {
-		docMap, ok := doc.(map[string]interface{})
-		if !ok {
-			continue
+		changed := false
+		if downloadURL != "" && downloadURL != "unknown" && downloadURL != pkg.DownloadURL {
+			pkg.AISuggestedDownloadURL = downloadURL
+			changed = true
 		}
-
-		groupId, _ := docMap["g"].(string)
-		artifactId, _ := docMap["a"].(string)
-		version, _ := docMap["latestVersion"].(string)
-
-		name := groupId + ":" + artifactId
-
-		packages = append(packages, PackageInfo{
-			Name:        name,
-			Version:     version,
-			Description: "",
-		})
-	}
-
-	return packages, nil
-}
-
-// searchGoPackage 搜索Go包
-func (a *App) searchGoPackage(packageName string) ([]PackageInfo, error) {
-	// 使用pkg.go.dev API
-	url := fmt.Sprintf("https://pkg.go.dev/search?q=%s", packageName)
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	// 简单解析HTML响应
-	content := string(body)
-
-	// 使用正则表达式提取包信息
-	packagePattern := regexp.MustCompile(`<a class="[^"]*" href="/([^"]+)"[^>]*>([^<]+)</a>`)
-	matches := packagePattern.FindAllStringSubmatch(content, -1)
-
-	packages := []PackageInfo{}
-	for _, match := range matches {
-		if len(match) >= 3 {
-			path := match[1]
-			name := match[2]
-
-			packages = append(packages, PackageInfo{
-				Name:        name,
-				Version:     "",
-				Description: path,
-			})
-
-			if len(packages) >= 20 {
-				break
-			}
+		if installCommand != "" && installCommand != "unknown" && installCommand != pkg.InstallLink {
+			pkg.AISuggestedInstallLink = installCommand
+			changed = true
 		}
-	}
-
-	return packages, nil
-}
-
-// searchDubPackage 搜索D语言的Dub包
-func (a *App) searchDubPackage(packageName string) ([]PackageInfo, error) {
-	output, err := executeCommandWithTimeout("dub", "search", packageName)
-	if err != nil {
-		return nil, err
-	}
-
-	lines := strings.Split(output, "\n")
-	packages := []PackageInfo{}
-
-	for _, line := range lines {
-		if strings.Contains(line, packageName) {
-			parts := strings.SplitN(line, " - ", 2)
-			if len(parts) == 2 {
-				nameVer := strings.TrimSpace(parts[0])
-				desc := strings.TrimSpace(parts[1])
-
-				nameParts := strings.Split(nameVer, " ")
-				name := nameParts[0]
-				version := ""
-				if len(nameParts) > 1 {
-					version = strings.Trim(nameParts[1], "()")
-				}
-
-				packages = append(packages, PackageInfo{
-					Name:        name,
-					Version:     version,
-					Description: desc,
-				})
-
-				if len(packages) >= 20 {
-					break
-				}
-			}
+		if changed {
+			enriched = append(enriched, pkg)
 		}
 	}
 
-	return packages, nil
-}
-
-// searchHexPackage 搜索Elixir的Hex包
-func (a *App) searchHexPackage(packageName string) ([]PackageInfo, error) {
-	output, err := executeCommandWithTimeout("mix", "hex.search", packageName)
-	if err != nil {
-		return nil, err
+	if len(enriched) == 0 || a.ctx == nil {
+		return
 	}
-
-	lines := strings.Split(output, "\n")
-	packages := []PackageInfo{}
-
-	for _, line := range lines {
-		if strings.Contains(line, packageName) {
-			parts := strings.SplitN(line, " - ", 2)
-			if len(parts) == 2 {
-				name := strings.TrimSpace(parts[0])
-				desc := strings.TrimSpace(parts[1])
-
-				packages = append(packages, PackageInfo{
-					Name:        name,
-					Description: desc,
-				})
-
-				if len(packages) >= 20 {
-					break
-				}
-			}
-		}
-	}
-
-	return packages, nil
+	wailsruntime.EventsEmit(a.ctx, packageEnrichmentEventName, PackageEnrichmentEvent{
+		Manager:  packageManager,
+		Query:    packageName,
+		Packages: enriched,
+	})
 }
 
-// searchNimblePackage 搜索Nim的Nimble包
-func (a *App) searchNimblePackage(packageName string) ([]PackageInfo, error) {
-	output, err := executeCommandWithTimeout("nimble", "search", packageName)
-	if err != nil {
-		return nil, err
-	}
-
-	lines := strings.Split(output, "\n")
-	packages := []PackageInfo{}
-
-	for _, line := range lines {
-		if strings.Contains(line, packageName) {
-			parts := strings.SplitN(line, " - ", 2)
-			if len(parts) == 2 {
-				nameVer := strings.TrimSpace(parts[0])
-				desc := strings.TrimSpace(parts[1])
-
-				nameParts := strings.Split(nameVer, " ")
-				name := nameParts[0]
-				version := ""
-				if len(nameParts) > 1 {
-					version = strings.Join(nameParts[1:], " ")
-					version = strings.Trim(version, "()")
-				}
-
-				packages = append(packages, PackageInfo{
-					Name:        name,
-					Version:     version,
-					Description: desc,
-				})
-
-				if len(packages) >= 20 {
-					break
-				}
-			}
+// extractJSONObject 从AI回复内容中提取JSON对象
+func extractJSONObject(content string) map[string]interface{} {
+	jsonStart := strings.Index(content, "{")
+	jsonEnd := strings.LastIndex(content, "}")
+	if jsonStart >= 0 && jsonEnd > jsonStart {
+		jsonStr := content[jsonStart : jsonEnd+1]
+		var result map[string]interface{}
+		if err := json.Unmarshal([]byte(jsonStr), &result); err == nil {
+			return result
 		}
 	}
 
-	return packages, nil
+	return nil
 }
 
 // searchBrewPackage 搜索Homebrew包
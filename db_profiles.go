@@ -0,0 +1,293 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DatabaseTestResult 存储数据库连接测试结果
+type DatabaseTestResult struct {
+	Profile       string        `json:"profile"`
+	Engine        string        `json:"engine"`
+	Connected     bool          `json:"connected"`
+	ServerVersion string        `json:"serverVersion"`
+	LatencyMs     int64         `json:"latencyMs"`
+	TLS           string        `json:"tls"`
+	Extensions    []PackageInfo `json:"extensions"`
+	Databases     []string      `json:"databases"`
+	Error         string        `json:"error"`
+}
+
+// getDatabaseProfilesPath 获取数据库连接配置文件路径
+func (a *App) getDatabaseProfilesPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "db_profiles.json"
+	}
+	configDir := filepath.Join(homeDir, ".networ_tester")
+
+	// 确保目录存在
+	if _, err := os.Stat(configDir); os.IsNotExist(err) {
+		os.MkdirAll(configDir, 0755)
+	}
+
+	return filepath.Join(configDir, "db_profiles.json")
+}
+
+// storedDatabaseProfile 写入磁盘的连接配置，密码只以密文形式保存
+type storedDatabaseProfile struct {
+	SQLConnectionProfile
+	EncryptedPassword string `json:"encryptedPassword,omitempty"`
+}
+
+// readStoredDatabaseProfiles 读取磁盘上的连接配置，不解密密码
+// 旧版本保存的明文密码会在首次读取时自动迁移为密文
+func (a *App) readStoredDatabaseProfiles() []storedDatabaseProfile {
+	data, err := os.ReadFile(a.getDatabaseProfilesPath())
+	if err != nil {
+		return []storedDatabaseProfile{}
+	}
+
+	var profiles []storedDatabaseProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return []storedDatabaseProfile{}
+	}
+
+	for _, profile := range profiles {
+		if profile.Password != "" {
+			if err := a.writeDatabaseProfiles(profiles); err != nil {
+				fmt.Printf("迁移明文数据库密码失败: %v\n", err)
+			}
+			break
+		}
+	}
+
+	return profiles
+}
+
+// GetDatabaseProfiles 获取所有已保存的数据库连接配置，不包含密码
+func (a *App) GetDatabaseProfiles() []SQLConnectionProfile {
+	profiles := []SQLConnectionProfile{}
+	for _, stored := range a.readStoredDatabaseProfiles() {
+		profile := stored.SQLConnectionProfile
+		profile.HasPassword = stored.EncryptedPassword != "" || profile.Password != ""
+		profile.Password = ""
+		profiles = append(profiles, profile)
+	}
+	return profiles
+}
+
+// SaveDatabaseProfile 保存数据库连接配置，同名配置会被覆盖
+// 密码为空时根据 HasPassword 保留或清除已保存的密码
+func (a *App) SaveDatabaseProfile(profile SQLConnectionProfile) error {
+	profile.Name = strings.TrimSpace(profile.Name)
+	if profile.Name == "" {
+		return fmt.Errorf("连接配置名称不能为空")
+	}
+
+	if versionQuery, _ := sqlIntrospectionQueries(profile.Engine); versionQuery == "" {
+		return fmt.Errorf("不支持的数据库类型: %s", profile.Engine)
+	}
+
+	if profile.Engine == "sqlite" && profile.FilePath == "" {
+		return fmt.Errorf("SQLite连接配置缺少数据库文件路径")
+	}
+
+	profiles := a.readStoredDatabaseProfiles()
+	stored := storedDatabaseProfile{SQLConnectionProfile: profile}
+	replaced := false
+	for i := range profiles {
+		if profiles[i].Name == profile.Name {
+			if profile.Password == "" && profile.HasPassword {
+				stored.EncryptedPassword = profiles[i].EncryptedPassword
+			}
+			profiles[i] = stored
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		profiles = append(profiles, stored)
+	}
+
+	return a.writeDatabaseProfiles(profiles)
+}
+
+// DeleteDatabaseProfile 删除指定名称的数据库连接配置
+func (a *App) DeleteDatabaseProfile(name string) error {
+	profiles := a.readStoredDatabaseProfiles()
+	remaining := []storedDatabaseProfile{}
+	for _, profile := range profiles {
+		if profile.Name != name {
+			remaining = append(remaining, profile)
+		}
+	}
+
+	if len(remaining) == len(profiles) {
+		return fmt.Errorf("未找到连接配置: %s", name)
+	}
+
+	return a.writeDatabaseProfiles(remaining)
+}
+
+// writeDatabaseProfiles 使用本机密钥加密明文密码并写入连接配置文件，文件仅当前用户可读写
+func (a *App) writeDatabaseProfiles(profiles []storedDatabaseProfile) error {
+	profiles = append([]storedDatabaseProfile{}, profiles...)
+	for i := range profiles {
+		if profiles[i].Password != "" {
+			key, err := a.deriveEncryptionKey(keySourceMachine, nil)
+			if err != nil {
+				return err
+			}
+			encrypted, err := encryptSecret(key, profiles[i].Password)
+			if err != nil {
+				return err
+			}
+			profiles[i].EncryptedPassword = encrypted
+		}
+		// 明文密码从不写入磁盘
+		profiles[i].Password = ""
+		profiles[i].HasPassword = profiles[i].EncryptedPassword != ""
+	}
+
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := a.getDatabaseProfilesPath()
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+
+	// 旧版本创建的文件不会被WriteFile修改权限
+	return os.Chmod(path, 0600)
+}
+
+// findDatabaseProfile 按名称查找数据库连接配置并解密密码，仅供后端内部使用
+func (a *App) findDatabaseProfile(name string) (SQLConnectionProfile, error) {
+	if strings.TrimSpace(name) == "" {
+		return SQLConnectionProfile{}, fmt.Errorf("必须指定连接配置名称")
+	}
+
+	for _, stored := range a.readStoredDatabaseProfiles() {
+		if stored.Name != name {
+			continue
+		}
+
+		profile := stored.SQLConnectionProfile
+		if stored.EncryptedPassword != "" {
+			key, err := a.deriveEncryptionKey(keySourceMachine, nil)
+			if err != nil {
+				return SQLConnectionProfile{}, err
+			}
+			password, err := decryptSecret(key, stored.EncryptedPassword)
+			if err != nil {
+				return SQLConnectionProfile{}, fmt.Errorf("解密连接配置 %s 的密码失败: %v", name, err)
+			}
+			profile.Password = password
+		}
+		profile.HasPassword = profile.Password != ""
+		return profile, nil
+	}
+
+	return SQLConnectionProfile{}, fmt.Errorf("未找到连接配置: %s", name)
+}
+
+// TestDatabaseConnection 测试具名连接配置的连通性
+// 报告服务器版本、延迟、TLS状态、可用扩展/插件和数据库列表
+func (a *App) TestDatabaseConnection(name string) DatabaseTestResult {
+	result := DatabaseTestResult{
+		Profile: name,
+	}
+
+	profile, err := a.findDatabaseProfile(name)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Engine = profile.Engine
+
+	// 延迟包含启动客户端进程的时间
+	start := time.Now()
+	if _, err := runReadOnlySQLQuery(profile, "SELECT 1"); err != nil {
+		result.Error = "连接失败: " + err.Error()
+		return result
+	}
+	result.LatencyMs = time.Since(start).Milliseconds()
+	result.Connected = true
+
+	introspection := a.IntrospectSQLServer(name)
+	result.ServerVersion = introspection.ServerVersion
+	result.Extensions = introspection.Extensions
+	if !introspection.Success {
+		result.Error = introspection.Error
+	}
+
+	result.TLS = a.detectDatabaseTLS(profile)
+
+	if query := sqlListDatabasesQuery(profile.Engine); query != "" {
+		rows, err := runReadOnlySQLQuery(profile, query)
+		if err != nil {
+			if result.Error == "" {
+				result.Error = "获取数据库列表失败: " + err.Error()
+			}
+		} else {
+			for _, row := range rows {
+				result.Databases = append(result.Databases, row[0])
+			}
+		}
+	}
+
+	return result
+}
+
+// detectDatabaseTLS 查询当前连接是否使用TLS
+func (a *App) detectDatabaseTLS(profile SQLConnectionProfile) string {
+	var query string
+	switch profile.Engine {
+	case "sqlite":
+		return "不适用（本地文件）"
+	case "postgres":
+		query = "SELECT coalesce((SELECT CASE WHEN ssl THEN 'TLS ' || version ELSE 'off' END FROM pg_stat_ssl WHERE pid = pg_backend_pid()), 'off')"
+	case "mysql":
+		query = "SELECT VARIABLE_VALUE FROM performance_schema.session_status WHERE VARIABLE_NAME = 'Ssl_version'"
+	case "sqlserver":
+		query = "SET NOCOUNT ON; SELECT encrypt_option FROM sys.dm_exec_connections WHERE session_id = @@SPID"
+	default:
+		return "未知"
+	}
+
+	rows, err := runReadOnlySQLQuery(profile, query)
+	if err != nil || len(rows) == 0 || len(rows[0]) == 0 {
+		return "未知"
+	}
+
+	value := strings.TrimSpace(rows[0][0])
+	switch strings.ToUpper(value) {
+	case "", "OFF", "FALSE":
+		return "未加密"
+	case "TRUE":
+		return "已加密"
+	}
+	return value
+}
+
+// sqlListDatabasesQuery 返回列出数据库的只读查询
+func sqlListDatabasesQuery(engine string) string {
+	switch engine {
+	case "postgres":
+		return "SELECT datname FROM pg_database WHERE NOT datistemplate ORDER BY datname"
+	case "mysql":
+		return "SHOW DATABASES"
+	case "sqlite":
+		return "SELECT name FROM pragma_database_list"
+	case "sqlserver":
+		return "SET NOCOUNT ON; SELECT name FROM sys.databases ORDER BY name"
+	}
+	return ""
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useTempHome 让配置文件写入临时目录
+func useTempHome(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+}
+
+func TestTestDatabaseConnectionSQLite(t *testing.T) {
+	if !commandExists("sqlite3") {
+		t.Skip("未找到sqlite3")
+	}
+	useTempHome(t)
+
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	if output, err := executeCommandWithTimeout("sqlite3", dbPath, "CREATE TABLE items (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("创建SQLite数据库失败: %v %s", err, output)
+	}
+
+	app := NewApp()
+	if err := app.SaveDatabaseProfile(SQLConnectionProfile{Name: "local", Engine: "sqlite", FilePath: dbPath}); err != nil {
+		t.Fatalf("保存连接配置失败: %v", err)
+	}
+
+	result := app.TestDatabaseConnection("local")
+	if !result.Connected {
+		t.Fatalf("连接失败: %s", result.Error)
+	}
+	if result.Error != "" {
+		t.Errorf("不应有错误: %s", result.Error)
+	}
+	if result.Engine != "sqlite" || result.ServerVersion == "" {
+		t.Errorf("引擎或版本不正确: %q %q", result.Engine, result.ServerVersion)
+	}
+	if result.TLS != "不适用（本地文件）" {
+		t.Errorf("TLS状态不正确: %q", result.TLS)
+	}
+	if len(result.Databases) == 0 || result.Databases[0] != "main" {
+		t.Errorf("数据库列表不正确: %v", result.Databases)
+	}
+
+	missing := app.TestDatabaseConnection("missing")
+	if missing.Connected || missing.Error == "" {
+		t.Errorf("不存在的配置应返回错误: %+v", missing)
+	}
+}
+
+func TestDatabaseProfilePasswordEncrypted(t *testing.T) {
+	useTempHome(t)
+	app := NewApp()
+
+	profile := SQLConnectionProfile{Name: "pg", Engine: "postgres", Host: "localhost", User: "app", Password: "s3cret-pass"}
+	if err := app.SaveDatabaseProfile(profile); err != nil {
+		t.Fatalf("保存连接配置失败: %v", err)
+	}
+
+	data, err := os.ReadFile(app.getDatabaseProfilesPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "s3cret-pass") {
+		t.Fatalf("配置文件中包含明文密码: %s", data)
+	}
+
+	profiles := app.GetDatabaseProfiles()
+	if len(profiles) != 1 || profiles[0].Password != "" || !profiles[0].HasPassword {
+		t.Fatalf("返回给前端的配置不应包含密码: %+v", profiles)
+	}
+
+	// 不修改密码时保留原密码
+	profiles[0].Port = 5433
+	if err := app.SaveDatabaseProfile(profiles[0]); err != nil {
+		t.Fatal(err)
+	}
+	found, err := app.findDatabaseProfile("pg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Password != "s3cret-pass" || found.Port != 5433 {
+		t.Errorf("密码未保留或配置未更新: %+v", found)
+	}
+
+	// HasPassword 为 false 时清除密码
+	found.Password = ""
+	found.HasPassword = false
+	if err := app.SaveDatabaseProfile(found); err != nil {
+		t.Fatal(err)
+	}
+	if profiles := app.GetDatabaseProfiles(); profiles[0].HasPassword {
+		t.Errorf("密码应已清除: %+v", profiles[0])
+	}
+}
+
+func TestDatabaseProfileMigratesPlaintextPassword(t *testing.T) {
+	useTempHome(t)
+	app := NewApp()
+
+	legacy := `[{"name":"old","engine":"mysql","host":"db","port":3306,"user":"root","password":"legacy-pass","database":"","filePath":""}]`
+	if err := os.WriteFile(app.getDatabaseProfilesPath(), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := app.findDatabaseProfile("old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Password != "legacy-pass" {
+		t.Errorf("迁移后密码不正确: %q", found.Password)
+	}
+
+	data, err := os.ReadFile(app.getDatabaseProfilesPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "legacy-pass") {
+		t.Errorf("明文密码未迁移: %s", data)
+	}
+}
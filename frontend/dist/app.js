@@ -359,6 +359,21 @@ window.onload = function() {
     // 设置搜索按钮事件
     document.getElementById('search-btn').addEventListener('click', searchPackages);
     
+    // 跨注册表搜索时逐个接收各注册表的结果
+    window.runtime.EventsOn('package:search', onPackageSearchEvent);
+    
+    // AI补充的包信息在搜索结果显示后推送
+    window.runtime.EventsOn('package:enriched', onPackageEnrichedEvent);
+    
+    // 注册表地址设置
+    initRegistrySettingsPanel();
+    initNetworkSettingsPanel();
+    initSQLIntrospectionPanel();
+    initDatabaseProfilesPanel();
+    initOutdatedReport();
+    initPackageJobs();
+    initVulnerabilityAudit();
+    
     // 设置语言搜索事件
     document.getElementById('language-search').addEventListener('input', searchLanguages);
 
@@ -1698,6 +1713,13 @@ function scanLanguages() {
         // 停止进度模拟
         clearInterval(progressInterval);
         
+        // 保存检测结果，包操作任务完成后替换其中的单个语言
+        lastScannedLanguages = languages;
+        
+        // 更新提示词模板的语言筛选
+        detectedLanguageNames = languages.filter(lang => lang.installed).map(lang => lang.name).sort();
+        refreshPromptLanguageFilter();
+        
         // 设置进度为100%
         updateProgressUI(100, '扫描完成！');
         
@@ -1893,6 +1915,8 @@ async function showLanguageDetails(language, isInstalled) {
                             <div class="package-item">
                                 <span class="package-name">${pkg.name}</span>
                                 <span class="package-version">${pkg.version || ''}</span>
+                                <button class="secondary-btn" data-package-action="upgrade" data-package-name="${encodeURIComponent(pkg.name)}">升级</button>
+                                <button class="secondary-btn" data-package-action="uninstall" data-package-name="${encodeURIComponent(pkg.name)}">卸载</button>
                             </div>
                         `).join('')}
                     </div>
@@ -1914,6 +1938,7 @@ async function showLanguageDetails(language, isInstalled) {
                                     <span class="package-version">${pkg.version || ''}</span>
                                 </div>
                                 <div class="package-description">${pkg.description || ''}</div>
+                                <button class="secondary-btn" data-package-action="install" data-package-name="${encodeURIComponent(pkg.name)}">安装</button>
                             </div>
                         `).join('')}
                     </div>
@@ -1980,6 +2005,14 @@ async function showLanguageDetails(language, isInstalled) {
     
     modalBody.innerHTML = content;
     modal.style.display = 'block';
+    modal.dataset.language = language.name;
+    
+    // 已安装包的升级、卸载和推荐包的安装
+    modalBody.querySelectorAll('[data-package-action]').forEach(button => {
+        button.addEventListener('click', () => {
+            runPackageAction('', decodeURIComponent(button.dataset.packageName), button.dataset.packageAction, language.name);
+        });
+    });
 }
 
 // 获取缺少的包
@@ -1993,235 +2026,1313 @@ async function getMissingPackages(languageName) {
     }
 }
 
-// 搜索包
-async function searchPackages() {
-    const packageManager = document.getElementById('package-manager').value;
-    const packageName = document.getElementById('package-name').value.trim();
-    const searchResults = document.getElementById('search-results');
-    
-    if (!packageName) {
-        searchResults.innerHTML = `<p class="no-results">${getText('no_input')}</p>`;
+// 生成单个包的搜索结果HTML
+function renderPackageResult(pkg) {
+    const manager = pkg.manager || document.getElementById('package-manager').value;
+    const quotedName = pkg.name.replace(/\\/g, '\\\\').replace(/'/g, "\\'");
+    return `
+        <div class="result-item">
+            <div class="result-header">
+                <span>
+                    <span class="result-name">${pkg.name}</span>
+                    ${pkg.manager ? `<span class="result-manager">${pkg.manager}</span>` : ''}
+                </span>
+                ${pkg.version ? `<span class="result-version">${pkg.version}</span>` : ''}
+            </div>
+            ${pkg.description ? `<p class="result-description">${pkg.description}</p>` : ''}
+            <div class="result-actions">
+                ${pkg.installLink ? `
+                    <div class="install-command">
+                        <span class="command-label">${getText('install_command')}</span>
+                        <code>${pkg.installLink}</code>
+                        <button class="copy-btn" onclick="navigator.clipboard.writeText('${pkg.installLink.replace(/'/g, "\\'")}')">
+                            ${getText('copy_btn')}
+                        </button>
+                    </div>
+                ` : ''}
+                ${pkg.downloadUrl ? `
+                    <a href="${pkg.downloadUrl}" class="download-link" target="_blank">
+                        ${getText('view_details')}
+                    </a>
+                ` : ''}
+                ${manager && manager !== 'all' ? `
+                    <button class="secondary-btn" onclick="showPackageDetails('${manager}', '${quotedName}')">版本与依赖</button>
+                    <button class="secondary-btn" onclick="runPackageAction('${manager}', '${quotedName}', 'install')">安装</button>
+                ` : ''}
+                ${pkg.aiSuggestedInstallLink ? `
+                    <div class="install-command ai-suggested">
+                        <span class="command-label">AI建议:</span>
+                        <code>${pkg.aiSuggestedInstallLink}</code>
+                    </div>
+                ` : ''}
+                ${pkg.aiSuggestedDownloadUrl ? `
+                    <a href="${pkg.aiSuggestedDownloadUrl}" class="download-link ai-suggested" target="_blank">
+                        AI建议链接
+                    </a>
+                ` : ''}
+            </div>
+        </div>
+    `;
+}
+
+// 显示包的详细信息，refresh 为true时忽略本地缓存
+async function showPackageDetails(manager, name, refresh) {
+    const panel = document.getElementById('package-details');
+    panel.style.display = 'block';
+    panel.innerHTML = '';
+    panel.textContent = `正在获取 ${name} 的详细信息...`;
+    
+    let details;
+    try {
+        details = refresh
+            ? await window.go.main.App.RefreshPackageDetails(manager, name)
+            : await window.go.main.App.GetPackageDetails(manager, name);
+    } catch (error) {
+        panel.textContent = '获取包详情失败: ' + (error.message || error);
         return;
     }
     
-    // 显示加载中
-    searchResults.innerHTML = `
-        <div class="loading">
-            <div class="spinner"></div>
-            <p>${getText('searching')} ${packageName}...</p>
-        </div>
-    `;
+    panel.innerHTML = '';
+    const header = document.createElement('div');
+    header.className = 'result-header';
+    const title = document.createElement('span');
+    title.className = 'result-name';
+    title.textContent = `${details.name} (${details.manager})`;
+    const actions = document.createElement('span');
+    const refreshBtn = document.createElement('button');
+    refreshBtn.className = 'secondary-btn';
+    refreshBtn.textContent = '刷新';
+    refreshBtn.addEventListener('click', () => showPackageDetails(manager, name, true));
+    const closeBtn = document.createElement('button');
+    closeBtn.className = 'secondary-btn';
+    closeBtn.textContent = '关闭';
+    closeBtn.addEventListener('click', () => {
+        panel.style.display = 'none';
+    });
+    actions.append(refreshBtn, closeBtn);
+    header.append(title, actions);
+    panel.appendChild(header);
     
-    try {
-        const results = await window.go.main.App.SearchPackage(packageManager, packageName);
-        
-        if (results.length === 0) {
-            searchResults.innerHTML = `<p class="no-results">${getText('no_results')} "${packageName}"</p>`;
+    const addLine = (label, value, link) => {
+        if (!value) {
             return;
         }
-        
-        // 渲染搜索结果
-        let html = '';
-        results.forEach(pkg => {
-            html += `
-                <div class="result-item">
-                    <div class="result-header">
-                        <span class="result-name">${pkg.name}</span>
-                        ${pkg.version ? `<span class="result-version">${pkg.version}</span>` : ''}
-                    </div>
-                    ${pkg.description ? `<p class="result-description">${pkg.description}</p>` : ''}
-                    <div class="result-actions">
-                        ${pkg.installLink ? `
-                            <div class="install-command">
-                                <span class="command-label">${getText('install_command')}</span>
-                                <code>${pkg.installLink}</code>
-                                <button class="copy-btn" onclick="navigator.clipboard.writeText('${pkg.installLink.replace(/'/g, "\\'")}')">
-                                    ${getText('copy_btn')}
-                                </button>
-                            </div>
-                        ` : ''}
-                        ${pkg.downloadURL ? `
-                            <a href="${pkg.downloadURL}" class="download-link" target="_blank">
-                                ${getText('view_details')}
-                            </a>
-                        ` : ''}
-                    </div>
-                </div>
-            `;
-        });
-        
-        searchResults.innerHTML = html;
-    } catch (error) {
-        console.error('搜索包时出错:', error);
-        searchResults.innerHTML = `<p class="no-results">${getText('searching')} ${error.message || '未知错误'}</p>`;
+        const line = document.createElement('div');
+        line.className = 'package-details-line';
+        const strong = document.createElement('strong');
+        strong.textContent = label + ': ';
+        line.appendChild(strong);
+        if (link) {
+            const a = document.createElement('a');
+            a.href = value;
+            a.target = '_blank';
+            a.textContent = value;
+            line.appendChild(a);
+        } else {
+            line.append(String(value));
+        }
+        panel.appendChild(line);
+    };
+    
+    if (details.stale) {
+        addLine('提示', '注册表请求失败，显示的是过期的缓存: ' + details.warning);
+    } else if (details.warning) {
+        addLine('提示', details.warning);
     }
+    if (details.deprecated) {
+        addLine('已弃用', details.deprecationMessage || '是');
+    }
+    addLine('描述', details.description);
+    addLine('最新版本', details.latestVersion);
+    addLine('许可证', details.license);
+    addLine('作者', details.author);
+    addLine('主页', details.homepage, true);
+    addLine('仓库', details.repository, true);
+    addLine('周下载量', details.weeklyDownloads ? details.weeklyDownloads.toLocaleString() : '');
+    addLine('总下载量', details.totalDownloads ? details.totalDownloads.toLocaleString() : '');
+    addLine('运行时', (details.runtimes || []).join('，'));
+    addLine('数据时间', new Date(details.fetchedAt).toLocaleString() + (details.fromCache ? '（缓存）' : ''));
+    
+    const dependencies = details.dependencies || [];
+    const depsTitle = document.createElement('h4');
+    depsTitle.textContent = `依赖 (${dependencies.length})`;
+    panel.appendChild(depsTitle);
+    const depsList = document.createElement('ul');
+    depsList.className = 'package-details-list';
+    dependencies.forEach(dep => {
+        const item = document.createElement('li');
+        item.textContent = `${dep.name} ${dep.requirement || ''} [${dep.kind}]${dep.target ? ' ' + dep.target : ''}`;
+        depsList.appendChild(item);
+    });
+    panel.appendChild(depsList);
+    
+    const versions = details.versions || [];
+    const versionsTitle = document.createElement('h4');
+    versionsTitle.textContent = `版本 (${versions.length})`;
+    panel.appendChild(versionsTitle);
+    const versionsList = document.createElement('ul');
+    versionsList.className = 'package-details-list';
+    versions.forEach(version => {
+        const item = document.createElement('li');
+        const flags = [];
+        if (version.prerelease) flags.push('预发布');
+        if (version.yanked) flags.push('已撤回');
+        if (version.deprecated) flags.push('已弃用');
+        let text = version.version;
+        if (version.releasedAt) text += '  ' + new Date(version.releasedAt).toLocaleDateString();
+        if (flags.length > 0) text += '  [' + flags.join('、') + ']';
+        if (version.note) text += '  ' + version.note;
+        item.textContent = text;
+        if (version.yanked || version.deprecated) {
+            item.classList.add('package-version-yanked');
+        }
+        versionsList.appendChild(item);
+    });
+    panel.appendChild(versionsList);
 }
 
-// 搜索语言
-function searchLanguages(event) {
-    const searchTerm = document.getElementById('language-search').value.toLowerCase();
-    const activeTab = document.querySelector('.tab-btn.active').getAttribute('data-tab');
-    const languageCards = document.querySelectorAll(`#${activeTab}-languages .language-card`);
+// 最近一次的过期包报告，用于导出
+let lastOutdatedReport = null;
+
+// 更新级别的显示名称
+const outdatedUpdateLabels = {
+    major: '不兼容更新',
+    minor: '次版本更新',
+    patch: '修订更新',
+    unknown: '无法判断'
+};
+
+// 初始化过期包检查
+function initOutdatedReport() {
+    const checkBtn = document.getElementById('check-outdated-btn');
+    if (!checkBtn) {
+        return;
+    }
     
-    languageCards.forEach(card => {
-        const languageName = card.querySelector('h3').textContent.toLowerCase();
-        if (languageName.includes(searchTerm) || searchTerm === '') {
-            card.style.display = 'block';
-        } else {
-            card.style.display = 'none';
-        }
+    checkBtn.addEventListener('click', checkOutdatedPackages);
+    document.getElementById('export-outdated-csv-btn').addEventListener('click', () => exportOutdatedReport('csv'));
+    document.getElementById('export-outdated-json-btn').addEventListener('click', () => exportOutdatedReport('json'));
+    document.getElementById('export-outdated-md-btn').addEventListener('click', () => exportOutdatedReport('markdown'));
+    
+    // 每个包管理器检查完成时显示进度
+    window.runtime.EventsOn('package:outdated', (event) => {
+        const status = event.status;
+        const summary = document.getElementById('outdated-summary');
+        summary.textContent = `${status.manager} 检查完成（${status.method}），${status.outdated} 个过期`;
     });
 }
 
-// 创建简单的Logo
-function createLogo() {
-    const canvas = document.createElement('canvas');
-    canvas.width = 200;
-    canvas.height = 200;
-    const ctx = canvas.getContext('2d');
+// 检查所有包管理器中的过期包
+async function checkOutdatedPackages() {
+    const checkBtn = document.getElementById('check-outdated-btn');
+    const summary = document.getElementById('outdated-summary');
+    const results = document.getElementById('outdated-results');
     
-    // 绘制背景圆
-    ctx.fillStyle = '#4a6bff';
-    ctx.beginPath();
-    ctx.arc(100, 100, 80, 0, Math.PI * 2);
-    ctx.fill();
+    checkBtn.disabled = true;
+    summary.textContent = '正在检查过期包，可能需要几分钟...';
+    results.innerHTML = '';
     
-    // 绘制内部圆
-    ctx.fillStyle = 'white';
-    ctx.beginPath();
-    ctx.arc(100, 100, 60, 0, Math.PI * 2);
-    ctx.fill();
+    try {
+        lastOutdatedReport = await window.go.main.App.CheckOutdatedPackages([]);
+        renderOutdatedReport(lastOutdatedReport);
+    } catch (error) {
+        summary.textContent = '检查过期包失败: ' + (error.message || error);
+    } finally {
+        checkBtn.disabled = false;
+    }
+}
+
+// 显示过期包报告
+function renderOutdatedReport(report) {
+    const summary = document.getElementById('outdated-summary');
+    const results = document.getElementById('outdated-results');
+    const packages = report.packages || [];
+    
+    summary.textContent = `共 ${packages.length} 个过期包：` +
+        `不兼容更新 ${report.summary.major}，次版本更新 ${report.summary.minor}，` +
+        `修订更新 ${report.summary.patch}，无法判断 ${report.summary.unknown}`;
+    
+    ['csv', 'json', 'md'].forEach(format => {
+        document.getElementById(`export-outdated-${format}-btn`).style.display = 'inline-block';
+    });
     
-    // 绘制网络连接线
-    ctx.strokeStyle = '#4a6bff';
-    ctx.lineWidth = 8;
+    results.innerHTML = '';
+    if (packages.length > 0) {
+        const table = document.createElement('table');
+        table.className = 'outdated-table';
+        const head = document.createElement('tr');
+        ['包管理器', '包', '当前版本', '最新版本', '更新级别', ''].forEach(text => {
+            const th = document.createElement('th');
+            th.textContent = text;
+            head.appendChild(th);
+        });
+        table.appendChild(head);
+        
+        packages.forEach(pkg => {
+            const row = document.createElement('tr');
+            [pkg.manager, pkg.name, pkg.current, pkg.latest].forEach(text => {
+                const td = document.createElement('td');
+                td.textContent = text;
+                row.appendChild(td);
+            });
+            const updateCell = document.createElement('td');
+            const badge = document.createElement('span');
+            badge.className = `outdated-badge outdated-${pkg.update}`;
+            badge.textContent = outdatedUpdateLabels[pkg.update] || pkg.update;
+            badge.title = pkg.source === 'cli' ? '来自包管理器命令' : '来自注册表';
+            updateCell.appendChild(badge);
+            row.appendChild(updateCell);
+            const actionCell = document.createElement('td');
+            const upgradeBtn = document.createElement('button');
+            upgradeBtn.className = 'secondary-btn';
+            upgradeBtn.textContent = '升级';
+            upgradeBtn.addEventListener('click', () => runPackageAction(pkg.manager, pkg.name, 'upgrade'));
+            actionCell.appendChild(upgradeBtn);
+            row.appendChild(actionCell);
+            table.appendChild(row);
+        });
+        results.appendChild(table);
+    }
     
-    // 绘制连接线
-    ctx.beginPath();
-    ctx.moveTo(70, 70);
-    ctx.lineTo(130, 130);
-    ctx.stroke();
+    // 检查失败的包管理器单独列出
+    (report.managers || []).filter(status => status.error).forEach(status => {
+        const line = document.createElement('div');
+        line.className = 'package-details-line';
+        line.textContent = `${status.manager}（${status.method}）: ${status.error}`;
+        results.appendChild(line);
+    });
+}
+
+// 导出最近一次的过期包报告
+async function exportOutdatedReport(format) {
+    if (!lastOutdatedReport) {
+        return;
+    }
+    try {
+        const path = await window.go.main.App.ExportOutdatedReport(lastOutdatedReport, format);
+        if (path) {
+            showSystemMessage('过期包报告已导出到 ' + path);
+        }
+    } catch (error) {
+        showSystemMessage('导出过期包报告失败: ' + (error.message || error));
+    }
+}
+
+// 最近一次扫描的语言检测结果
+let lastScannedLanguages = [];
+
+// 任务操作和状态的显示名称
+const packageJobActionLabels = {
+    install: '安装',
+    upgrade: '升级',
+    uninstall: '卸载'
+};
+const packageJobStatusLabels = {
+    queued: '排队中',
+    running: '执行中',
+    succeeded: '成功',
+    failed: '失败',
+    cancelled: '已取消'
+};
+
+// 初始化包操作任务列表
+function initPackageJobs() {
+    const list = document.getElementById('package-jobs-list');
+    if (!list) {
+        return;
+    }
     
-    ctx.beginPath();
-    ctx.moveTo(130, 70);
-    ctx.lineTo(70, 130);
-    ctx.stroke();
+    document.getElementById('clear-package-jobs-btn').addEventListener('click', async () => {
+        await window.go.main.App.ClearFinishedPackageJobs();
+        list.innerHTML = '';
+        const jobs = await window.go.main.App.GetPackageJobs();
+        jobs.forEach(renderPackageJob);
+    });
     
-    // 绘制节点
-    ctx.fillStyle = '#34c759';
-    ctx.beginPath();
-    ctx.arc(70, 70, 12, 0, Math.PI * 2);
-    ctx.fill();
+    window.runtime.EventsOn('package:job', onPackageJobEvent);
     
-    ctx.fillStyle = '#ff3b30';
-    ctx.beginPath();
-    ctx.arc(130, 130, 12, 0, Math.PI * 2);
-    ctx.fill();
+    const journalPanel = document.getElementById('package-journal-panel');
+    document.getElementById('toggle-package-journal-btn').addEventListener('click', () => {
+        const visible = journalPanel.style.display !== 'none';
+        journalPanel.style.display = visible ? 'none' : 'block';
+        if (!visible) {
+            loadPackageJournal();
+        }
+    });
+}
+
+// 安装、升级或卸载包：确认命令后加入任务队列，不支持时复制命令
+// manager 为空时由后端根据 language 确定包管理器
+async function runPackageAction(manager, name, action, language) {
+    const request = {
+        manager: manager || '',
+        language: language || '',
+        name: name,
+        action: action,
+        dryRun: document.getElementById('package-job-dry-run').checked
+    };
+    
+    let command;
+    try {
+        command = await window.go.main.App.PreviewPackageCommand(request);
+    } catch (error) {
+        showSystemMessage(`${packageJobActionLabels[action]} ${name} 失败: ` + (error.message || error));
+        return;
+    }
     
-    ctx.fillStyle = '#ffcc00';
-    ctx.beginPath();
-    ctx.arc(130, 70, 12, 0, Math.PI * 2);
-    ctx.fill();
+    const copyOnly = document.getElementById('package-job-copy-only').checked;
+    if (copyOnly || !command.supported) {
+        if (!command.command) {
+            showSystemMessage(command.reason || '没有可复制的命令');
+            return;
+        }
+        await navigator.clipboard.writeText(command.command);
+        showSystemMessage((command.reason ? command.reason + '，' : '') + '已复制命令: ' + command.command);
+        return;
+    }
     
-    ctx.fillStyle = '#5856d6';
-    ctx.beginPath();
-    ctx.arc(70, 130, 12, 0, Math.PI * 2);
-    ctx.fill();
+    const dryRunNote = request.dryRun
+        ? (command.dryRun ? '\n\n（演练模式，不会修改已安装的包）' : '\n\n（演练模式：该包管理器不支持演练，只记录命令，不会执行）')
+        : '';
+    if (!confirm(`将执行以下命令：\n\n${command.command}${dryRunNote}\n\n确定继续吗？`)) {
+        return;
+    }
     
-    return canvas.toDataURL();
+    try {
+        await window.go.main.App.EnqueuePackageJob(request);
+    } catch (error) {
+        showSystemMessage('加入任务队列失败: ' + (error.message || error));
+    }
 }
 
-// AI助手相关功能
-
-// 设置AI相关事件
-function setupAIEvents() {
-    // 保存AI配置按钮
-    const saveConfigBtn = document.getElementById('save-ai-config-btn');
-    if (saveConfigBtn) {
-        saveConfigBtn.addEventListener('click', saveAIConfig);
+// 处理任务事件
+function onPackageJobEvent(event) {
+    if (event.type === 'output') {
+        const output = document.querySelector(`#package-job-${event.job.id} .package-job-output`);
+        if (output) {
+            output.textContent += event.line + '\n';
+            output.scrollTop = output.scrollHeight;
+        }
+        return;
     }
     
-    // 发送消息按钮
-    const sendBtn = document.getElementById('send-btn');
-    if (sendBtn) {
-        sendBtn.addEventListener('click', sendAIMessage);
+    if (event.type === 'refreshed' && event.language) {
+        onLanguageRefreshed(event.language);
+        return;
     }
     
-    // 用户输入按下Enter键发送消息
-    const userInput = document.getElementById('user-input');
-    if (userInput) {
-        userInput.addEventListener('keydown', (e) => {
-            // Ctrl+Enter发送消息
-            if (e.key === 'Enter' && e.ctrlKey) {
-                e.preventDefault();
-                sendAIMessage();
-            }
-        });
+    renderPackageJob(event.job);
+    if (['succeeded', 'failed', 'cancelled'].includes(event.job.status) &&
+        document.getElementById('package-journal-panel').style.display !== 'none') {
+        loadPackageJournal();
     }
-    
-    // AI提供商选择变化时更新UI
-    const providerSelect = document.getElementById('ai-provider');
-    if (providerSelect) {
-        providerSelect.addEventListener('change', updateProviderUI);
+    if (event.job.status === 'succeeded' || event.job.status === 'failed') {
+        const label = `${packageJobActionLabels[event.job.action]} ${event.job.name}`;
+        showSystemMessage(event.job.status === 'succeeded' ? `${label} 完成` : `${label} 失败，退出码 ${event.job.exitCode}`);
+    }
+}
+
+// 创建或更新任务条目
+function renderPackageJob(job) {
+    const list = document.getElementById('package-jobs-list');
+    let item = document.getElementById('package-job-' + job.id);
+    if (!item) {
+        item = document.createElement('div');
+        item.id = 'package-job-' + job.id;
+        item.className = 'package-job';
+        
+        const header = document.createElement('div');
+        header.className = 'result-header';
+        const title = document.createElement('span');
+        title.className = 'package-job-title';
+        const actions = document.createElement('span');
+        const cancelBtn = document.createElement('button');
+        cancelBtn.className = 'secondary-btn package-job-cancel';
+        cancelBtn.textContent = '取消';
+        cancelBtn.addEventListener('click', () => window.go.main.App.CancelPackageJob(job.id));
+        actions.appendChild(cancelBtn);
+        header.append(title, actions);
+        
+        const command = document.createElement('code');
+        command.textContent = job.command;
+        const output = document.createElement('pre');
+        output.className = 'package-job-output';
+        output.textContent = job.output || '';
+        
+        item.append(header, command, output);
+        list.prepend(item);
     }
     
-    // 添加语言检测数据按钮
-    const addDetectionDataBtn = document.getElementById('add-detection-data-btn');
-    if (addDetectionDataBtn) {
-        addDetectionDataBtn.addEventListener('click', addDetectionDataToChat);
+    let status = packageJobStatusLabels[job.status] || job.status;
+    if (job.status === 'failed') {
+        status += `（退出码 ${job.exitCode}${job.error ? '，' + job.error : ''}）`;
+    }
+    item.querySelector('.package-job-title').textContent =
+        `${packageJobActionLabels[job.action]} ${job.name} (${job.manager})${job.dryRun ? ' [演练]' : ''} - ${status}`;
+    item.className = `package-job package-job-${job.status}`;
+    item.querySelector('.package-job-cancel').style.display =
+        job.status === 'queued' || job.status === 'running' ? 'inline-block' : 'none';
+    if (job.status !== 'running' && job.output) {
+        item.querySelector('.package-job-output').textContent = job.output;
     }
 }
 
-// 初始化AI助手
-async function initAIAssistant() {
+// 加载包操作记录
+async function loadPackageJournal() {
+    const list = document.getElementById('package-journal-list');
+    let entries;
     try {
-        // 获取AI提供商列表
-        const providers = await window.go.main.App.GetAIProviders();
-        const providerSelect = document.getElementById('ai-provider');
-        
-        if (providerSelect) {
-            // 清空现有选项
-            providerSelect.innerHTML = '';
-            
-            // 添加提供商选项
-            providers.forEach(provider => {
-                const option = document.createElement('option');
-                option.value = provider.id;
-                option.textContent = provider.name;
-                option.dataset.docUrl = provider.documentUrl;
-                providerSelect.appendChild(option);
-            });
-            
-            // 获取保存的配置
-            const config = await window.go.main.App.GetAIConfig();
-            
-            // 设置选中的提供商
-            if (config.selectedProviderId) {
-                providerSelect.value = config.selectedProviderId;
-            }
-            
-            // 设置API密钥
-            const apiKeyInput = document.getElementById('api-key');
-            if (apiKeyInput && config.apiKey) {
-                apiKeyInput.value = config.apiKey;
-            }
-            
-            // 设置自定义端点
-            const customEndpointInput = document.getElementById('custom-endpoint');
-            if (customEndpointInput && config.customEndpoint) {
-                customEndpointInput.value = config.customEndpoint;
-            }
-            
-            // 更新UI
-            updateProviderUI();
-        }
+        entries = await window.go.main.App.GetPackageJournal(100);
     } catch (error) {
-        console.error('初始化AI助手失败:', error);
-        showSystemMessage('初始化AI助手失败: ' + error.message);
+        list.textContent = '读取操作记录失败: ' + (error.message || error);
+        return;
+    }
+    
+    list.innerHTML = '';
+    if (entries.length === 0) {
+        list.textContent = '还没有通过应用执行的包操作';
+        return;
     }
+    
+    entries.forEach(entry => {
+        const item = document.createElement('div');
+        item.className = 'package-journal-entry' + (entry.rolledBackBy ? ' package-journal-rolled-back' : '');
+        item.title = entry.command + (entry.error ? '\n' + entry.error : '');
+        
+        const text = document.createElement('span');
+        const previous = entry.previousVersionUnknown ? '版本未知' : (entry.previousVersion || '未安装');
+        const current = entry.newVersionUnknown ? '版本未知' : (entry.newVersion || '未安装');
+        const versions = `${previous} → ${current}`;
+        let status = packageJobStatusLabels[entry.status] || entry.status;
+        if (entry.rollbackOf) {
+            status += '，回滚操作';
+        }
+        if (entry.rolledBackBy) {
+            status += '，已回滚';
+        }
+        text.textContent = `${new Date(entry.startedAt).toLocaleString()}  ${packageJobActionLabels[entry.action]} ` +
+            `${entry.name} (${entry.manager})  ${versions}  [${status}]`;
+        item.appendChild(text);
+        
+        if (entry.canRollback) {
+            const rollbackBtn = document.createElement('button');
+            rollbackBtn.className = 'secondary-btn';
+            rollbackBtn.textContent = '回滚';
+            rollbackBtn.addEventListener('click', () => rollbackPackageOperation(entry));
+            item.appendChild(rollbackBtn);
+        }
+        list.appendChild(item);
+    });
 }
 
-// 更新提供商UI
+// 确认后把回滚任务加入队列
+async function rollbackPackageOperation(entry) {
+    let commands;
+    try {
+        commands = await window.go.main.App.PreviewPackageRollback(entry.id);
+    } catch (error) {
+        showSystemMessage('无法回滚: ' + (error.message || error));
+        return;
+    }
+    
+    const target = entry.previousVersion ? `恢复到 ${entry.previousVersion}` : '卸载';
+    const lines = commands.map(command => command.command).join('\n');
+    if (!confirm(`将${target} ${entry.name}，依次执行以下命令：\n\n${lines}\n\n确定继续吗？`)) {
+        return;
+    }
+    
+    try {
+        await window.go.main.App.RollbackPackageOperation(entry.id);
+    } catch (error) {
+        showSystemMessage('回滚失败: ' + (error.message || error));
+    }
+}
+
+// 严重程度的显示名称
+const severityLabels = {
+    CRITICAL: '严重',
+    HIGH: '高危',
+    MEDIUM: '中危',
+    LOW: '低危',
+    UNKNOWN: '未知'
+};
+
+// 初始化漏洞审计
+function initVulnerabilityAudit() {
+    const auditBtn = document.getElementById('run-audit-btn');
+    if (!auditBtn) {
+        return;
+    }
+    
+    auditBtn.addEventListener('click', runVulnerabilityAudit);
+    document.getElementById('import-osv-btn').addEventListener('click', importOSVDatabase);
+    document.getElementById('update-osv-btn').addEventListener('click', updateOSVDatabase);
+    document.getElementById('cancel-osv-btn').addEventListener('click', () => window.go.main.App.CancelOSVUpdate());
+    
+    // 在线更新时显示各生态系统的进度
+    window.runtime.EventsOn('osv:update', (event) => {
+        document.getElementById('osv-database-status').textContent = `${event.ecosystem}: ${event.message}`;
+    });
+    
+    loadOSVDatabaseStatus();
+}
+
+// 显示已导入的漏洞数据
+async function loadOSVDatabaseStatus() {
+    const container = document.getElementById('osv-database-status');
+    const databases = await window.go.main.App.GetOSVDatabaseStatus();
+    if (databases.length === 0) {
+        container.textContent = '尚未导入漏洞数据。离线环境请导入从 osv-vulnerabilities.storage.googleapis.com 下载的各生态系统 all.zip';
+        return;
+    }
+    container.textContent = '漏洞数据: ' + databases.map(db => {
+        const modified = db.latestModified ? new Date(db.latestModified).toLocaleDateString() : '未知';
+        return `${db.ecosystem} ${db.advisories} 条（截至 ${modified}）`;
+    }).join('，');
+}
+
+// 下载或导入漏洞数据期间禁用按钮并显示取消按钮
+function setOSVUpdating(updating) {
+    document.getElementById('import-osv-btn').disabled = updating;
+    document.getElementById('update-osv-btn').disabled = updating;
+    document.getElementById('cancel-osv-btn').style.display = updating ? '' : 'none';
+}
+
+// 导入OSV导出文件
+async function importOSVDatabase() {
+    const container = document.getElementById('osv-database-status');
+    container.textContent = '正在导入漏洞数据...';
+    setOSVUpdating(true);
+    try {
+        const imported = await window.go.main.App.ImportOSVDatabase();
+        if (imported.length > 0) {
+            showSystemMessage('已导入: ' + imported.map(db => `${db.ecosystem} ${db.advisories} 条`).join('，'));
+        }
+    } catch (error) {
+        showSystemMessage('导入漏洞数据失败: ' + (error.message || error));
+    } finally {
+        setOSVUpdating(false);
+    }
+    loadOSVDatabaseStatus();
+}
+
+// 在线下载并导入最新的漏洞数据
+async function updateOSVDatabase() {
+    if (!confirm('将从 OSV 重新下载已导入的生态系统（尚未导入时下载全部生态系统）的漏洞数据，文件可能有数百MB，是否继续？')) {
+        return;
+    }
+    
+    setOSVUpdating(true);
+    try {
+        const updated = await window.go.main.App.UpdateOSVDatabase([]);
+        showSystemMessage('已更新: ' + updated.map(db => `${db.ecosystem} ${db.advisories} 条`).join('，'));
+    } catch (error) {
+        showSystemMessage('更新漏洞数据失败: ' + (error.message || error));
+    } finally {
+        setOSVUpdating(false);
+    }
+    loadOSVDatabaseStatus();
+}
+
+// 使用本地漏洞数据审计已安装的包
+async function runVulnerabilityAudit() {
+    const auditBtn = document.getElementById('run-audit-btn');
+    const summary = document.getElementById('audit-summary');
+    const results = document.getElementById('audit-results');
+    
+    auditBtn.disabled = true;
+    summary.textContent = '正在审计已安装的包...';
+    results.innerHTML = '';
+    
+    let report;
+    try {
+        report = await window.go.main.App.AuditInstalledPackages();
+    } catch (error) {
+        summary.textContent = '审计失败: ' + (error.message || error);
+        return;
+    } finally {
+        auditBtn.disabled = false;
+    }
+    
+    const findings = report.findings || [];
+    summary.textContent = `检查了 ${report.scanned} 个包版本，发现 ${findings.length} 个漏洞：` +
+        `严重 ${report.summary.critical}，高危 ${report.summary.high}，中危 ${report.summary.medium}，` +
+        `低危 ${report.summary.low}，未知 ${report.summary.unknown}` +
+        (report.missingEcosystems.length > 0 ? `。没有 ${report.missingEcosystems.join('、')} 的漏洞数据，这些包未审计` : '');
+    
+    if (findings.length === 0) {
+        return;
+    }
+    
+    const table = document.createElement('table');
+    table.className = 'outdated-table';
+    const head = document.createElement('tr');
+    ['包', '版本', '公告', '严重程度', '修复版本', '升级命令'].forEach(text => {
+        const th = document.createElement('th');
+        th.textContent = text;
+        head.appendChild(th);
+    });
+    table.appendChild(head);
+    
+    findings.forEach(finding => {
+        const row = document.createElement('tr');
+        
+        const nameCell = document.createElement('td');
+        nameCell.textContent = `${finding.name} (${finding.ecosystem})`;
+        const versionCell = document.createElement('td');
+        versionCell.textContent = finding.version;
+        
+        const advisoryCell = document.createElement('td');
+        const link = document.createElement('a');
+        link.href = 'https://osv.dev/vulnerability/' + encodeURIComponent(finding.advisoryId);
+        link.target = '_blank';
+        link.textContent = finding.advisoryId;
+        link.title = [finding.summary, ...(finding.aliases || [])].filter(Boolean).join('\n');
+        advisoryCell.appendChild(link);
+        
+        const severityCell = document.createElement('td');
+        const badge = document.createElement('span');
+        badge.className = `outdated-badge outdated-${finding.severity.toLowerCase()}`;
+        badge.textContent = (severityLabels[finding.severity] || finding.severity) + (finding.score ? ' ' + finding.score.toFixed(1) : '');
+        severityCell.appendChild(badge);
+        
+        const fixedCell = document.createElement('td');
+        const fixedVersions = finding.fixedVersions || [];
+        fixedCell.textContent = fixedVersions.length > 0 ? fixedVersions.join(', ') : '暂无修复版本';
+        
+        const commandCell = document.createElement('td');
+        if (finding.upgradeCommand) {
+            const code = document.createElement('code');
+            code.textContent = finding.upgradeCommand;
+            const copyBtn = document.createElement('button');
+            copyBtn.className = 'copy-btn';
+            copyBtn.textContent = getText('copy_btn');
+            copyBtn.addEventListener('click', () => navigator.clipboard.writeText(finding.upgradeCommand));
+            commandCell.append(code, copyBtn);
+        }
+        
+        row.append(nameCell, versionCell, advisoryCell, severityCell, fixedCell, commandCell);
+        table.appendChild(row);
+    });
+    results.appendChild(table);
+}
+
+// 任务完成后用重新检测的结果更新语言列表和打开的语言详情
+function onLanguageRefreshed(language) {
+    showSystemMessage(`${language.name} 的包列表已刷新`);
+    
+    const index = lastScannedLanguages.findIndex(lang => lang.name === language.name);
+    if (index >= 0) {
+        lastScannedLanguages[index] = language;
+        renderLanguages(lastScannedLanguages.filter(lang => lang.installed), 'installed-languages', true);
+        renderLanguages(lastScannedLanguages.filter(lang => !lang.installed), 'missing-languages', false);
+    }
+    
+    const modal = document.getElementById('language-modal');
+    if (modal.style.display === 'block' && modal.dataset.language === language.name) {
+        showLanguageDetails(language, language.installed);
+    }
+}
+
+// 正在进行的跨注册表搜索
+let allPackageSearch = null;
+
+// 显示各注册表的搜索状态
+function renderRegistryStatus() {
+    const statusDiv = document.getElementById('registry-status');
+    if (!statusDiv || !allPackageSearch) {
+        return;
+    }
+    
+    statusDiv.innerHTML = '';
+    Object.keys(allPackageSearch.statuses).sort().forEach(manager => {
+        const status = allPackageSearch.statuses[manager];
+        const span = document.createElement('span');
+        if (status.unsupported) {
+            span.className = 'unsupported';
+            span.textContent = `${manager} 不支持关键词`;
+            span.title = status.error;
+        } else if (status.error && status.count === 0) {
+            span.className = 'failed';
+            span.textContent = `${manager} ✗`;
+            span.title = status.error;
+        } else {
+            span.textContent = `${manager} ${status.count} (${status.elapsedMs} ms)`;
+        }
+        statusDiv.appendChild(span);
+    });
+}
+
+// 显示合并排序后的结果
+function renderAllPackageResults() {
+    const searchResults = document.getElementById('search-results');
+    const packages = allPackageSearch.packages.slice().sort((a, b) => (b.score || 0) - (a.score || 0));
+    if (packages.length === 0) {
+        return;
+    }
+    searchResults.innerHTML = packages.map(renderPackageResult).join('');
+}
+
+// 接收单个注册表的搜索结果
+function onPackageSearchEvent(event) {
+    if (!allPackageSearch || event.query !== allPackageSearch.query) {
+        return;
+    }
+    allPackageSearch.statuses[event.status.manager] = event.status;
+    allPackageSearch.packages = allPackageSearch.packages.concat(event.packages || []);
+    renderRegistryStatus();
+    renderAllPackageResults();
+}
+
+// 同时搜索所有相关的注册表
+async function searchAllPackages(packageName) {
+    const searchResults = document.getElementById('search-results');
+    const detectedOnly = document.getElementById('search-detected-only');
+    const languages = detectedOnly && detectedOnly.checked ? detectedLanguageNames : [];
+    
+    allPackageSearch = { query: packageName, packages: [], statuses: {} };
+    
+    try {
+        const result = await window.go.main.App.SearchAllPackages(packageName, languages);
+        if (!allPackageSearch || allPackageSearch.query !== result.query) {
+            return;
+        }
+        
+        allPackageSearch.packages = result.packages || [];
+        (result.registries || []).forEach(status => {
+            allPackageSearch.statuses[status.manager] = status;
+        });
+        renderRegistryStatus();
+        
+        if (allPackageSearch.packages.length === 0) {
+            searchResults.innerHTML = `<p class="no-results">${getText('no_results')} "${packageName}"</p>`;
+            return;
+        }
+        renderAllPackageResults();
+    } catch (error) {
+        console.error('搜索包时出错:', error);
+        searchResults.innerHTML = `<p class="no-results">${getText('searching')} ${error.message || '未知错误'}</p>`;
+    }
+}
+
+// 单个包管理器的当前搜索结果，用于合并AI补充的信息
+let currentPackageSearch = null;
+
+// 把AI补充的下载链接和安装命令合并到当前搜索结果
+function onPackageEnrichedEvent(event) {
+    if (!currentPackageSearch || currentPackageSearch.manager !== event.manager || currentPackageSearch.query !== event.query) {
+        return;
+    }
+    event.packages.forEach(enriched => {
+        const pkg = currentPackageSearch.results.find(item => item.name === enriched.name);
+        if (pkg) {
+            pkg.aiSuggestedDownloadUrl = enriched.aiSuggestedDownloadUrl;
+            pkg.aiSuggestedInstallLink = enriched.aiSuggestedInstallLink;
+        }
+    });
+    document.getElementById('search-results').innerHTML = currentPackageSearch.results.map(renderPackageResult).join('');
+}
+
+// 搜索包
+async function searchPackages() {
+    const packageManager = document.getElementById('package-manager').value;
+    const packageName = document.getElementById('package-name').value.trim();
+    const searchResults = document.getElementById('search-results');
+    const registryStatus = document.getElementById('registry-status');
+    
+    if (!packageName) {
+        searchResults.innerHTML = `<p class="no-results">${getText('no_input')}</p>`;
+        return;
+    }
+    
+    if (registryStatus) {
+        registryStatus.innerHTML = '';
+    }
+    
+    // 显示加载中
+    searchResults.innerHTML = `
+        <div class="loading">
+            <div class="spinner"></div>
+            <p>${getText('searching')} ${packageName}...</p>
+        </div>
+    `;
+    
+    currentPackageSearch = null;
+    if (packageManager === 'all') {
+        await searchAllPackages(packageName);
+        return;
+    }
+    
+    try {
+        const results = await window.go.main.App.SearchPackage(packageManager, packageName);
+        currentPackageSearch = { manager: packageManager, query: packageName, results: results };
+        
+        if (results.length === 0) {
+            searchResults.innerHTML = `<p class="no-results">${getText('no_results')} "${packageName}"</p>`;
+            return;
+        }
+        
+        // 渲染搜索结果
+        searchResults.innerHTML = results.map(renderPackageResult).join('');
+    } catch (error) {
+        console.error('搜索包时出错:', error);
+        searchResults.innerHTML = `<p class="no-results">${getText('searching')} ${error.message || '未知错误'}</p>`;
+    }
+}
+
+// 搜索语言
+function searchLanguages(event) {
+    const searchTerm = document.getElementById('language-search').value.toLowerCase();
+    const activeTab = document.querySelector('.tab-btn.active').getAttribute('data-tab');
+    const languageCards = document.querySelectorAll(`#${activeTab}-languages .language-card`);
+    
+    languageCards.forEach(card => {
+        const languageName = card.querySelector('h3').textContent.toLowerCase();
+        if (languageName.includes(searchTerm) || searchTerm === '') {
+            card.style.display = 'block';
+        } else {
+            card.style.display = 'none';
+        }
+    });
+}
+
+// 创建简单的Logo
+function createLogo() {
+    const canvas = document.createElement('canvas');
+    canvas.width = 200;
+    canvas.height = 200;
+    const ctx = canvas.getContext('2d');
+    
+    // 绘制背景圆
+    ctx.fillStyle = '#4a6bff';
+    ctx.beginPath();
+    ctx.arc(100, 100, 80, 0, Math.PI * 2);
+    ctx.fill();
+    
+    // 绘制内部圆
+    ctx.fillStyle = 'white';
+    ctx.beginPath();
+    ctx.arc(100, 100, 60, 0, Math.PI * 2);
+    ctx.fill();
+    
+    // 绘制网络连接线
+    ctx.strokeStyle = '#4a6bff';
+    ctx.lineWidth = 8;
+    
+    // 绘制连接线
+    ctx.beginPath();
+    ctx.moveTo(70, 70);
+    ctx.lineTo(130, 130);
+    ctx.stroke();
+    
+    ctx.beginPath();
+    ctx.moveTo(130, 70);
+    ctx.lineTo(70, 130);
+    ctx.stroke();
+    
+    // 绘制节点
+    ctx.fillStyle = '#34c759';
+    ctx.beginPath();
+    ctx.arc(70, 70, 12, 0, Math.PI * 2);
+    ctx.fill();
+    
+    ctx.fillStyle = '#ff3b30';
+    ctx.beginPath();
+    ctx.arc(130, 130, 12, 0, Math.PI * 2);
+    ctx.fill();
+    
+    ctx.fillStyle = '#ffcc00';
+    ctx.beginPath();
+    ctx.arc(130, 70, 12, 0, Math.PI * 2);
+    ctx.fill();
+    
+    ctx.fillStyle = '#5856d6';
+    ctx.beginPath();
+    ctx.arc(70, 130, 12, 0, Math.PI * 2);
+    ctx.fill();
+    
+    return canvas.toDataURL();
+}
+
+// AI助手相关功能
+
+// 设置AI相关事件
+function setupAIEvents() {
+    // 保存AI配置按钮
+    const saveConfigBtn = document.getElementById('save-ai-config-btn');
+    if (saveConfigBtn) {
+        saveConfigBtn.addEventListener('click', saveAIConfig);
+    }
+    
+    // 发送消息按钮
+    const sendBtn = document.getElementById('send-btn');
+    if (sendBtn) {
+        sendBtn.addEventListener('click', sendAIMessage);
+    }
+    
+    // 会话管理
+    initConversationBar();
+    initEnvironmentSummaryBar();
+    initPromptTemplateBar();
+    initUsagePanel();
+    initUserProvidersPanel();
+    initRedactionPanel();
+    
+    // 停止生成按钮
+    const stopBtn = document.getElementById('stop-btn');
+    if (stopBtn) {
+        stopBtn.addEventListener('click', () => {
+            if (currentAIRequestId) {
+                window.go.main.App.CancelAI(currentAIRequestId);
+            }
+        });
+    }
+    
+    // 接收流式生成的文本
+    window.runtime.EventsOn('ai:stream', (event) => {
+        const stream = aiStreams[event.requestId];
+        if (stream && event.delta) {
+            stream.content += event.delta;
+            stream.element.textContent = stream.content;
+            const chatMessages = document.getElementById('chat-messages');
+            if (chatMessages) {
+                chatMessages.scrollTop = chatMessages.scrollHeight;
+            }
+        }
+    });
+    
+    // 接收AI工具调用的状态
+    window.runtime.EventsOn('ai:tool', showToolCall);
+    
+    // 用户输入按下Enter键发送消息
+    const userInput = document.getElementById('user-input');
+    if (userInput) {
+        userInput.addEventListener('keydown', (e) => {
+            // Ctrl+Enter发送消息
+            if (e.key === 'Enter' && e.ctrlKey) {
+                e.preventDefault();
+                sendAIMessage();
+            }
+        });
+    }
+    
+    // AI提供商选择变化时更新UI
+    const providerSelect = document.getElementById('ai-provider');
+    if (providerSelect) {
+        providerSelect.addEventListener('change', updateProviderUI);
+    }
+    
+    // 刷新模型列表按钮
+    const refreshModelsBtn = document.getElementById('refresh-models-btn');
+    if (refreshModelsBtn) {
+        refreshModelsBtn.addEventListener('click', refreshModelList);
+    }
+    
+    // 测试连接按钮
+    const testConnectionBtn = document.getElementById('test-connection-btn');
+    if (testConnectionBtn) {
+        testConnectionBtn.addEventListener('click', testAIConnection);
+    }
+    
+    // 密钥口令
+    const unlockKeysBtn = document.getElementById('unlock-ai-keys-btn');
+    if (unlockKeysBtn) {
+        unlockKeysBtn.addEventListener('click', unlockAIKeys);
+        document.getElementById('set-ai-passphrase-btn').addEventListener('click', setAIKeyPassphrase);
+        document.getElementById('clear-ai-passphrase-btn').addEventListener('click', clearAIKeyPassphrase);
+    }
+    
+    // 添加语言检测数据按钮
+    const addDetectionDataBtn = document.getElementById('add-detection-data-btn');
+    if (addDetectionDataBtn) {
+        addDetectionDataBtn.addEventListener('click', addDetectionDataToChat);
+    }
+}
+
+// 初始化AI助手
+async function initAIAssistant() {
+    try {
+        // 获取AI提供商列表
+        await refreshAIProviderOptions();
+        const providerSelect = document.getElementById('ai-provider');
+        
+        if (providerSelect) {
+            // 获取保存的配置
+            const config = await window.go.main.App.GetAIConfig();
+            
+            // 设置选中的提供商
+            if (config.selectedProviderId) {
+                providerSelect.value = config.selectedProviderId;
+            }
+            
+            // 设置API密钥
+            const apiKeyInput = document.getElementById('api-key');
+            if (apiKeyInput && config.apiKey) {
+                apiKeyInput.value = config.apiKey;
+            }
+            
+            // 设置自定义端点
+            const customEndpointInput = document.getElementById('custom-endpoint');
+            if (customEndpointInput && config.customEndpoint) {
+                customEndpointInput.value = config.customEndpoint;
+            }
+            
+            aiProviderSettings = config.providerSettings || {};
+            aiCredentials = config.credentials || {};
+            
+            // 设置包信息补充和离线模式
+            const enrichmentToggle = document.getElementById('package-enrichment-toggle');
+            if (enrichmentToggle) {
+                enrichmentToggle.checked = !!config.packageEnrichment;
+            }
+            const offlineToggle = document.getElementById('offline-mode-toggle');
+            if (offlineToggle) {
+                offlineToggle.checked = !!config.offlineMode;
+            }
+            const toolsToggle = document.getElementById('enable-tools-toggle');
+            if (toolsToggle) {
+                toolsToggle.checked = !!config.enableTools;
+            }
+            
+            // 使用口令加密的密钥需要先解锁
+            updateAIKeyProtection(config);
+            if (config.keyLocked) {
+                showSystemMessage('AI密钥已使用口令加密，请在AI助手设置中输入口令解锁');
+                document.getElementById('ai-passphrase').focus();
+            }
+            
+            // 更新UI
+            updateProviderUI();
+        }
+    } catch (error) {
+        console.error('初始化AI助手失败:', error);
+        showSystemMessage('初始化AI助手失败: ' + error.message);
+    }
+}
+
+// 提供商列表和按提供商保存的生成参数、凭据
+let aiProviders = [];
+let aiProviderSettings = {};
+let aiCredentials = {};
+
+// 重新获取提供商列表，更新设置和会话栏中的下拉框
+async function refreshAIProviderOptions() {
+    aiProviders = await window.go.main.App.GetAIProviders();
+    
+    const providerSelect = document.getElementById('ai-provider');
+    if (providerSelect) {
+        const selected = providerSelect.value;
+        providerSelect.innerHTML = '';
+        aiProviders.forEach(provider => {
+            const option = document.createElement('option');
+            option.value = provider.id;
+            option.textContent = provider.name;
+            option.dataset.docUrl = provider.documentUrl || '';
+            providerSelect.appendChild(option);
+        });
+        if (aiProviders.some(p => p.id === selected)) {
+            providerSelect.value = selected;
+        }
+    }
+    
+    const conversationProvider = document.getElementById('conversation-provider');
+    if (conversationProvider) {
+        const selected = conversationProvider.value;
+        conversationProvider.innerHTML = '<option value="">默认提供商</option>';
+        aiProviders.forEach(provider => {
+            const option = document.createElement('option');
+            option.value = provider.id;
+            option.textContent = provider.name;
+            conversationProvider.appendChild(option);
+        });
+        conversationProvider.value = aiProviders.some(p => p.id === selected) ? selected : '';
+    }
+}
+
+// 自定义提供商使用各自保存的密钥
+function isUserDefinedProvider(providerId) {
+    const provider = aiProviders.find(p => p.id === providerId);
+    return !!(provider && provider.userDefined);
+}
+
+// 当前会话使用的提供商，未单独指定时使用设置中选中的提供商
+function getConversationProviderId(config) {
+    const conversationProvider = document.getElementById('conversation-provider');
+    return (conversationProvider && conversationProvider.value) || config.selectedProviderId;
+}
+
+// 本地模型不需要API密钥
+function isLocalProvider(providerId) {
+    const provider = aiProviders.find(p => p.id === providerId);
+    return !!(provider && provider.local);
+}
+
+// 填充模型下拉框
+function fillModelSelect(models, selected) {
+    const modelSelect = document.getElementById('ai-model');
+    if (!modelSelect) {
+        return;
+    }
+    
+    modelSelect.innerHTML = '';
+    const all = models.slice();
+    if (selected && !all.includes(selected)) {
+        all.unshift(selected);
+    }
+    all.forEach(model => {
+        const option = document.createElement('option');
+        option.value = model;
+        option.textContent = model;
+        modelSelect.appendChild(option);
+    });
+    if (selected) {
+        modelSelect.value = selected;
+    }
+}
+
+// 显示当前提供商的模型和生成参数
+function updateProviderSettingsUI(providerId) {
+    const provider = aiProviders.find(p => p.id === providerId) || {};
+    const settings = aiProviderSettings[providerId] || {};
+    
+    fillModelSelect(provider.models || [], settings.model || provider.defaultModel || '');
+    
+    const temperatureInput = document.getElementById('ai-temperature');
+    if (temperatureInput) {
+        temperatureInput.value = settings.temperature ?? '';
+    }
+    const maxTokensInput = document.getElementById('ai-max-tokens');
+    if (maxTokensInput) {
+        maxTokensInput.value = settings.maxTokens || '';
+        maxTokensInput.placeholder = provider.defaultMaxTokens ? String(provider.defaultMaxTokens) : '默认';
+    }
+    const systemPromptInput = document.getElementById('ai-system-prompt');
+    if (systemPromptInput) {
+        systemPromptInput.value = settings.systemPrompt || '';
+        systemPromptInput.placeholder = provider.defaultPrompt || '留空使用默认提示词';
+    }
+    const disableToolsInput = document.getElementById('ai-disable-tools');
+    if (disableToolsInput) {
+        disableToolsInput.checked = !!settings.disableTools;
+    }
+}
+
+// 显示当前提供商需要的凭据字段
+function renderCredentialFields(providerId) {
+    const container = document.getElementById('provider-credentials');
+    if (!container) {
+        return;
+    }
+    
+    const provider = aiProviders.find(p => p.id === providerId) || {};
+    const saved = aiCredentials[providerId] || {};
+    container.innerHTML = '';
+    
+    (provider.credentialFields || []).forEach(field => {
+        const group = document.createElement('div');
+        group.className = 'form-group';
+        
+        const label = document.createElement('label');
+        label.htmlFor = 'credential-' + field.name;
+        label.textContent = field.label + ':';
+        
+        const input = document.createElement('input');
+        input.type = field.secret ? 'password' : 'text';
+        input.id = 'credential-' + field.name;
+        input.dataset.field = field.name;
+        input.placeholder = field.placeholder || field.default || '';
+        input.value = saved[field.name] || '';
+        
+        group.appendChild(label);
+        group.appendChild(input);
+        container.appendChild(group);
+    });
+}
+
+// 读取界面上填写的凭据
+function collectCredentialFields() {
+    const credentials = {};
+    document.querySelectorAll('#provider-credentials input[data-field]').forEach(input => {
+        credentials[input.dataset.field] = input.value.trim();
+    });
+    return credentials;
+}
+
+// 使用界面上的配置测试连接（无需先保存）
+async function testAIConnection() {
+    const providerSelect = document.getElementById('ai-provider');
+    const apiKeyInput = document.getElementById('api-key');
+    const customEndpointInput = document.getElementById('custom-endpoint');
+    const testBtn = document.getElementById('test-connection-btn');
+    const resultDiv = document.getElementById('test-connection-result');
+    
+    if (!providerSelect || !resultDiv) {
+        return;
+    }
+    
+    resultDiv.style.display = 'block';
+    resultDiv.className = 'test-connection-result';
+    resultDiv.textContent = '正在测试连接...';
+    if (testBtn) {
+        testBtn.disabled = true;
+    }
+    
+    const stageNames = {
+        config: '配置检查',
+        token: '获取访问令牌',
+        request: '发送请求',
+        response: '解析响应'
+    };
+    
+    try {
+        const result = await window.go.main.App.TestAIConnection(
+            providerSelect.value,
+            apiKeyInput ? apiKeyInput.value.trim() : '',
+            customEndpointInput ? customEndpointInput.value.trim() : '',
+            collectCredentialFields()
+        );
+        
+        if (result.success) {
+            resultDiv.classList.add('success');
+            resultDiv.textContent = result.message;
+        } else {
+            resultDiv.classList.add('error');
+            let text = `失败环节: ${stageNames[result.stage] || result.stage}\n${result.message}`;
+            if (result.endpoint) {
+                text += `\n端点: ${result.endpoint}`;
+            }
+            resultDiv.textContent = text;
+        }
+    } catch (error) {
+        resultDiv.classList.add('error');
+        resultDiv.textContent = '测试连接失败: ' + error;
+    } finally {
+        if (testBtn) {
+            testBtn.disabled = false;
+        }
+    }
+}
+
+// 从提供商获取可用模型
+async function refreshModelList() {
+    const providerSelect = document.getElementById('ai-provider');
+    const apiKeyInput = document.getElementById('api-key');
+    const customEndpointInput = document.getElementById('custom-endpoint');
+    const modelSelect = document.getElementById('ai-model');
+    
+    try {
+        const models = await window.go.main.App.ListAIModels(
+            providerSelect.value,
+            apiKeyInput ? apiKeyInput.value.trim() : '',
+            customEndpointInput ? customEndpointInput.value.trim() : ''
+        );
+        fillModelSelect(models || [], modelSelect ? modelSelect.value : '');
+    } catch (error) {
+        showSystemMessage('获取模型列表失败: ' + error);
+    }
+}
+
+// 更新提供商UI
 function updateProviderUI() {
     const providerSelect = document.getElementById('ai-provider');
     const customEndpointContainer = document.getElementById('custom-endpoint-container');
@@ -2230,8 +3341,22 @@ function updateProviderUI() {
     if (providerSelect && customEndpointContainer) {
         const selectedProvider = providerSelect.value;
         
-        // 显示/隐藏自定义端点输入
-        if (selectedProvider === 'custom' || selectedProvider === 'azure_openai') {
+        updateProviderSettingsUI(selectedProvider);
+        renderCredentialFields(selectedProvider);
+        
+        // 自定义提供商的密钥在凭据字段中填写
+        const apiKeyInput = document.getElementById('api-key');
+        if (apiKeyInput) {
+            apiKeyInput.closest('.form-group').style.display = isUserDefinedProvider(selectedProvider) ? 'none' : '';
+        }
+        
+        const testResult = document.getElementById('test-connection-result');
+        if (testResult) {
+            testResult.style.display = 'none';
+        }
+        
+        // 显示/隐藏自定义端点输入（本地模型可修改服务地址）
+        if (selectedProvider === 'custom' || selectedProvider === 'azure_openai' || isLocalProvider(selectedProvider)) {
             customEndpointContainer.style.display = 'block';
         } else {
             customEndpointContainer.style.display = 'none';
@@ -2252,103 +3377,1307 @@ function updateProviderUI() {
     }
 }
 
-// 保存AI配置
-async function saveAIConfig() {
-    const providerSelect = document.getElementById('ai-provider');
-    const apiKeyInput = document.getElementById('api-key');
-    const customEndpointInput = document.getElementById('custom-endpoint');
-    
-    if (!providerSelect || !apiKeyInput) {
+// 保存AI配置
+async function saveAIConfig() {
+    const providerSelect = document.getElementById('ai-provider');
+    const apiKeyInput = document.getElementById('api-key');
+    const customEndpointInput = document.getElementById('custom-endpoint');
+    
+    if (!providerSelect || !apiKeyInput) {
+        return;
+    }
+    
+    const enrichmentToggle = document.getElementById('package-enrichment-toggle');
+    const offlineToggle = document.getElementById('offline-mode-toggle');
+    const toolsToggle = document.getElementById('enable-tools-toggle');
+    
+    const selectedProvider = providerSelect.value;
+    const apiKey = apiKeyInput.value.trim();
+    const customEndpoint = customEndpointInput ? customEndpointInput.value.trim() : '';
+    const offlineMode = offlineToggle ? offlineToggle.checked : false;
+    
+    if (!apiKey && !offlineMode && !isLocalProvider(selectedProvider) && !isUserDefinedProvider(selectedProvider)) {
+        showSystemMessage('请输入API密钥');
+        return;
+    }
+    
+    // Azure可以填写资源名称和部署名称代替完整端点
+    const credentials = collectCredentialFields();
+    const azureFromCredentials = selectedProvider === 'azure_openai' && credentials.resource && credentials.deployment;
+    if ((selectedProvider === 'custom' || selectedProvider === 'azure_openai') && !customEndpoint && !azureFromCredentials) {
+        showSystemMessage(selectedProvider === 'azure_openai' ? '请输入资源名称和部署名称，或输入自定义端点URL' : '请输入自定义端点URL');
+        return;
+    }
+    
+    // 保存当前提供商的模型和生成参数
+    const modelSelect = document.getElementById('ai-model');
+    const temperatureInput = document.getElementById('ai-temperature');
+    const maxTokensInput = document.getElementById('ai-max-tokens');
+    const systemPromptInput = document.getElementById('ai-system-prompt');
+    const disableToolsInput = document.getElementById('ai-disable-tools');
+    const previous = aiProviderSettings[selectedProvider] || {};
+    aiProviderSettings[selectedProvider] = {
+        model: modelSelect ? modelSelect.value : '',
+        temperature: temperatureInput && temperatureInput.value !== '' ? parseFloat(temperatureInput.value) : null,
+        maxTokens: maxTokensInput && maxTokensInput.value !== '' ? parseInt(maxTokensInput.value, 10) : 0,
+        systemPrompt: systemPromptInput ? systemPromptInput.value.trim() : '',
+        extraHeaders: previous.extraHeaders || {},
+        disableTools: disableToolsInput ? disableToolsInput.checked : false
+    };
+    
+    try {
+        // 保存配置，保留界面上没有的字段
+        const existing = await window.go.main.App.GetAIConfig();
+        aiCredentials = { ...(existing.credentials || {}), [selectedProvider]: credentials };
+        const config = {
+            ...existing,
+            providerSettings: aiProviderSettings,
+            credentials: aiCredentials,
+            selectedProviderId: selectedProvider,
+            apiKey: apiKey,
+            customEndpoint: customEndpoint,
+            packageEnrichment: enrichmentToggle ? enrichmentToggle.checked : false,
+            offlineMode: offlineMode,
+            enableTools: toolsToggle ? toolsToggle.checked : false
+        };
+        
+        await window.go.main.App.SaveAIConfig(config);
+        showSystemMessage('配置已保存');
+    } catch (error) {
+        console.error('保存AI配置失败:', error);
+        showSystemMessage('保存AI配置失败: ' + error.message);
+    }
+}
+
+// 发送AI消息
+async function sendAIMessage() {
+    const userInput = document.getElementById('user-input');
+    const chatMessages = document.getElementById('chat-messages');
+    
+    if (!userInput || !chatMessages) {
+        return;
+    }
+    
+    const query = userInput.value.trim();
+    
+    if (!query) {
+        return;
+    }
+    
+    // 获取配置
+    const config = await window.go.main.App.GetAIConfig();
+    const providerId = getConversationProviderId(config);
+    
+    if (!config.apiKey && !isLocalProvider(providerId) && !isUserDefinedProvider(providerId)) {
+        showSystemMessage('请先设置API密钥');
+        return;
+    }
+    
+    // 检查本月预算，超出时提醒但允许继续发送
+    if (!isLocalProvider(providerId)) {
+        const budget = await window.go.main.App.CheckAIBudget();
+        if (budget.exceeded) {
+            if (!confirm(budget.message + '，仍要发送吗？')) {
+                return;
+            }
+        } else if (budget.warning) {
+            showSystemMessage(budget.message);
+        }
+    }
+    
+    // 附加环境信息：首次发送时先生成摘要供用户确认，再次发送时使用确认后的内容
+    let fullQuery = query;
+    const envToggle = document.getElementById('attach-env-toggle');
+    const envPreview = document.getElementById('env-summary-preview');
+    if (envToggle && envToggle.checked && envPreview) {
+        if (!envPreview.value.trim()) {
+            await previewEnvironmentSummary();
+            showSystemMessage('已生成环境信息，请确认或修改后再次点击发送');
+            return;
+        }
+        fullQuery = '[环境信息]\n' + envPreview.value.trim() + '\n\n[问题]\n' + query;
+    }
+    
+    // 有内容会被脱敏时先显示预览，再次点击发送时确认
+    if (!(await confirmRedaction(fullQuery))) {
+        return;
+    }
+    if (fullQuery !== query && envPreview) {
+        envPreview.value = '';
+        envPreview.style.display = 'none';
+    }
+    
+    // 添加用户消息
+    addMessage('user', query);
+    
+    // 清空输入框
+    userInput.value = '';
+    
+    // 显示流式生成中的消息
+    const requestId = 'req-' + Date.now();
+    const streamDiv = document.createElement('div');
+    streamDiv.className = 'message ai';
+    const streamContent = document.createElement('div');
+    streamContent.className = 'message-content';
+    streamDiv.appendChild(streamContent);
+    chatMessages.appendChild(streamDiv);
+    aiStreams[requestId] = { element: streamContent, content: '' };
+    currentAIRequestId = requestId;
+    setStreamingUI(true);
+    
+    try {
+        // 没有选中会话时自动创建一个，以问题开头作为标题
+        if (!currentConversationId) {
+            const conversation = await window.go.main.App.CreateConversation(query.slice(0, 30), providerId);
+            currentConversationId = conversation.id;
+        }
+        
+        // 在会话中发送流式请求，全局密钥和端点只属于设置中选中的提供商
+        const usesSettings = providerId === config.selectedProviderId;
+        const response = await window.go.main.App.SendConversationMessage(
+            requestId,
+            currentConversationId,
+            providerId,
+            usesSettings ? config.apiKey : '',
+            usesSettings ? config.customEndpoint : '',
+            fullQuery
+        );
+        
+        // 用格式化后的完整内容替换流式文本
+        streamDiv.remove();
+        
+        if (response.content) {
+            addMessage('ai', response.content, response.provider);
+        }
+        if (!response.success) {
+            // 显示错误
+            showSystemMessage('请求失败: ' + response.error);
+        }
+    } catch (error) {
+        streamDiv.remove();
+        
+        console.error('AI请求失败:', error);
+        showSystemMessage('AI请求失败: ' + error.message);
+    } finally {
+        delete aiStreams[requestId];
+        currentAIRequestId = null;
+        setStreamingUI(false);
+        refreshConversationList();
+    }
+}
+
+// 生成环境信息摘要并显示在预览框中，供用户确认或修改
+async function previewEnvironmentSummary() {
+    const userInput = document.getElementById('user-input');
+    const envPreview = document.getElementById('env-summary-preview');
+    if (!envPreview) {
+        return;
+    }
+    
+    try {
+        const summary = await window.go.main.App.GetEnvironmentSummary(userInput ? userInput.value : '', '');
+        envPreview.value = summary.text;
+        envPreview.style.display = 'block';
+    } catch (error) {
+        console.error('生成环境信息失败:', error);
+        showSystemMessage('生成环境信息失败: ' + error.message);
+    }
+}
+
+// 初始化环境信息栏
+function initEnvironmentSummaryBar() {
+    const previewBtn = document.getElementById('preview-env-btn');
+    const envToggle = document.getElementById('attach-env-toggle');
+    const envPreview = document.getElementById('env-summary-preview');
+    
+    if (previewBtn) {
+        previewBtn.addEventListener('click', previewEnvironmentSummary);
+    }
+    if (envToggle && envPreview) {
+        envToggle.addEventListener('change', () => {
+            if (!envToggle.checked) {
+                envPreview.value = '';
+                envPreview.style.display = 'none';
+            }
+        });
+    }
+}
+
+// 初始化用量统计面板
+function initUsagePanel() {
+    const toggleBtn = document.getElementById('toggle-usage-btn');
+    const panel = document.getElementById('ai-usage-panel');
+    const monthInput = document.getElementById('usage-month');
+    const saveBtn = document.getElementById('save-pricing-btn');
+    
+    if (!toggleBtn || !panel) {
+        return;
+    }
+    
+    toggleBtn.addEventListener('click', () => {
+        const visible = panel.style.display !== 'none';
+        panel.style.display = visible ? 'none' : 'block';
+        if (!visible) {
+            loadPricing();
+            loadUsageReport();
+        }
+    });
+    if (monthInput) {
+        monthInput.value = new Date().toISOString().slice(0, 7);
+        monthInput.addEventListener('change', loadUsageReport);
+    }
+    if (saveBtn) {
+        saveBtn.addEventListener('click', savePricing);
+    }
+}
+
+// 格式化按币种累计的花费
+function formatCost(cost) {
+    const parts = Object.entries(cost || {}).map(([currency, value]) => `${value.toFixed(4)} ${currency}`);
+    return parts.length ? parts.join(' + ') : '-';
+}
+
+// 加载并显示用量报告
+async function loadUsageReport() {
+    const monthInput = document.getElementById('usage-month');
+    const container = document.getElementById('usage-report');
+    if (!container) {
+        return;
+    }
+    
+    try {
+        const report = await window.go.main.App.GetAIUsageReport(monthInput ? monthInput.value : '');
+        const row = (total) => `<tr><td>${total.key}</td><td>${total.requests}</td><td>${total.promptTokens}</td><td>${total.completionTokens}</td><td>${total.avgLatencyMs} ms</td><td>${formatCost(total.cost)}</td></tr>`;
+        const header = '<tr><th></th><th>请求</th><th>输入token</th><th>输出token</th><th>平均耗时</th><th>估算费用</th></tr>';
+        
+        let html = `<table class="usage-table">${header}${row(report.total)}</table>`;
+        if (report.budget && report.budget.message) {
+            html += `<p class="usage-warning">${report.budget.message}</p>`;
+        }
+        html += `<h4>按模型</h4><table class="usage-table">${header}${(report.byModel || []).map(row).join('')}</table>`;
+        html += `<h4>按天</h4><table class="usage-table">${header}${(report.daily || []).map(row).join('')}</table>`;
+        container.innerHTML = html;
+    } catch (error) {
+        container.textContent = '加载用量报告失败: ' + error;
+    }
+}
+
+// 加载预算和价格表
+async function loadPricing() {
+    const pricing = await window.go.main.App.GetAIPricing();
+    document.getElementById('usage-budget').value = pricing.monthlyBudget || '';
+    document.getElementById('usage-currency').value = pricing.currency || 'USD';
+    document.getElementById('usage-prices').value = JSON.stringify(pricing.prices || [], null, 2);
+}
+
+// 保存预算和价格表
+async function savePricing() {
+    try {
+        const pricing = {
+            monthlyBudget: parseFloat(document.getElementById('usage-budget').value) || 0,
+            currency: document.getElementById('usage-currency').value.trim() || 'USD',
+            prices: JSON.parse(document.getElementById('usage-prices').value || '[]')
+        };
+        await window.go.main.App.SaveAIPricing(pricing);
+        showSystemMessage('预算和价格表已保存');
+        loadUsageReport();
+    } catch (error) {
+        showSystemMessage('保存价格表失败: ' + (error.message || error));
+    }
+}
+
+// 已预览过脱敏结果、等待确认发送的内容
+let pendingRedactionText = null;
+
+// 预览发送前会被脱敏的内容，用户确认后返回true
+async function confirmRedaction(text) {
+    const preview = document.getElementById('redaction-preview');
+    if (!preview) {
+        return true;
+    }
+    
+    if (pendingRedactionText === text) {
+        pendingRedactionText = null;
+        preview.style.display = 'none';
+        return true;
+    }
+    
+    const result = await window.go.main.App.PreviewRedaction(text);
+    if (!result.matches || result.matches.length === 0) {
+        pendingRedactionText = null;
+        preview.style.display = 'none';
+        return true;
+    }
+    
+    // 相同内容只列出一次
+    const seen = new Set();
+    const items = result.matches.filter(match => {
+        if (seen.has(match.placeholder)) {
+            return false;
+        }
+        seen.add(match.placeholder);
+        return true;
+    });
+    
+    preview.innerHTML = '<strong>以下内容将在发送前被替换，确认后请再次点击发送：</strong>';
+    const list = document.createElement('ul');
+    items.forEach(match => {
+        const item = document.createElement('li');
+        const original = document.createElement('code');
+        original.textContent = match.original.length > 60 ? match.original.slice(0, 60) + '…' : match.original;
+        const placeholder = document.createElement('code');
+        placeholder.textContent = match.placeholder;
+        item.append(match.rule + ': ', original, ' → ', placeholder);
+        list.appendChild(item);
+    });
+    preview.appendChild(list);
+    preview.style.display = 'block';
+    
+    pendingRedactionText = text;
+    return false;
+}
+
+// 初始化注册表地址设置面板
+function initRegistrySettingsPanel() {
+    const toggleBtn = document.getElementById('toggle-registry-settings-btn');
+    const panel = document.getElementById('registry-settings-panel');
+    const saveBtn = document.getElementById('save-registry-settings-btn');
+    
+    if (!toggleBtn || !panel) {
+        return;
+    }
+    
+    toggleBtn.addEventListener('click', () => {
+        const visible = panel.style.display !== 'none';
+        panel.style.display = visible ? 'none' : 'block';
+        if (!visible) {
+            loadRegistrySettings();
+        }
+    });
+    if (saveBtn) {
+        saveBtn.addEventListener('click', saveRegistrySettings);
+    }
+}
+
+// 加载注册表地址设置，输入框的占位符显示默认地址
+async function loadRegistrySettings() {
+    const settings = await window.go.main.App.GetRegistrySettings();
+    const container = document.getElementById('registry-base-urls');
+    container.innerHTML = '';
+    
+    Object.keys(settings.defaults || {}).sort().forEach(manager => {
+        const group = document.createElement('div');
+        group.className = 'form-group';
+        
+        const label = document.createElement('label');
+        label.textContent = manager + ':';
+        
+        const input = document.createElement('input');
+        input.type = 'text';
+        input.dataset.manager = manager;
+        input.placeholder = settings.defaults[manager];
+        input.value = (settings.baseUrls || {})[manager] || '';
+        
+        group.append(label, input);
+        
+        // 有镜像可选时提供一键填入
+        (settings.mirrors || []).filter(mirror => mirror.manager === manager).forEach(mirror => {
+            const button = document.createElement('button');
+            button.className = 'secondary-btn';
+            button.textContent = mirror.name;
+            button.title = mirror.baseUrl;
+            button.addEventListener('click', () => {
+                input.value = mirror.baseUrl;
+            });
+            group.appendChild(button);
+        });
+        
+        container.appendChild(group);
+    });
+}
+
+// 保存注册表地址设置
+async function saveRegistrySettings() {
+    const baseUrls = {};
+    document.querySelectorAll('#registry-base-urls input[data-manager]').forEach(input => {
+        if (input.value.trim()) {
+            baseUrls[input.dataset.manager] = input.value.trim();
+        }
+    });
+    try {
+        await window.go.main.App.SaveRegistrySettings({ baseUrls: baseUrls });
+        showSystemMessage('注册表地址已保存');
+    } catch (error) {
+        showSystemMessage('保存注册表地址失败: ' + (error.message || error));
+    }
+}
+
+// 初始化网络设置面板
+function initNetworkSettingsPanel() {
+    const toggleBtn = document.getElementById('toggle-network-settings-btn');
+    const panel = document.getElementById('network-settings-panel');
+    
+    if (!toggleBtn || !panel) {
+        return;
+    }
+    
+    toggleBtn.addEventListener('click', () => {
+        const visible = panel.style.display !== 'none';
+        panel.style.display = visible ? 'none' : 'block';
+        if (!visible) {
+            loadNetworkSettings();
+        }
+    });
+    document.getElementById('network-proxy-mode').addEventListener('change', updateNetworkProxyFields);
+    document.getElementById('save-network-settings-btn').addEventListener('click', saveNetworkSettings);
+    document.getElementById('test-network-btn').addEventListener('click', testNetworkConnection);
+}
+
+// 只有手动代理时才能填写代理地址
+function updateNetworkProxyFields() {
+    const manual = document.getElementById('network-proxy-mode').value === 'manual';
+    document.getElementById('network-proxy-url').disabled = !manual;
+    document.getElementById('network-no-proxy').disabled = !manual;
+}
+
+// 加载网络设置
+async function loadNetworkSettings() {
+    const settings = await window.go.main.App.GetNetworkSettings();
+    document.getElementById('network-proxy-mode').value = settings.proxyMode || 'system';
+    document.getElementById('network-proxy-url').value = settings.proxyUrl || '';
+    document.getElementById('network-no-proxy').value = settings.noProxy || '';
+    document.getElementById('network-ca-bundle').value = settings.caBundlePath || '';
+    updateNetworkProxyFields();
+}
+
+// 读取界面上的网络设置
+function collectNetworkSettings() {
+    return {
+        proxyMode: document.getElementById('network-proxy-mode').value,
+        proxyUrl: document.getElementById('network-proxy-url').value.trim(),
+        noProxy: document.getElementById('network-no-proxy').value.trim(),
+        caBundlePath: document.getElementById('network-ca-bundle').value.trim()
+    };
+}
+
+// 保存网络设置
+async function saveNetworkSettings() {
+    try {
+        await window.go.main.App.SaveNetworkSettings(collectNetworkSettings());
+        showSystemMessage('网络设置已保存');
+    } catch (error) {
+        showSystemMessage('保存网络设置失败: ' + (error.message || error));
+    }
+}
+
+// 使用尚未保存的网络设置测试连接
+async function testNetworkConnection() {
+    const resultDiv = document.getElementById('network-test-result');
+    resultDiv.style.display = 'block';
+    resultDiv.className = 'test-connection-result';
+    resultDiv.textContent = '正在测试...';
+    
+    const target = document.getElementById('network-test-url').value.trim();
+    const result = await window.go.main.App.TestNetworkConnection(collectNetworkSettings(), target);
+    
+    resultDiv.classList.add(result.success ? 'success' : 'error');
+    let text = result.message;
+    if (result.proxy) {
+        text += '（代理: ' + result.proxy + '）';
+    }
+    resultDiv.textContent = text;
+}
+
+// 数据库类型的显示名称
+const databaseEngineLabels = {
+    postgres: 'PostgreSQL',
+    mysql: 'MySQL',
+    sqlite: 'SQLite',
+    sqlserver: 'SQL Server'
+};
+
+// 初始化数据库服务器查询面板，只有用户点击查询时才会连接数据库
+function initSQLIntrospectionPanel() {
+    const toggleBtn = document.getElementById('toggle-sql-introspection-btn');
+    const panel = document.getElementById('sql-introspection-panel');
+    
+    if (!toggleBtn || !panel) {
+        return;
+    }
+    
+    toggleBtn.addEventListener('click', () => {
+        const visible = panel.style.display !== 'none';
+        panel.style.display = visible ? 'none' : 'block';
+        if (!visible) {
+            loadSQLIntrospectionProfiles();
+        }
+    });
+    document.getElementById('introspect-sql-btn').addEventListener('click', () => {
+        const name = document.getElementById('sql-introspection-profile').value;
+        if (name) {
+            introspectDatabaseServer(name);
+        }
+    });
+}
+
+// 列出可以查询的数据库连接
+async function loadSQLIntrospectionProfiles() {
+    const select = document.getElementById('sql-introspection-profile');
+    const profiles = await window.go.main.App.GetDatabaseProfiles();
+    
+    select.innerHTML = '';
+    profiles.forEach(profile => {
+        const option = document.createElement('option');
+        option.value = profile.name;
+        option.textContent = `${profile.name} (${databaseEngineLabels[profile.engine] || profile.engine})`;
+        select.appendChild(option);
+    });
+    document.getElementById('introspect-sql-btn').disabled = profiles.length === 0;
+}
+
+// 用户确认后以只读方式查询服务器版本和扩展/插件，语言检测不会自动连接数据库
+async function introspectDatabaseServer(name) {
+    if (!confirm(`将使用连接 ${name} 登录数据库服务器，只执行只读的目录查询以获取版本和扩展/插件列表。是否继续？`)) {
+        return;
+    }
+    
+    const resultDiv = document.getElementById('sql-introspection-result');
+    resultDiv.style.display = 'block';
+    resultDiv.className = 'test-connection-result';
+    resultDiv.textContent = `正在查询 ${name}...`;
+    
+    const result = await window.go.main.App.IntrospectSQLServer(name);
+    resultDiv.classList.add(result.success ? 'success' : 'error');
+    resultDiv.textContent = result.success ?
+        `${name}: ${databaseEngineLabels[result.engine] || result.engine} ${result.serverVersion}` :
+        `${name}: ${result.error}`;
+    
+    const extensions = result.extensions || [];
+    if (extensions.length > 0) {
+        const details = document.createElement('div');
+        details.className = 'sql-introspection-details';
+        details.textContent = extensions.map(ext => {
+            let line = ext.version ? `${ext.name} ${ext.version}` : ext.name;
+            if (!ext.installed) {
+                line += '（未启用）';
+            } else if (ext.description) {
+                line += `（${ext.description}）`;
+            }
+            return line;
+        }).join('\n');
+        resultDiv.appendChild(details);
+    }
+}
+
+// 正在编辑的连接配置，新建时为空
+let editingDatabaseProfile = null;
+
+// 初始化数据库连接面板
+function initDatabaseProfilesPanel() {
+    const toggleBtn = document.getElementById('toggle-db-profiles-btn');
+    const panel = document.getElementById('db-profiles-panel');
+    
+    if (!toggleBtn || !panel) {
+        return;
+    }
+    
+    toggleBtn.addEventListener('click', () => {
+        const visible = panel.style.display !== 'none';
+        panel.style.display = visible ? 'none' : 'block';
+        if (!visible) {
+            loadDatabaseProfiles();
+        }
+    });
+    document.getElementById('db-profile-engine').addEventListener('change', updateDatabaseProfileFields);
+    document.getElementById('save-db-profile-btn').addEventListener('click', saveDatabaseProfile);
+    document.getElementById('new-db-profile-btn').addEventListener('click', () => editDatabaseProfile(null));
+    editDatabaseProfile(null);
+}
+
+// SQLite只需要文件路径，其他数据库需要服务器信息
+function updateDatabaseProfileFields() {
+    const sqlite = document.getElementById('db-profile-engine').value === 'sqlite';
+    document.querySelectorAll('.db-profile-server-field').forEach(field => {
+        field.style.display = sqlite ? 'none' : '';
+    });
+    document.querySelectorAll('.db-profile-file-field').forEach(field => {
+        field.style.display = sqlite ? '' : 'none';
+    });
+}
+
+// 加载已保存的连接配置
+async function loadDatabaseProfiles() {
+    const list = document.getElementById('db-profiles-list');
+    const profiles = await window.go.main.App.GetDatabaseProfiles();
+    // 服务器查询面板的连接列表同步更新
+    loadSQLIntrospectionProfiles();
+    
+    list.innerHTML = '';
+    if (profiles.length === 0) {
+        list.textContent = '还没有保存数据库连接';
+        return;
+    }
+    
+    profiles.forEach(profile => {
+        const item = document.createElement('div');
+        item.className = 'db-profile-entry';
+        
+        const text = document.createElement('span');
+        const target = profile.engine === 'sqlite' ? profile.filePath :
+            `${profile.user ? profile.user + '@' : ''}${profile.host || 'localhost'}${profile.port ? ':' + profile.port : ''}${profile.database ? '/' + profile.database : ''}`;
+        text.textContent = `${profile.name}  (${databaseEngineLabels[profile.engine] || profile.engine})  ${target}`;
+        item.appendChild(text);
+        
+        const actions = document.createElement('span');
+        [
+            ['测试', () => testDatabaseProfile(profile.name)],
+            ['编辑', () => editDatabaseProfile(profile)],
+            ['删除', () => deleteDatabaseProfile(profile.name)]
+        ].forEach(([label, handler]) => {
+            const btn = document.createElement('button');
+            btn.className = 'secondary-btn';
+            btn.textContent = label;
+            btn.addEventListener('click', handler);
+            actions.appendChild(btn);
+        });
+        item.appendChild(actions);
+        list.appendChild(item);
+    });
+}
+
+// 把连接配置填入编辑表单，profile 为空时清空表单用于新建
+function editDatabaseProfile(profile) {
+    editingDatabaseProfile = profile;
+    document.getElementById('db-profile-name').value = profile ? profile.name : '';
+    document.getElementById('db-profile-engine').value = profile ? profile.engine : 'postgres';
+    document.getElementById('db-profile-host').value = profile ? profile.host : '';
+    document.getElementById('db-profile-port').value = profile && profile.port ? profile.port : '';
+    document.getElementById('db-profile-user').value = profile ? profile.user : '';
+    document.getElementById('db-profile-database').value = profile ? profile.database : '';
+    document.getElementById('db-profile-file').value = profile ? profile.filePath : '';
+    
+    // 已保存的密码不会返回前端，留空表示保留
+    const password = document.getElementById('db-profile-password');
+    password.value = '';
+    password.placeholder = profile && profile.hasPassword ? '已保存，留空保留原密码' : '密码加密保存在本机';
+    document.getElementById('db-profile-clear-password').checked = false;
+    document.getElementById('db-profile-clear-password').parentElement.style.display = profile && profile.hasPassword ? '' : 'none';
+    
+    updateDatabaseProfileFields();
+}
+
+// 保存编辑中的连接配置
+async function saveDatabaseProfile() {
+    const name = document.getElementById('db-profile-name').value.trim();
+    const password = document.getElementById('db-profile-password').value;
+    const keepPassword = editingDatabaseProfile !== null && editingDatabaseProfile.name === name &&
+        editingDatabaseProfile.hasPassword && !document.getElementById('db-profile-clear-password').checked;
+    
+    const profile = {
+        name: name,
+        engine: document.getElementById('db-profile-engine').value,
+        host: document.getElementById('db-profile-host').value.trim(),
+        port: parseInt(document.getElementById('db-profile-port').value, 10) || 0,
+        user: document.getElementById('db-profile-user').value.trim(),
+        password: password,
+        database: document.getElementById('db-profile-database').value.trim(),
+        filePath: document.getElementById('db-profile-file').value.trim(),
+        hasPassword: password !== '' || keepPassword
+    };
+    
+    try {
+        await window.go.main.App.SaveDatabaseProfile(profile);
+        showSystemMessage('数据库连接已保存: ' + profile.name);
+        editDatabaseProfile(null);
+        loadDatabaseProfiles();
+    } catch (error) {
+        showSystemMessage('保存数据库连接失败: ' + (error.message || error));
+    }
+}
+
+// 删除连接配置
+async function deleteDatabaseProfile(name) {
+    if (!confirm(`确定删除数据库连接 ${name} 吗？`)) {
+        return;
+    }
+    try {
+        await window.go.main.App.DeleteDatabaseProfile(name);
+        if (editingDatabaseProfile && editingDatabaseProfile.name === name) {
+            editDatabaseProfile(null);
+        }
+        loadDatabaseProfiles();
+    } catch (error) {
+        showSystemMessage('删除数据库连接失败: ' + (error.message || error));
+    }
+}
+
+// 测试连接并显示服务器版本、延迟、TLS状态、扩展和数据库列表
+async function testDatabaseProfile(name) {
+    const resultDiv = document.getElementById('db-test-result');
+    resultDiv.style.display = 'block';
+    resultDiv.className = 'test-connection-result';
+    resultDiv.textContent = `正在测试 ${name}...`;
+    
+    const result = await window.go.main.App.TestDatabaseConnection(name);
+    resultDiv.classList.add(result.connected ? 'success' : 'error');
+    if (!result.connected) {
+        resultDiv.textContent = `${name}: ${result.error}`;
         return;
     }
     
-    const selectedProvider = providerSelect.value;
-    const apiKey = apiKeyInput.value.trim();
-    const customEndpoint = customEndpointInput ? customEndpointInput.value.trim() : '';
+    resultDiv.textContent = `${name}: 连接成功，版本 ${result.serverVersion || '未知'}，延迟 ${result.latencyMs} ms，TLS ${result.tls}`;
     
-    if (!apiKey) {
-        showSystemMessage('请输入API密钥');
+    const details = document.createElement('div');
+    details.className = 'db-test-details';
+    const lines = [];
+    if (result.databases && result.databases.length > 0) {
+        lines.push('数据库: ' + result.databases.join(', '));
+    }
+    if (result.extensions && result.extensions.length > 0) {
+        lines.push('扩展/插件: ' + result.extensions.map(ext => ext.version ? `${ext.name} ${ext.version}` : ext.name).join(', '));
+    }
+    if (result.error) {
+        lines.push('部分信息获取失败: ' + result.error);
+    }
+    details.textContent = lines.join('\n');
+    resultDiv.appendChild(details);
+}
+
+// 显示密钥的加密方式和解锁状态
+function updateAIKeyProtection(config) {
+    const status = document.getElementById('ai-key-protection-status');
+    if (!status) {
+        return;
+    }
+    
+    let text = '密钥使用本机密钥文件加密';
+    if (config.passphraseProtected) {
+        text = config.keyLocked ? '密钥使用口令加密，尚未解锁' : '密钥使用口令加密，已解锁';
+    }
+    status.textContent = text;
+    document.getElementById('unlock-ai-keys-btn').style.display = config.keyLocked ? '' : 'none';
+    document.getElementById('clear-ai-passphrase-btn').style.display = config.passphraseProtected && !config.keyLocked ? '' : 'none';
+    document.getElementById('set-ai-passphrase-btn').style.display = config.keyLocked ? 'none' : '';
+    document.getElementById('ai-passphrase-confirm').style.display = config.keyLocked ? 'none' : '';
+}
+
+// 清空口令输入框
+function clearAIPassphraseInputs() {
+    document.getElementById('ai-passphrase').value = '';
+    document.getElementById('ai-passphrase-confirm').value = '';
+}
+
+// 输入口令解锁密钥，然后重新加载配置
+async function unlockAIKeys() {
+    const passphrase = document.getElementById('ai-passphrase').value;
+    if (!passphrase) {
+        showSystemMessage('请输入口令');
+        return;
+    }
+    try {
+        await window.go.main.App.UnlockAIKeys(passphrase);
+        clearAIPassphraseInputs();
+        showSystemMessage('AI密钥已解锁');
+        await initAIAssistant();
+    } catch (error) {
+        showSystemMessage('解锁失败: ' + (error.message || error));
+    }
+}
+
+// 使用口令重新加密已保存的密钥
+async function setAIKeyPassphrase() {
+    const passphrase = document.getElementById('ai-passphrase').value;
+    if (!passphrase) {
+        showSystemMessage('请输入口令');
+        return;
+    }
+    if (passphrase !== document.getElementById('ai-passphrase-confirm').value) {
+        showSystemMessage('两次输入的口令不一致');
+        return;
+    }
+    if (!confirm('设置口令后，每次启动应用都需要输入口令才能使用AI密钥。忘记口令只能重新输入密钥。是否继续？')) {
+        return;
+    }
+    try {
+        await window.go.main.App.SetAIKeyPassphrase(passphrase);
+        clearAIPassphraseInputs();
+        showSystemMessage('已使用口令加密AI密钥');
+        updateAIKeyProtection(await window.go.main.App.GetAIConfig());
+    } catch (error) {
+        showSystemMessage('设置口令失败: ' + (error.message || error));
+    }
+}
+
+// 取消口令，改用本机密钥文件加密
+async function clearAIKeyPassphrase() {
+    if (!confirm('取消口令后，AI密钥改为使用本机密钥文件加密。是否继续？')) {
         return;
     }
+    try {
+        await window.go.main.App.SetAIKeyPassphrase('');
+        clearAIPassphraseInputs();
+        showSystemMessage('AI密钥已改用本机密钥加密');
+        updateAIKeyProtection(await window.go.main.App.GetAIConfig());
+    } catch (error) {
+        showSystemMessage('取消口令失败: ' + (error.message || error));
+    }
+}
+
+// 初始化脱敏设置面板
+function initRedactionPanel() {
+    const toggleBtn = document.getElementById('toggle-redaction-btn');
+    const panel = document.getElementById('redaction-panel');
+    const saveBtn = document.getElementById('save-redaction-btn');
     
-    if ((selectedProvider === 'custom' || selectedProvider === 'azure_openai') && !customEndpoint) {
-        showSystemMessage('请输入自定义端点URL');
+    if (!toggleBtn || !panel) {
         return;
     }
     
+    toggleBtn.addEventListener('click', () => {
+        const visible = panel.style.display !== 'none';
+        panel.style.display = visible ? 'none' : 'block';
+        if (!visible) {
+            loadRedactionConfig();
+        }
+    });
+    if (saveBtn) {
+        saveBtn.addEventListener('click', saveRedactionConfig);
+    }
+}
+
+// 加载脱敏设置
+async function loadRedactionConfig() {
+    const config = await window.go.main.App.GetRedactionConfig();
+    document.getElementById('redaction-enabled-toggle').checked = !!config.enabled;
+    document.getElementById('redaction-patterns').value = JSON.stringify(config.customPatterns || [], null, 2);
+    
+    const rules = document.getElementById('redaction-rules');
+    rules.innerHTML = '';
+    (config.builtinRules || []).forEach(rule => {
+        const label = document.createElement('label');
+        const checkbox = document.createElement('input');
+        checkbox.type = 'checkbox';
+        checkbox.dataset.rule = rule.id;
+        checkbox.checked = !(config.disabledRules || []).includes(rule.id);
+        label.append(checkbox, ' ' + rule.name);
+        rules.appendChild(label);
+    });
+}
+
+// 保存脱敏设置
+async function saveRedactionConfig() {
     try {
-        // 保存配置
+        const disabledRules = [];
+        document.querySelectorAll('#redaction-rules input[data-rule]').forEach(checkbox => {
+            if (!checkbox.checked) {
+                disabledRules.push(checkbox.dataset.rule);
+            }
+        });
         const config = {
-            selectedProviderId: selectedProvider,
-            apiKey: apiKey,
-            customEndpoint: customEndpoint
+            enabled: document.getElementById('redaction-enabled-toggle').checked,
+            disabledRules: disabledRules,
+            customPatterns: JSON.parse(document.getElementById('redaction-patterns').value || '[]')
+        };
+        await window.go.main.App.SaveRedactionConfig(config);
+        pendingRedactionText = null;
+        showSystemMessage('脱敏设置已保存');
+    } catch (error) {
+        showSystemMessage('保存脱敏设置失败: ' + (error.message || error));
+    }
+}
+
+// 注册文件中的自定义提供商
+let userProviders = [];
+
+// 初始化自定义提供商面板
+function initUserProvidersPanel() {
+    const toggleBtn = document.getElementById('toggle-user-providers-btn');
+    const panel = document.getElementById('user-providers-panel');
+    const select = document.getElementById('user-provider-select');
+    
+    if (!toggleBtn || !panel || !select) {
+        return;
+    }
+    
+    toggleBtn.addEventListener('click', () => {
+        const visible = panel.style.display !== 'none';
+        panel.style.display = visible ? 'none' : 'block';
+        if (!visible) {
+            loadUserProviders();
+        }
+    });
+    select.addEventListener('change', () => fillUserProviderForm(select.value));
+    document.getElementById('save-user-provider-btn').addEventListener('click', saveUserProvider);
+    document.getElementById('delete-user-provider-btn').addEventListener('click', deleteUserProvider);
+}
+
+// 加载自定义提供商列表
+async function loadUserProviders(selectedId) {
+    const select = document.getElementById('user-provider-select');
+    try {
+        userProviders = await window.go.main.App.GetUserAIProviders();
+    } catch (error) {
+        userProviders = [];
+        showSystemMessage('读取提供商注册文件失败: ' + error);
+    }
+    
+    select.innerHTML = '<option value="">新建提供商</option>';
+    userProviders.forEach(provider => {
+        const option = document.createElement('option');
+        option.value = provider.id;
+        option.textContent = provider.name || provider.id;
+        select.appendChild(option);
+    });
+    select.value = userProviders.some(p => p.id === selectedId) ? selectedId : '';
+    fillUserProviderForm(select.value);
+}
+
+// 将选中的自定义提供商填入表单
+function fillUserProviderForm(id) {
+    const provider = userProviders.find(p => p.id === id) || {};
+    const idInput = document.getElementById('user-provider-id');
+    idInput.value = provider.id || '';
+    idInput.disabled = !!provider.id;
+    document.getElementById('user-provider-name').value = provider.name || '';
+    document.getElementById('user-provider-format').value = provider.format || 'openai';
+    document.getElementById('user-provider-endpoint').value = provider.endpointUrl || '';
+    document.getElementById('user-provider-models').value = (provider.models || []).join(', ');
+    document.getElementById('user-provider-context').value = provider.contextWindow || '';
+    document.getElementById('user-provider-key').value = '';
+    document.getElementById('user-provider-headers').value = provider.headers ? JSON.stringify(provider.headers, null, 2) : '';
+}
+
+// 保存自定义提供商
+async function saveUserProvider() {
+    try {
+        const headersText = document.getElementById('user-provider-headers').value.trim();
+        const models = document.getElementById('user-provider-models').value
+            .split(',')
+            .map(model => model.trim())
+            .filter(model => model);
+        const id = document.getElementById('user-provider-id').value.trim();
+        const previous = userProviders.find(p => p.id === id) || {};
+        const provider = {
+            ...previous,
+            id: id,
+            name: document.getElementById('user-provider-name').value.trim(),
+            format: document.getElementById('user-provider-format').value,
+            endpointUrl: document.getElementById('user-provider-endpoint').value.trim(),
+            models: models,
+            defaultModel: models.includes(previous.defaultModel) ? previous.defaultModel : (models[0] || ''),
+            contextWindow: parseInt(document.getElementById('user-provider-context').value, 10) || 0,
+            headers: headersText ? JSON.parse(headersText) : {},
+            apiKey: document.getElementById('user-provider-key').value.trim()
         };
         
-        await window.go.main.App.SaveAIConfig(config);
-        showSystemMessage('配置已保存');
+        await window.go.main.App.SaveUserAIProvider(provider);
+        showSystemMessage('提供商已保存');
+        await refreshAIProviderOptions();
+        updateProviderUI();
+        loadUserProviders(id);
     } catch (error) {
-        console.error('保存AI配置失败:', error);
-        showSystemMessage('保存AI配置失败: ' + error.message);
+        showSystemMessage('保存提供商失败: ' + (error.message || error));
     }
 }
 
-// 发送AI消息
-async function sendAIMessage() {
-    const userInput = document.getElementById('user-input');
-    const chatMessages = document.getElementById('chat-messages');
+// 删除自定义提供商
+async function deleteUserProvider() {
+    const select = document.getElementById('user-provider-select');
+    if (!select.value || !confirm('确定删除该提供商及其保存的密钥吗？')) {
+        return;
+    }
+    try {
+        await window.go.main.App.DeleteUserAIProvider(select.value);
+        showSystemMessage('提供商已删除');
+        await refreshAIProviderOptions();
+        updateProviderUI();
+        loadUserProviders();
+    } catch (error) {
+        showSystemMessage('删除提供商失败: ' + error);
+    }
+}
+
+// 已检测到的语言名称，用于筛选提示词模板
+let detectedLanguageNames = [];
+let promptTemplates = [];
+
+// 初始化提示词模板栏
+function initPromptTemplateBar() {
+    const languageFilter = document.getElementById('prompt-language-filter');
+    const templateSelect = document.getElementById('prompt-template-select');
+    const applyBtn = document.getElementById('apply-template-btn');
     
-    if (!userInput || !chatMessages) {
+    if (!languageFilter || !templateSelect) {
         return;
     }
     
-    const query = userInput.value.trim();
+    languageFilter.addEventListener('change', refreshPromptTemplates);
+    templateSelect.addEventListener('change', renderPromptParams);
+    if (applyBtn) {
+        applyBtn.addEventListener('click', applyPromptTemplate);
+    }
     
-    if (!query) {
+    refreshPromptTemplates();
+}
+
+// 用检测结果更新语言筛选下拉框
+function refreshPromptLanguageFilter() {
+    const languageFilter = document.getElementById('prompt-language-filter');
+    if (!languageFilter) {
         return;
     }
     
-    // 获取配置
-    const config = await window.go.main.App.GetAIConfig();
+    const selected = languageFilter.value;
+    languageFilter.innerHTML = '<option value="">所有语言</option>';
+    detectedLanguageNames.forEach(name => {
+        const option = document.createElement('option');
+        option.value = name;
+        option.textContent = name;
+        languageFilter.appendChild(option);
+    });
+    languageFilter.value = detectedLanguageNames.includes(selected) ? selected : '';
+}
+
+// 加载适用于所选语言的模板
+async function refreshPromptTemplates() {
+    const languageFilter = document.getElementById('prompt-language-filter');
+    const templateSelect = document.getElementById('prompt-template-select');
     
-    if (!config.apiKey) {
-        showSystemMessage('请先设置API密钥');
+    try {
+        promptTemplates = await window.go.main.App.GetPromptTemplates(languageFilter ? languageFilter.value : '');
+    } catch (error) {
+        console.error('加载提示词模板失败:', error);
+        promptTemplates = [];
+    }
+    
+    templateSelect.innerHTML = '<option value="">选择模板...</option>';
+    promptTemplates.forEach(template => {
+        const option = document.createElement('option');
+        option.value = template.id;
+        option.textContent = template.name;
+        option.title = template.description || '';
+        templateSelect.appendChild(option);
+    });
+    renderPromptParams();
+}
+
+// 显示所选模板的参数输入框
+function renderPromptParams() {
+    const templateSelect = document.getElementById('prompt-template-select');
+    const languageFilter = document.getElementById('prompt-language-filter');
+    const container = document.getElementById('prompt-template-params');
+    if (!templateSelect || !container) {
         return;
     }
     
-    // 添加用户消息
-    addMessage('user', query);
+    container.innerHTML = '';
+    const template = promptTemplates.find(t => t.id === templateSelect.value);
+    if (!template) {
+        return;
+    }
     
-    // 清空输入框
-    userInput.value = '';
+    (template.parameters || []).forEach(param => {
+        const input = document.createElement(param.multiline ? 'textarea' : 'input');
+        input.dataset.param = param.name;
+        input.placeholder = (param.label || param.name) + (param.optional ? '（可选）' : '');
+        if (param.multiline) {
+            input.rows = 3;
+        }
+        // 语言参数默认使用筛选中的语言
+        input.value = param.name === 'language' && languageFilter && languageFilter.value ? languageFilter.value : (param.default || '');
+        container.appendChild(input);
+    });
+}
+
+// 用参数填充模板并放入输入框，用户确认后再发送
+async function applyPromptTemplate() {
+    const templateSelect = document.getElementById('prompt-template-select');
+    const container = document.getElementById('prompt-template-params');
+    const userInput = document.getElementById('user-input');
+    if (!templateSelect || !templateSelect.value || !userInput) {
+        return;
+    }
     
-    // 显示加载中
-    const loadingId = showLoadingMessage();
+    const values = {};
+    container.querySelectorAll('[data-param]').forEach(input => {
+        values[input.dataset.param] = input.value;
+    });
     
     try {
-        // 发送请求
-        const response = await window.go.main.App.QueryAI(
-            config.selectedProviderId,
-            config.apiKey,
-            config.customEndpoint,
-            query
-        );
-        
-        // 移除加载中
-        removeLoadingMessage(loadingId);
-        
-        if (response.success) {
-            // 添加AI响应
-            addMessage('ai', response.content, response.provider);
-        } else {
-            // 显示错误
-            showSystemMessage('请求失败: ' + response.error);
+        userInput.value = await window.go.main.App.RenderPromptTemplate(templateSelect.value, values);
+        userInput.focus();
+    } catch (error) {
+        showSystemMessage('填充模板失败: ' + error);
+    }
+}
+
+// 当前会话
+let currentConversationId = null;
+
+// 初始化会话栏
+function initConversationBar() {
+    const select = document.getElementById('conversation-select');
+    const search = document.getElementById('conversation-search');
+    
+    if (!select) {
+        return;
+    }
+    
+    select.addEventListener('change', () => loadConversation(select.value));
+    
+    // 切换当前会话使用的提供商
+    const conversationProvider = document.getElementById('conversation-provider');
+    if (conversationProvider) {
+        conversationProvider.addEventListener('change', async () => {
+            if (!currentConversationId || !conversationProvider.value) {
+                return;
+            }
+            try {
+                await window.go.main.App.SetConversationProvider(currentConversationId, conversationProvider.value);
+            } catch (error) {
+                showSystemMessage('切换提供商失败: ' + error);
+            }
+        });
+    }
+    
+    if (search) {
+        search.addEventListener('input', () => refreshConversationList());
+    }
+    
+    document.getElementById('new-conversation-btn').addEventListener('click', () => {
+        currentConversationId = null;
+        setConversationProvider('');
+        clearChatMessages();
+        refreshConversationList();
+    });
+    
+    document.getElementById('rename-conversation-btn').addEventListener('click', async () => {
+        if (!currentConversationId) {
+            return;
+        }
+        const title = prompt('新的会话标题:');
+        if (title) {
+            try {
+                await window.go.main.App.RenameConversation(currentConversationId, title);
+                refreshConversationList();
+            } catch (error) {
+                showSystemMessage('重命名失败: ' + error);
+            }
+        }
+    });
+    
+    document.getElementById('export-conversation-btn').addEventListener('click', async () => {
+        if (!currentConversationId) {
+            return;
+        }
+        try {
+            const path = await window.go.main.App.ExportConversationMarkdown(currentConversationId);
+            if (path) {
+                showSystemMessage('会话已导出到 ' + path);
+            }
+        } catch (error) {
+            showSystemMessage('导出失败: ' + error);
+        }
+    });
+    
+    document.getElementById('delete-conversation-btn').addEventListener('click', async () => {
+        if (!currentConversationId || !confirm('确定删除当前会话吗？')) {
+            return;
+        }
+        try {
+            await window.go.main.App.DeleteConversation(currentConversationId);
+            currentConversationId = null;
+            clearChatMessages();
+            refreshConversationList();
+        } catch (error) {
+            showSystemMessage('删除失败: ' + error);
         }
+    });
+    
+    refreshConversationList();
+}
+
+// 刷新会话列表
+async function refreshConversationList() {
+    const select = document.getElementById('conversation-select');
+    const search = document.getElementById('conversation-search');
+    
+    if (!select) {
+        return;
+    }
+    
+    const keyword = search ? search.value.trim() : '';
+    const conversations = await window.go.main.App.SearchConversations(keyword);
+    
+    select.innerHTML = '<option value="">新对话</option>';
+    conversations.forEach(conversation => {
+        const option = document.createElement('option');
+        option.value = conversation.id;
+        option.textContent = conversation.title;
+        select.appendChild(option);
+    });
+    select.value = currentConversationId || '';
+}
+
+// 加载会话历史
+async function loadConversation(id) {
+    currentConversationId = id || null;
+    clearChatMessages();
+    
+    if (!id) {
+        return;
+    }
+    
+    try {
+        const conversation = await window.go.main.App.GetConversation(id);
+        setConversationProvider(conversation.providerId);
+        conversation.messages.forEach(message => {
+            if (message.role === 'tool') {
+                showToolCall({
+                    callId: '',
+                    name: message.toolName,
+                    arguments: message.toolArguments,
+                    status: 'done',
+                    result: message.content
+                });
+                return;
+            }
+            addMessage(message.role === 'assistant' ? 'ai' : 'user', message.content, message.provider);
+        });
     } catch (error) {
-        // 移除加载中
-        removeLoadingMessage(loadingId);
-        
-        console.error('AI请求失败:', error);
-        showSystemMessage('AI请求失败: ' + error.message);
+        showSystemMessage('加载会话失败: ' + error);
+    }
+}
+
+// 在会话栏中显示会话使用的提供商
+function setConversationProvider(providerId) {
+    const conversationProvider = document.getElementById('conversation-provider');
+    if (conversationProvider) {
+        conversationProvider.value = aiProviders.some(p => p.id === providerId) ? providerId : '';
+    }
+}
+
+// 清空聊天窗口
+function clearChatMessages() {
+    const chatMessages = document.getElementById('chat-messages');
+    if (chatMessages) {
+        chatMessages.innerHTML = '';
+    }
+}
+
+// 流式请求状态
+const aiStreams = {};
+let currentAIRequestId = null;
+
+// 切换发送/停止按钮
+function setStreamingUI(streaming) {
+    const sendBtn = document.getElementById('send-btn');
+    const stopBtn = document.getElementById('stop-btn');
+    if (sendBtn) {
+        sendBtn.disabled = streaming;
+    }
+    if (stopBtn) {
+        stopBtn.style.display = streaming ? 'inline-block' : 'none';
     }
 }
 
@@ -2703,6 +5032,90 @@ function addMessage(type, content, provider = '') {
     chatMessages.scrollTop = chatMessages.scrollHeight;
 }
 
+// 显示或更新工具调用消息，需要确认的工具显示确认和拒绝按钮
+function showToolCall(event) {
+    const chatMessages = document.getElementById('chat-messages');
+    if (!chatMessages) {
+        return;
+    }
+    
+    let messageDiv = event.callId ? document.getElementById('tool-call-' + event.callId) : null;
+    if (!messageDiv) {
+        messageDiv = document.createElement('div');
+        messageDiv.className = 'message tool';
+        if (event.callId) {
+            messageDiv.id = 'tool-call-' + event.callId;
+        }
+        // 流式回复的占位消息保持在最后
+        const stream = aiStreams[event.requestId];
+        if (stream && stream.element.parentNode && stream.element.parentNode.parentNode === chatMessages) {
+            chatMessages.insertBefore(messageDiv, stream.element.parentNode);
+        } else {
+            chatMessages.appendChild(messageDiv);
+        }
+    }
+    
+    const statusText = {
+        confirm: '等待确认',
+        running: '执行中…',
+        done: '已完成',
+        rejected: '已拒绝',
+        error: '失败'
+    };
+    
+    messageDiv.innerHTML = '';
+    const contentDiv = document.createElement('div');
+    contentDiv.className = 'message-content';
+    
+    const title = document.createElement('div');
+    title.className = 'tool-call-title';
+    title.textContent = `调用工具 ${event.name}（${statusText[event.status] || event.status}）`;
+    contentDiv.appendChild(title);
+    
+    const args = document.createElement('code');
+    args.textContent = event.arguments || '{}';
+    contentDiv.appendChild(args);
+    
+    // 修改系统的工具显示将要执行的命令，执行过程和输出在包操作任务列表中
+    if (event.command) {
+        const command = document.createElement('div');
+        command.className = 'tool-call-command';
+        const prefix = { confirm: '将执行', rejected: '未执行' }[event.status] || '已加入包操作任务';
+        command.textContent = `${prefix}: ${event.command}`;
+        contentDiv.appendChild(command);
+    }
+    
+    if (event.result) {
+        const details = document.createElement('details');
+        const summary = document.createElement('summary');
+        summary.textContent = '查看结果';
+        const pre = document.createElement('pre');
+        pre.textContent = event.result;
+        details.appendChild(summary);
+        details.appendChild(pre);
+        contentDiv.appendChild(details);
+    }
+    
+    if (event.status === 'confirm') {
+        const actions = document.createElement('div');
+        actions.className = 'tool-call-actions';
+        const approveBtn = document.createElement('button');
+        approveBtn.className = 'primary-btn';
+        approveBtn.textContent = '允许执行';
+        approveBtn.addEventListener('click', () => window.go.main.App.ConfirmAIToolCall(event.callId, true));
+        const rejectBtn = document.createElement('button');
+        rejectBtn.className = 'secondary-btn';
+        rejectBtn.textContent = '拒绝';
+        rejectBtn.addEventListener('click', () => window.go.main.App.ConfirmAIToolCall(event.callId, false));
+        actions.appendChild(approveBtn);
+        actions.appendChild(rejectBtn);
+        contentDiv.appendChild(actions);
+    }
+    
+    messageDiv.appendChild(contentDiv);
+    chatMessages.scrollTop = chatMessages.scrollHeight;
+}
+
 // 显示系统消息
 function showSystemMessage(message) {
     addMessage('system', message);
@@ -6,6 +6,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
 )
 
@@ -1346,84 +1347,258 @@ func (a *App) detectHaskell() LanguageInfo {
 		},
 	}
 
-	if !commandExists("ghc") {
+	if !commandExists("ghc") && !commandExists("ghcup") {
 		return info
 	}
 
-	output, err := executeCommandWithTimeout("ghc", "--version")
-	if err == nil {
-		info.Installed = true
-		info.Version = output
+	// ghcup管理的工具链（ghc、cabal、stack、hls）
+	toolchain := a.listGhcupToolchain()
+	info.Extensions = toolchain
 
-		// 检测已安装的Haskell包
-		packages, _ := a.listHaskellPackages()
-		info.Packages = packages
+	if commandExists("ghc") {
+		output, err := executeCommandWithTimeout("ghc", "--numeric-version")
+		if err == nil {
+			info.Installed = true
+			info.Version = "GHC " + output
+		}
+	}
+
+	// ghc不在PATH中时，使用ghcup中设置的版本
+	if !info.Installed {
+		for _, tool := range toolchain {
+			if strings.HasPrefix(tool.Name, "ghc ") && hasGhcupMarker(tool.Description, "set") {
+				info.Installed = true
+				info.Version = "GHC " + tool.Version
+				break
+			}
+		}
+	}
+
+	if !info.Installed {
+		return info
+	}
+
+	// 检测stack当前使用的snapshot
+	if snapshot := a.detectStackSnapshot(); snapshot != "" {
+		info.Extensions = append(info.Extensions, PackageInfo{
+			Name:        "stack snapshot",
+			Version:     snapshot,
+			Description: "stack当前使用的snapshot",
+			Installed:   true,
+		})
 	}
 
 	return info
 }
 
-// 列出已安装的Haskell包
-func (a *App) listHaskellPackages() ([]PackageInfo, error) {
-	var packages []PackageInfo
+// listGhcupToolchain 列出ghcup安装的GHC、cabal、stack和HLS版本
+func (a *App) listGhcupToolchain() []PackageInfo {
+	var tools []PackageInfo
 
-	// 尝试使用cabal命令
-	if commandExists("cabal") {
-		output, err := executeCommandWithTimeout("cabal", "list", "--installed", "--simple-output")
-		if err == nil {
-			lines := strings.Split(output, "\n")
-			for _, line := range lines {
-				line = strings.TrimSpace(line)
-				if line == "" {
-					continue
-				}
+	if !commandExists("ghcup") {
+		return tools
+	}
 
-				// cabal输出格式通常是 "package-name version"
-				parts := strings.Split(line, " ")
-				if len(parts) >= 2 {
-					packages = append(packages, PackageInfo{
-						Name:      parts[0],
-						Version:   parts[1],
-						Installed: true,
-					})
+	// -r 输出机器可读格式，-c installed 只列出已安装的版本
+	output, err := executeCommandWithTimeout("ghcup", "list", "-r", "-c", "installed")
+	if err != nil {
+		return tools
+	}
 
-					// 限制数量
-					if len(packages) >= 20 {
-						return packages, nil
-					}
-				}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		// 跳过状态标记，定位工具名
+		idx := -1
+		for i, field := range fields {
+			switch field {
+			case "ghc", "cabal", "stack", "hls", "ghcup":
+				idx = i
+			}
+			if idx >= 0 {
+				break
 			}
 		}
+		if idx < 0 || idx+1 >= len(fields) {
+			continue
+		}
+
+		tool := fields[idx]
+		version := fields[idx+1]
+
+		// 工具名之前是状态列（✔✔ 或 Windows 上的 IS 表示已设置），版本之后是逗号分隔的标签和状态列
+		columns := map[string]bool{}
+		for _, field := range fields[:idx] {
+			if field == "✔✔" || field == "IS" {
+				columns["set"] = true
+			}
+		}
+		for _, field := range fields[idx+2:] {
+			for _, value := range strings.Split(field, ",") {
+				columns[value] = true
+			}
+		}
+
+		// 收集推荐和已设置标记
+		var markers []string
+		for _, marker := range []string{"recommended", "latest", "set"} {
+			if columns[marker] {
+				markers = append(markers, marker)
+			}
+		}
+
+		tools = append(tools, PackageInfo{
+			Name:        tool + " " + version,
+			Version:     version,
+			Description: strings.Join(markers, ", "),
+			Installed:   true,
+		})
 	}
 
-	// 如果cabal没有结果或不存在，尝试使用stack
-	if len(packages) == 0 && commandExists("stack") {
-		output, err := executeCommandWithTimeout("stack", "list-dependencies")
-		if err == nil {
-			lines := strings.Split(output, "\n")
-			for _, line := range lines {
-				line = strings.TrimSpace(line)
-				if line == "" || strings.HasPrefix(line, "base ") {
-					continue
-				}
+	return tools
+}
 
-				// stack输出格式通常是 "package-name version"
-				parts := strings.Split(line, " ")
-				if len(parts) >= 2 {
-					packages = append(packages, PackageInfo{
-						Name:      parts[0],
-						Version:   parts[1],
-						Installed: true,
-					})
+// hasGhcupMarker 判断listGhcupToolchain生成的描述中是否有指定标记
+func hasGhcupMarker(description string, marker string) bool {
+	for _, value := range strings.Split(description, ", ") {
+		if value == marker {
+			return true
+		}
+	}
+	return false
+}
 
-					// 限制数量
-					if len(packages) >= 20 {
-						break
+// ghcupBinDir 获取ghcup安装工具的bin目录
+func ghcupBinDir() string {
+	if commandExists("ghcup") {
+		output, err := executeCommandWithTimeout("ghcup", "whereis", "bindir")
+		if err == nil && strings.TrimSpace(output) != "" {
+			return strings.TrimSpace(output)
+		}
+	}
+
+	// 旧版本ghcup没有 whereis 命令，使用默认安装位置
+	prefix := os.Getenv("GHCUP_INSTALL_BASE_PREFIX")
+	if runtime.GOOS == "windows" {
+		if prefix == "" {
+			prefix = `C:\`
+		}
+		return filepath.Join(prefix, "ghcup", "bin")
+	}
+	if prefix == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		prefix = homeDir
+	}
+	return filepath.Join(prefix, ".ghcup", "bin")
+}
+
+// ghcPkgCommand 获取ghc-pkg命令，不在PATH中时使用ghcup bin目录中当前设置的版本
+func ghcPkgCommand() string {
+	if commandExists("ghc-pkg") {
+		return "ghc-pkg"
+	}
+
+	dir := ghcupBinDir()
+	if dir == "" {
+		return ""
+	}
+	name := "ghc-pkg"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// detectStackSnapshot 检测stack当前使用的snapshot（resolver）
+func (a *App) detectStackSnapshot() string {
+	if !commandExists("stack") {
+		return ""
+	}
+
+	// 优先使用当前目录的项目配置，其次使用全局项目配置
+	candidates := []string{"stack.yaml"}
+
+	stackRoot := os.Getenv("STACK_ROOT")
+	if stackRoot == "" {
+		if runtime.GOOS == "windows" {
+			stackRoot = filepath.Join(os.Getenv("APPDATA"), "stack")
+		} else if homeDir, err := os.UserHomeDir(); err == nil {
+			stackRoot = filepath.Join(homeDir, ".stack")
+		}
+	}
+	if stackRoot != "" {
+		candidates = append(candidates, filepath.Join(stackRoot, "global-project", "stack.yaml"))
+	}
+
+	for _, path := range candidates {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			for _, key := range []string{"snapshot:", "resolver:"} {
+				if strings.HasPrefix(line, key) {
+					value := strings.TrimSpace(strings.TrimPrefix(line, key))
+					if idx := strings.Index(value, "#"); idx >= 0 {
+						value = strings.TrimSpace(value[:idx])
+					}
+					if value != "" {
+						return value
 					}
 				}
 			}
 		}
 	}
 
+	return ""
+}
+
+// 列出已安装的Haskell包
+func (a *App) listHaskellPackages() ([]PackageInfo, error) {
+	var packages []PackageInfo
+
+	ghcPkg := ghcPkgCommand()
+	if ghcPkg == "" {
+		return packages, nil
+	}
+
+	// 列出全局和用户包数据库中的所有包
+	output, err := executeCommandWithTimeout(ghcPkg, "list", "--global", "--user", "--simple-output")
+	if err != nil {
+		return packages, err
+	}
+
+	seen := make(map[string]bool)
+	for _, field := range strings.Fields(output) {
+		// 输出格式为 "name-version"，版本号在最后一个连字符之后
+		idx := strings.LastIndex(field, "-")
+		if idx <= 0 || idx == len(field)-1 {
+			continue
+		}
+
+		if seen[field] {
+			continue
+		}
+		seen[field] = true
+
+		packages = append(packages, PackageInfo{
+			Name:      field[:idx],
+			Version:   field[idx+1:],
+			Installed: true,
+		})
+	}
+
 	return packages, nil
 }
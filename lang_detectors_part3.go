@@ -1886,37 +1886,41 @@ func (a *App) detectSQL() LanguageInfo {
 		},
 	}
 
-	// 检查各种SQL数据库是否安装
+	// 检查各种SQL数据库客户端和本地服务端是否安装（只运行本地命令，不连接服务器）
+	// args 为空的只在PATH中查找，不执行
 	dbClients := []struct {
 		name    string
 		command string
 		args    []string
 	}{
 		{"MySQL", "mysql", []string{"--version"}},
+		{"MariaDB", "mariadb", []string{"--version"}},
 		{"PostgreSQL", "psql", []string{"--version"}},
 		{"SQLite", "sqlite3", []string{"--version"}},
-		{"SQL Server", "sqlcmd", []string{"-?"}},
+		// sqlcmd 没有输出版本号的参数，只检查是否存在
+		{"SQL Server", "sqlcmd", []string{}},
+		{"MySQL Server", "mysqld", []string{"--version"}},
+		{"PostgreSQL Server", "postgres", []string{"--version"}},
 	}
 
 	for _, client := range dbClients {
-		if commandExists(client.command) {
-			info.Installed = true
-			output, err := executeCommandWithTimeout(client.command, client.args...)
-			if err == nil {
-				// 如果至少有一个客户端安装，则标记为已安装
-				if info.Version == "" {
-					info.Version = client.name + ": " + output
-				} else {
-					info.Version += ", " + client.name
-				}
-			} else {
-				if info.Version == "" {
-					info.Version = client.name + " (已安装)"
-				} else {
-					info.Version += ", " + client.name
-				}
+		if !commandExists(client.command) {
+			continue
+		}
+		// 如果至少有一个客户端安装，则标记为已安装
+		info.Installed = true
+
+		label := client.name + " (已安装)"
+		if len(client.args) > 0 {
+			if output, err := executeCommandWithTimeout(client.command, client.args...); err == nil {
+				label = client.name + ": " + strings.Split(output, "\n")[0]
 			}
 		}
+		if info.Version == "" {
+			info.Version = label
+		} else {
+			info.Version += ", " + client.name
+		}
 	}
 
 	// 如果找到了数据库客户端，获取扩展信息
@@ -1929,120 +1933,62 @@ func (a *App) detectSQL() LanguageInfo {
 }
 
 // 列出已安装的SQL数据库和扩展
+// 这里只做离线检测：客户端、本地服务端程序、数据目录和扩展文件，
+// 不会连接任何数据库服务器。服务器内省见 IntrospectSQLServer。
 func (a *App) listSQLPackages() ([]PackageInfo, error) {
 	var packages []PackageInfo
 
-	// 检查MySQL
-	if commandExists("mysql") {
-		// 尝试获取MySQL版本
-		output, err := executeCommandWithTimeout("mysql", "--version")
-		if err == nil {
-			packages = append(packages, PackageInfo{
-				Name:      "MySQL",
-				Version:   output,
-				Installed: true,
-			})
-
-			// 尝试获取已安装的MySQL插件
-			pluginsOutput, err := executeCommandWithTimeout("mysql", "-e", "SHOW PLUGINS")
-			if err == nil {
-				lines := strings.Split(pluginsOutput, "\n")
-				for i, line := range lines {
-					// 跳过标题行
-					if i == 0 || strings.TrimSpace(line) == "" {
-						continue
-					}
-
-					fields := strings.Fields(line)
-					if len(fields) >= 2 {
-						packages = append(packages, PackageInfo{
-							Name:      "MySQL Plugin: " + fields[0],
-							Version:   "已启用",
-							Installed: true,
-						})
-
-						// 限制数量
-						if len(packages) >= 20 {
-							return packages, nil
-						}
-					}
-				}
-			}
-		}
+	// 检查客户端
+	sqlClients := []struct {
+		name    string
+		command string
+		args    []string
+	}{
+		{"MySQL Client", "mysql", []string{"--version"}},
+		{"MariaDB Client", "mariadb", []string{"--version"}},
+		{"PostgreSQL Client", "psql", []string{"--version"}},
+		{"SQLite", "sqlite3", []string{"--version"}},
+		{"SQL Server Client Tools", "sqlcmd", []string{}},
+		{"Oracle Database Client", "sqlplus", []string{"-v"}},
 	}
 
-	// 检查PostgreSQL
-	if commandExists("psql") {
-		// 尝试获取PostgreSQL版本
-		output, err := executeCommandWithTimeout("psql", "--version")
-		if err == nil {
-			packages = append(packages, PackageInfo{
-				Name:      "PostgreSQL",
-				Version:   output,
-				Installed: true,
-			})
-
-			// 尝试获取已安装的PostgreSQL扩展
-			extOutput, err := executeCommandWithTimeout("psql", "-c", "SELECT name, default_version FROM pg_available_extensions LIMIT 10")
-			if err == nil {
-				lines := strings.Split(extOutput, "\n")
-				for i, line := range lines {
-					// 跳过标题行和分隔行
-					if i <= 1 || strings.TrimSpace(line) == "" || strings.Contains(line, "----") {
-						continue
-					}
-
-					fields := strings.Fields(line)
-					if len(fields) >= 2 {
-						packages = append(packages, PackageInfo{
-							Name:      "PostgreSQL Extension: " + fields[0],
-							Version:   fields[1],
-							Installed: true,
-						})
-
-						// 限制数量
-						if len(packages) >= 20 {
-							return packages, nil
-						}
-					}
-				}
-			}
+	for _, client := range sqlClients {
+		if !commandExists(client.command) {
+			continue
 		}
-	}
 
-	// 检查SQLite
-	if commandExists("sqlite3") {
-		output, err := executeCommandWithTimeout("sqlite3", "--version")
-		if err == nil {
-			packages = append(packages, PackageInfo{
-				Name:      "SQLite",
-				Version:   output,
-				Installed: true,
-			})
+		version := "已安装"
+		if len(client.args) > 0 {
+			output, err := executeCommandWithTimeout(client.command, client.args...)
+			if err == nil && output != "" {
+				version = strings.Split(output, "\n")[0]
+			}
 		}
-	}
 
-	// 检查SQL Server
-	if commandExists("sqlcmd") {
 		packages = append(packages, PackageInfo{
-			Name:      "SQL Server Client Tools",
-			Version:   "已安装",
+			Name:      client.name,
+			Version:   version,
 			Installed: true,
 		})
 	}
 
-	// 检查Oracle
-	if commandExists("sqlplus") {
-		output, err := executeCommandWithTimeout("sqlplus", "-v")
-		if err == nil {
-			packages = append(packages, PackageInfo{
-				Name:      "Oracle Database Client",
-				Version:   output,
-				Installed: true,
-			})
-		}
+	// 检查本地服务端程序
+	packages = append(packages, a.listSQLServerBinaries()...)
+
+	// 检查本地数据目录
+	for _, dir := range a.findSQLDataDirs() {
+		packages = append(packages, PackageInfo{
+			Name:        dir.name,
+			Version:     "已存在",
+			Description: dir.path,
+			Installed:   true,
+		})
 	}
 
+	// 检查已安装的扩展文件
+	packages = append(packages, a.listPostgresExtensionFiles()...)
+	packages = append(packages, a.listMySQLPluginFiles()...)
+
 	// 检查常见的SQL工具
 	sqlTools := []struct {
 		name    string
@@ -2071,15 +2017,245 @@ func (a *App) listSQLPackages() ([]PackageInfo, error) {
 				Version:   version,
 				Installed: true,
 			})
+		}
+	}
 
-			// 限制数量
-			if len(packages) >= 20 {
-				return packages, nil
+	return packages, nil
+}
+
+// listSQLServerBinaries 检测本地安装的数据库服务端程序
+func (a *App) listSQLServerBinaries() []PackageInfo {
+	var packages []PackageInfo
+
+	servers := []struct {
+		name    string
+		command string
+		args    []string
+	}{
+		{"MySQL Server", "mysqld", []string{"--version"}},
+		{"MariaDB Server", "mariadbd", []string{"--version"}},
+		{"PostgreSQL Server", "postgres", []string{"--version"}},
+		{"PostgreSQL pg_ctl", "pg_ctl", []string{"--version"}},
+	}
+
+	for _, server := range servers {
+		if commandExists(server.command) {
+			version := "已安装"
+			output, err := executeCommandWithTimeout(server.command, server.args...)
+			if err == nil && output != "" {
+				version = strings.Split(output, "\n")[0]
 			}
+
+			packages = append(packages, PackageInfo{
+				Name:      server.name,
+				Version:   version,
+				Installed: true,
+			})
 		}
 	}
 
-	return packages, nil
+	// Windows上的服务端通常不在PATH中，检查默认安装目录
+	if runtime.GOOS == "windows" {
+		patterns := []struct {
+			name    string
+			pattern string
+		}{
+			{"PostgreSQL Server", "C:/Program Files/PostgreSQL/*/bin/postgres.exe"},
+			{"MySQL Server", "C:/Program Files/MySQL/MySQL Server */bin/mysqld.exe"},
+			{"MariaDB Server", "C:/Program Files/MariaDB */bin/mariadbd.exe"},
+			{"SQL Server", "C:/Program Files/Microsoft SQL Server/MSSQL*/MSSQL/Binn/sqlservr.exe"},
+		}
+
+		for _, p := range patterns {
+			matches, _ := filepath.Glob(p.pattern)
+			for _, match := range matches {
+				packages = append(packages, PackageInfo{
+					Name:        p.name,
+					Version:     "已安装",
+					Description: match,
+					Installed:   true,
+				})
+			}
+		}
+	}
+
+	return packages
+}
+
+// sqlDataDir 描述一个本地数据库数据目录
+type sqlDataDir struct {
+	name string
+	path string
+}
+
+// findSQLDataDirs 查找常见的本地数据库数据目录
+func (a *App) findSQLDataDirs() []sqlDataDir {
+	var dirs []sqlDataDir
+
+	// path字段为glob模式
+	var candidates []sqlDataDir
+
+	if pgData := os.Getenv("PGDATA"); pgData != "" {
+		candidates = append(candidates, sqlDataDir{"PostgreSQL", pgData})
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		candidates = append(candidates,
+			sqlDataDir{"PostgreSQL", "C:/Program Files/PostgreSQL/*/data"},
+			sqlDataDir{"MySQL", "C:/ProgramData/MySQL/MySQL Server */Data"},
+			sqlDataDir{"MariaDB", "C:/Program Files/MariaDB */data"},
+		)
+	case "darwin":
+		candidates = append(candidates,
+			sqlDataDir{"PostgreSQL", "/usr/local/var/postgres*"},
+			sqlDataDir{"PostgreSQL", "/opt/homebrew/var/postgresql*"},
+			sqlDataDir{"MySQL", "/usr/local/var/mysql"},
+			sqlDataDir{"MySQL", "/opt/homebrew/var/mysql"},
+		)
+	default:
+		candidates = append(candidates,
+			sqlDataDir{"PostgreSQL", "/var/lib/postgresql/*/main"},
+			sqlDataDir{"PostgreSQL", "/var/lib/pgsql/data"},
+			sqlDataDir{"MySQL", "/var/lib/mysql"},
+		)
+	}
+
+	for _, candidate := range candidates {
+		matches, _ := filepath.Glob(candidate.path)
+		for _, match := range matches {
+			if stat, err := os.Stat(match); err == nil && stat.IsDir() {
+				dirs = append(dirs, sqlDataDir{name: candidate.name + " 数据目录", path: match})
+			}
+		}
+	}
+
+	return dirs
+}
+
+// listPostgresExtensionFiles 通过share/extension/*.control文件列出已安装的PostgreSQL扩展
+func (a *App) listPostgresExtensionFiles() []PackageInfo {
+	var packages []PackageInfo
+	var extensionDirs []string
+
+	// pg_config只读取编译配置，不会连接服务器
+	if commandExists("pg_config") {
+		output, err := executeCommandWithTimeout("pg_config", "--sharedir")
+		if err == nil && output != "" {
+			extensionDirs = append(extensionDirs, filepath.Join(output, "extension"))
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		matches, _ := filepath.Glob("C:/Program Files/PostgreSQL/*/share/extension")
+		extensionDirs = append(extensionDirs, matches...)
+	} else {
+		matches, _ := filepath.Glob("/usr/share/postgresql/*/extension")
+		extensionDirs = append(extensionDirs, matches...)
+	}
+
+	seen := make(map[string]bool)
+	for _, dir := range extensionDirs {
+		controlFiles, _ := filepath.Glob(filepath.Join(dir, "*.control"))
+		for _, controlFile := range controlFiles {
+			name := strings.TrimSuffix(filepath.Base(controlFile), ".control")
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			version, comment := parsePostgresControlFile(controlFile)
+			packages = append(packages, PackageInfo{
+				Name:        "PostgreSQL Extension: " + name,
+				Version:     version,
+				Description: comment,
+				Installed:   true,
+			})
+		}
+	}
+
+	return packages
+}
+
+// parsePostgresControlFile 从扩展控制文件中读取default_version和comment
+func parsePostgresControlFile(path string) (string, string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", ""
+	}
+
+	var version, comment string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		key := strings.TrimSpace(parts[0])
+		value := strings.Trim(strings.TrimSpace(parts[1]), "'")
+		switch key {
+		case "default_version":
+			version = value
+		case "comment":
+			comment = value
+		}
+	}
+
+	return version, comment
+}
+
+// listMySQLPluginFiles 通过插件目录中的共享库列出MySQL插件
+func (a *App) listMySQLPluginFiles() []PackageInfo {
+	var packages []PackageInfo
+	var pluginDirs []string
+
+	// mysql_config只读取编译配置，不会连接服务器
+	if commandExists("mysql_config") {
+		output, err := executeCommandWithTimeout("mysql_config", "--plugindir")
+		if err == nil && output != "" {
+			pluginDirs = append(pluginDirs, output)
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		matches, _ := filepath.Glob("C:/Program Files/MySQL/MySQL Server */lib/plugin")
+		pluginDirs = append(pluginDirs, matches...)
+	}
+
+	seen := make(map[string]bool)
+	for _, dir := range pluginDirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			if entry.IsDir() || (ext != ".so" && ext != ".dll") {
+				continue
+			}
+
+			name := strings.TrimSuffix(entry.Name(), ext)
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			packages = append(packages, PackageInfo{
+				Name:        "MySQL Plugin: " + name,
+				Version:     "已安装",
+				Description: filepath.Join(dir, entry.Name()),
+				Installed:   true,
+			})
+		}
+	}
+
+	return packages
 }
 
 // 检测HTML/CSS
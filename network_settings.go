@@ -0,0 +1,269 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// NetworkSettings 所有出站HTTP请求（包搜索、AI补充信息、AI对话）共用的网络设置
+type NetworkSettings struct {
+	// ProxyMode 代理模式：system（读取 HTTP_PROXY 等环境变量）、none（直连）、manual（使用 ProxyURL）
+	ProxyMode string `json:"proxyMode"`
+	// ProxyURL 手动代理地址，支持 http://、https:// 和 socks5://，可以带用户名密码
+	ProxyURL string `json:"proxyUrl,omitempty"`
+	// NoProxy 不走代理的主机，逗号分隔，格式与 NO_PROXY 环境变量相同
+	NoProxy string `json:"noProxy,omitempty"`
+	// CABundlePath 额外信任的PEM格式CA证书文件，用于公司网络的HTTPS检查代理
+	CABundlePath string `json:"caBundlePath,omitempty"`
+}
+
+// NetworkTestResult 网络连接测试结果
+type NetworkTestResult struct {
+	Success    bool   `json:"success"`
+	URL        string `json:"url"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	// Proxy 实际使用的代理地址（已隐藏密码），直连时为空
+	Proxy     string `json:"proxy,omitempty"`
+	LatencyMs int64  `json:"latencyMs"`
+	Message   string `json:"message"`
+}
+
+// networkTransport 根据网络设置创建的共享Transport，所有HTTP客户端复用其连接池
+var networkTransport struct {
+	sync.RWMutex
+	transport *http.Transport
+}
+
+// getNetworkSettingsPath 获取网络设置文件路径
+func (a *App) getNetworkSettingsPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "network.json"
+	}
+	return filepath.Join(homeDir, ".networ_tester", "network.json")
+}
+
+// GetNetworkSettings 获取网络设置，文件不存在时使用系统代理
+func (a *App) GetNetworkSettings() NetworkSettings {
+	settings := NetworkSettings{ProxyMode: "system"}
+	if data, err := os.ReadFile(a.getNetworkSettingsPath()); err == nil {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			fmt.Printf("解析网络设置失败: %v\n", err)
+			settings = NetworkSettings{ProxyMode: "system"}
+		}
+	}
+	if settings.ProxyMode == "" {
+		settings.ProxyMode = "system"
+	}
+	return settings
+}
+
+// SaveNetworkSettings 校验并保存网络设置，保存后立即对新的请求生效
+// 代理地址可能包含密码，文件只允许当前用户读写
+func (a *App) SaveNetworkSettings(settings NetworkSettings) error {
+	transport, err := newNetworkTransport(&settings)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	os.MkdirAll(filepath.Dir(a.getNetworkSettingsPath()), 0755)
+	if err := os.WriteFile(a.getNetworkSettingsPath(), data, 0600); err != nil {
+		return err
+	}
+
+	setNetworkTransport(transport)
+	return nil
+}
+
+// reloadNetworkSettings 读取网络设置并更新共享Transport，设置无效时退回系统代理
+func (a *App) reloadNetworkSettings() error {
+	settings := a.GetNetworkSettings()
+	transport, err := newNetworkTransport(&settings)
+	if err != nil {
+		setNetworkTransport(nil)
+		return err
+	}
+	setNetworkTransport(transport)
+	return nil
+}
+
+// newNetworkTransport 校验网络设置并创建对应的Transport，会规范化 settings 中的字段
+func newNetworkTransport(settings *NetworkSettings) (*http.Transport, error) {
+	settings.ProxyMode = strings.ToLower(strings.TrimSpace(settings.ProxyMode))
+	settings.ProxyURL = strings.TrimSpace(settings.ProxyURL)
+	settings.NoProxy = strings.TrimSpace(settings.NoProxy)
+	settings.CABundlePath = strings.TrimSpace(settings.CABundlePath)
+	if settings.ProxyMode == "" {
+		settings.ProxyMode = "system"
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	switch settings.ProxyMode {
+	case "system":
+		transport.Proxy = http.ProxyFromEnvironment
+	case "none":
+		transport.Proxy = nil
+	case "manual":
+		proxyURL, err := url.Parse(settings.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("代理地址无效: %s", settings.ProxyURL)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return nil, fmt.Errorf("不支持的代理协议: %s（只支持 http、https 和 socks5）", proxyURL.Scheme)
+		}
+		proxyFunc := (&httpproxy.Config{
+			HTTPProxy:  settings.ProxyURL,
+			HTTPSProxy: settings.ProxyURL,
+			NoProxy:    settings.NoProxy,
+		}).ProxyFunc()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}
+	default:
+		return nil, fmt.Errorf("未知的代理模式: %s", settings.ProxyMode)
+	}
+
+	if settings.CABundlePath != "" {
+		pool, err := loadCABundle(settings.CABundlePath)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	return transport, nil
+}
+
+// loadCABundle 在系统证书的基础上加入CA证书文件中的证书
+func loadCABundle(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取CA证书文件失败: %v", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("CA证书文件中没有有效的PEM证书: %s", path)
+	}
+	return pool, nil
+}
+
+// setNetworkTransport 替换共享Transport，旧Transport的空闲连接随之关闭
+func setNetworkTransport(transport *http.Transport) {
+	networkTransport.Lock()
+	old := networkTransport.transport
+	networkTransport.transport = transport
+	networkTransport.Unlock()
+
+	if old != nil {
+		old.CloseIdleConnections()
+	}
+}
+
+// currentTransport 返回当前的共享Transport，尚未加载设置时使用系统代理
+func currentTransport() *http.Transport {
+	networkTransport.RLock()
+	transport := networkTransport.transport
+	networkTransport.RUnlock()
+	if transport != nil {
+		return transport
+	}
+
+	networkTransport.Lock()
+	defer networkTransport.Unlock()
+	if networkTransport.transport == nil {
+		networkTransport.transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	return networkTransport.transport
+}
+
+// newHTTPClient 创建使用网络设置的HTTP客户端，timeout 为0时不限制总时间
+func newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: currentTransport()}
+}
+
+// networkCommandEnv 返回让包管理器命令使用同一代理的环境变量，系统代理模式下不需要额外设置
+func (a *App) networkCommandEnv() []string {
+	settings := a.GetNetworkSettings()
+	proxy, noProxy := "", ""
+	switch settings.ProxyMode {
+	case "manual":
+		proxy, noProxy = settings.ProxyURL, settings.NoProxy
+	case "none":
+		noProxy = "*"
+	default:
+		return nil
+	}
+
+	env := []string{}
+	for _, name := range []string{"HTTP_PROXY", "HTTPS_PROXY", "http_proxy", "https_proxy"} {
+		env = append(env, name+"="+proxy)
+	}
+	return append(env, "NO_PROXY="+noProxy, "no_proxy="+noProxy)
+}
+
+// TestNetworkConnection 使用界面上尚未保存的网络设置访问指定地址，检查代理和证书是否可用
+// targetURL 为空时访问npm注册表
+func (a *App) TestNetworkConnection(settings NetworkSettings, targetURL string) NetworkTestResult {
+	targetURL = strings.TrimSpace(targetURL)
+	if targetURL == "" {
+		targetURL = defaultRegistryBaseURLs["npm"]
+	}
+	result := NetworkTestResult{URL: targetURL}
+
+	transport, err := newNetworkTransport(&settings)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	defer transport.CloseIdleConnections()
+
+	req, err := http.NewRequest("GET", targetURL, nil)
+	if err != nil {
+		result.Message = fmt.Sprintf("地址无效: %v", err)
+		return result
+	}
+	req.Header.Set("User-Agent", registryUserAgent)
+
+	if transport.Proxy != nil {
+		if proxyURL, err := transport.Proxy(req); err == nil && proxyURL != nil {
+			result.Proxy = proxyURL.Redacted()
+		}
+	}
+
+	started := time.Now()
+	resp, err := (&http.Client{Timeout: 15 * time.Second, Transport: transport}).Do(req)
+	result.LatencyMs = time.Since(started).Milliseconds()
+	if err != nil {
+		result.Message = fmt.Sprintf("连接失败: %v", err)
+		return result
+	}
+	resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	result.Success = resp.StatusCode < 500
+	result.Message = fmt.Sprintf("HTTP %d，耗时 %d ms", resp.StatusCode, result.LatencyMs)
+	return result
+}
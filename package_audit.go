@@ -0,0 +1,973 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	// osvDumpBaseURL OSV按生态系统导出的漏洞数据，每个生态系统一个 all.zip
+	osvDumpBaseURL = "https://osv-vulnerabilities.storage.googleapis.com"
+	// osvUpdateEventName 在线更新漏洞数据时推送下载和导入进度
+	osvUpdateEventName = "osv:update"
+	// osvDownloadTimeout 单个生态系统的下载超时，npm的导出文件有数百MB
+	osvDownloadTimeout = 30 * time.Minute
+)
+
+// osvEcosystems 支持审计的OSV生态系统及对应的包管理器
+var osvEcosystems = map[string]string{
+	"npm":       "npm",
+	"PyPI":      "pip",
+	"crates.io": "cargo",
+	"Go":        "go",
+	"Maven":     "maven",
+	"RubyGems":  "gem",
+	"Packagist": "composer",
+	"NuGet":     "nuget",
+}
+
+// osvLanguageEcosystems 语言检测结果中的语言对应的OSV生态系统
+var osvLanguageEcosystems = map[string]string{
+	"Node.js":    "npm",
+	"TypeScript": "npm",
+	"Python":     "PyPI",
+	"Rust":       "crates.io",
+	"Go":         "Go",
+	"Java":       "Maven",
+	"Ruby":       "RubyGems",
+	"PHP":        "Packagist",
+	"C# (.NET)":  "NuGet",
+}
+
+// OSVDatabaseStatus 一个生态系统的本地漏洞数据状态
+type OSVDatabaseStatus struct {
+	Ecosystem  string `json:"ecosystem"`
+	Advisories int    `json:"advisories"`
+	Packages   int    `json:"packages"`
+	// LatestModified 数据中最近一次修改的公告时间，用于判断数据是否过旧
+	LatestModified string `json:"latestModified"`
+	ImportedAt     string `json:"importedAt"`
+	// Source 导入的文件路径或下载地址
+	Source string `json:"source"`
+}
+
+// VulnerabilityFinding 已安装的包受某个公告影响
+type VulnerabilityFinding struct {
+	Ecosystem  string   `json:"ecosystem"`
+	Language   string   `json:"language"`
+	Name       string   `json:"name"`
+	Version    string   `json:"version"`
+	AdvisoryID string   `json:"advisoryId"`
+	Aliases    []string `json:"aliases"`
+	Summary    string   `json:"summary"`
+	// Severity 严重程度：CRITICAL、HIGH、MEDIUM、LOW，无法确定时为 UNKNOWN
+	Severity string  `json:"severity"`
+	Score    float64 `json:"score,omitempty"`
+	// FixedVersions 公告中列出的修复版本
+	FixedVersions []string `json:"fixedVersions"`
+	// UpgradeCommand 升级到高于当前版本的最低修复版本的命令，没有修复版本时为空
+	UpgradeCommand string `json:"upgradeCommand,omitempty"`
+}
+
+// VulnerabilitySummary 按严重程度统计的数量
+type VulnerabilitySummary struct {
+	Critical int `json:"critical"`
+	High     int `json:"high"`
+	Medium   int `json:"medium"`
+	Low      int `json:"low"`
+	Unknown  int `json:"unknown"`
+}
+
+// VulnerabilityAuditReport 漏洞审计报告
+type VulnerabilityAuditReport struct {
+	GeneratedAt string                 `json:"generatedAt"`
+	Findings    []VulnerabilityFinding `json:"findings"`
+	// Scanned 参与审计的包版本数量
+	Scanned   int                  `json:"scanned"`
+	Summary   VulnerabilitySummary `json:"summary"`
+	Databases []OSVDatabaseStatus  `json:"databases"`
+	// MissingEcosystems 有已安装的包但没有导入漏洞数据的生态系统
+	MissingEcosystems []string `json:"missingEcosystems"`
+}
+
+// osvRecord OSV公告的JSON格式，只解析审计需要的字段
+type osvRecord struct {
+	ID        string   `json:"id"`
+	Aliases   []string `json:"aliases"`
+	Summary   string   `json:"summary"`
+	Details   string   `json:"details"`
+	Modified  string   `json:"modified"`
+	Withdrawn string   `json:"withdrawn"`
+	Severity  []struct {
+		Type  string `json:"type"`
+		Score string `json:"score"`
+	} `json:"severity"`
+	DatabaseSpecific map[string]interface{} `json:"database_specific"`
+	Affected         []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Type   string              `json:"type"`
+			Events []map[string]string `json:"events"`
+		} `json:"ranges"`
+		Versions []string `json:"versions"`
+	} `json:"affected"`
+}
+
+// osvEvent 版本范围中的一个事件
+type osvEvent struct {
+	Introduced   string `json:"i,omitempty"`
+	Fixed        string `json:"f,omitempty"`
+	LastAffected string `json:"l,omitempty"`
+}
+
+// osvAdvisory 保存在本地索引中的精简公告
+type osvAdvisory struct {
+	ID       string   `json:"id"`
+	Aliases  []string `json:"aliases,omitempty"`
+	Summary  string   `json:"summary,omitempty"`
+	Severity string   `json:"severity,omitempty"`
+	Score    float64  `json:"score,omitempty"`
+	// Ranges 语义化版本或生态系统版本范围，每个范围是一组事件
+	Ranges [][]osvEvent `json:"ranges,omitempty"`
+	// Versions 明确列出的受影响版本，只在没有版本范围时保存
+	Versions []string `json:"versions,omitempty"`
+}
+
+// osvMu 保护漏洞数据目录的读写，导入和审计不能同时进行
+var osvMu sync.Mutex
+
+// getOSVDir 获取漏洞数据目录
+func (a *App) getOSVDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "osv"
+	}
+	return filepath.Join(homeDir, ".networ_tester", "osv")
+}
+
+// osvIndexPath 生态系统的索引文件，每行为 包名\t公告列表JSON，gzip压缩，同一个包可以有多行
+func (a *App) osvIndexPath(ecosystem string) string {
+	return filepath.Join(a.getOSVDir(), ecosystem+".jsonl.gz")
+}
+
+// loadOSVStatus 读取各生态系统的导入状态
+func (a *App) loadOSVStatus() map[string]OSVDatabaseStatus {
+	status := map[string]OSVDatabaseStatus{}
+	data, err := os.ReadFile(filepath.Join(a.getOSVDir(), "status.json"))
+	if err != nil {
+		return status
+	}
+	if err := json.Unmarshal(data, &status); err != nil {
+		fmt.Printf("解析漏洞数据状态失败: %v\n", err)
+	}
+	return status
+}
+
+// saveOSVStatus 保存各生态系统的导入状态
+func (a *App) saveOSVStatus(status map[string]OSVDatabaseStatus) error {
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(a.getOSVDir(), 0755)
+	return os.WriteFile(filepath.Join(a.getOSVDir(), "status.json"), data, 0644)
+}
+
+// GetOSVDatabaseStatus 获取已导入的漏洞数据，按生态系统名称排序
+func (a *App) GetOSVDatabaseStatus() []OSVDatabaseStatus {
+	osvMu.Lock()
+	defer osvMu.Unlock()
+
+	return sortedOSVStatus(a.loadOSVStatus())
+}
+
+// sortedOSVStatus 把状态映射转换为按生态系统排序的列表
+func sortedOSVStatus(status map[string]OSVDatabaseStatus) []OSVDatabaseStatus {
+	result := make([]OSVDatabaseStatus, 0, len(status))
+	for _, item := range status {
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Ecosystem < result[j].Ecosystem })
+	return result
+}
+
+// normalizeOSVName 规范化包名用于匹配，PyPI按PEP 503规范化，其他生态系统不区分大小写
+func normalizeOSVName(ecosystem string, name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if ecosystem == "PyPI" {
+		name = pipNamePattern.ReplaceAllString(name, "-")
+	}
+	return name
+}
+
+// ImportOSVDatabase 选择OSV导出的 all.zip 文件导入，可以一次选择多个生态系统的文件
+// 用户取消选择时返回空列表；导入过程可以通过 CancelOSVUpdate 取消
+func (a *App) ImportOSVDatabase() ([]OSVDatabaseStatus, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("应用程序尚未启动")
+	}
+
+	paths, err := wailsruntime.OpenMultipleFilesDialog(a.ctx, wailsruntime.OpenDialogOptions{
+		Title:   "选择OSV漏洞数据（各生态系统的 all.zip）",
+		Filters: []wailsruntime.FileFilter{{DisplayName: "OSV导出文件 (*.zip)", Pattern: "*.zip"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return []OSVDatabaseStatus{}, nil
+	}
+
+	ctx, finish, err := a.beginOSVUpdate()
+	if err != nil {
+		return nil, err
+	}
+	defer finish()
+
+	imported := []OSVDatabaseStatus{}
+	for _, path := range paths {
+		statuses, err := a.importOSVZip(ctx, path, path)
+		if err != nil {
+			return imported, fmt.Errorf("导入 %s 失败: %v", filepath.Base(path), err)
+		}
+		imported = append(imported, statuses...)
+	}
+	return imported, nil
+}
+
+// beginOSVUpdate 开始一次漏洞数据的下载或导入，同一时间只允许一个，结束后调用返回的函数
+func (a *App) beginOSVUpdate() (context.Context, func(), error) {
+	a.osvUpdateMu.Lock()
+	defer a.osvUpdateMu.Unlock()
+
+	if a.osvUpdateCancel != nil {
+		return nil, nil, fmt.Errorf("正在更新漏洞数据，请等待完成或先取消")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a.osvUpdateCancel = cancel
+
+	return ctx, func() {
+		a.osvUpdateMu.Lock()
+		a.osvUpdateCancel = nil
+		a.osvUpdateMu.Unlock()
+		cancel()
+	}, nil
+}
+
+// CancelOSVUpdate 取消正在进行的漏洞数据下载或导入，已完成的生态系统保留新数据，其余保留原有数据
+func (a *App) CancelOSVUpdate() bool {
+	a.osvUpdateMu.Lock()
+	defer a.osvUpdateMu.Unlock()
+
+	if a.osvUpdateCancel == nil {
+		return false
+	}
+	a.osvUpdateCancel()
+	return true
+}
+
+// importOSVZip 解析OSV导出文件，按生态系统重建本地索引
+// 生态系统根据公告内容判断，不依赖文件名；公告逐条写入各生态系统的临时索引，不在内存中保留整个索引
+func (a *App) importOSVZip(ctx context.Context, path string, source string) ([]OSVDatabaseStatus, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开zip文件: %v", err)
+	}
+	defer reader.Close()
+
+	writers := map[string]*osvIndexWriter{}
+	defer func() {
+		for _, writer := range writers {
+			writer.discard()
+		}
+	}()
+
+	for _, file := range reader.File {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("已取消")
+		}
+		if file.FileInfo().IsDir() || !strings.HasSuffix(strings.ToLower(file.Name), ".json") {
+			continue
+		}
+		record, err := readOSVRecord(file)
+		if err != nil || record.Withdrawn != "" {
+			continue
+		}
+
+		severity, score := osvRecordSeverity(record)
+		counted := map[string]bool{}
+		for _, affected := range record.Affected {
+			ecosystem := affected.Package.Ecosystem
+			if _, ok := osvEcosystems[ecosystem]; !ok || affected.Package.Name == "" {
+				continue
+			}
+
+			advisory := osvAdvisory{
+				ID:       record.ID,
+				Aliases:  record.Aliases,
+				Summary:  osvRecordSummary(record),
+				Severity: severity,
+				Score:    score,
+			}
+			for _, r := range affected.Ranges {
+				if r.Type != "SEMVER" && r.Type != "ECOSYSTEM" {
+					continue
+				}
+				events := []osvEvent{}
+				for _, event := range r.Events {
+					events = append(events, osvEvent{Introduced: event["introduced"], Fixed: event["fixed"], LastAffected: event["last_affected"]})
+				}
+				advisory.Ranges = append(advisory.Ranges, events)
+			}
+			// 有版本范围时明确列出的版本是重复信息，不保存以减小索引
+			if len(advisory.Ranges) == 0 {
+				advisory.Versions = affected.Versions
+			}
+			if len(advisory.Ranges) == 0 && len(advisory.Versions) == 0 {
+				continue
+			}
+
+			writer := writers[ecosystem]
+			if writer == nil {
+				writer, err = a.newOSVIndexWriter(ecosystem)
+				if err != nil {
+					return nil, err
+				}
+				writers[ecosystem] = writer
+			}
+			if err := writer.add(normalizeOSVName(ecosystem, affected.Package.Name), advisory); err != nil {
+				return nil, err
+			}
+			if !counted[ecosystem] {
+				counted[ecosystem] = true
+				writer.advisories++
+			}
+			if record.Modified > writer.latest {
+				writer.latest = record.Modified
+			}
+		}
+	}
+
+	if len(writers) == 0 {
+		return nil, fmt.Errorf("文件中没有支持的生态系统的公告")
+	}
+	for _, writer := range writers {
+		if err := writer.close(); err != nil {
+			return nil, err
+		}
+	}
+
+	osvMu.Lock()
+	defer osvMu.Unlock()
+
+	status := a.loadOSVStatus()
+	imported := []OSVDatabaseStatus{}
+	for ecosystem, writer := range writers {
+		if err := os.Rename(writer.tmp.Name(), a.osvIndexPath(ecosystem)); err != nil {
+			return imported, err
+		}
+		item := OSVDatabaseStatus{
+			Ecosystem:      ecosystem,
+			Advisories:     writer.advisories,
+			Packages:       len(writer.names),
+			LatestModified: writer.latest,
+			ImportedAt:     time.Now().Format(time.RFC3339),
+			Source:         source,
+		}
+		status[ecosystem] = item
+		imported = append(imported, item)
+	}
+	sort.Slice(imported, func(i, j int) bool { return imported[i].Ecosystem < imported[j].Ecosystem })
+
+	return imported, a.saveOSVStatus(status)
+}
+
+// readOSVRecord 读取zip中的单个公告
+func readOSVRecord(file *zip.File) (*osvRecord, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var record osvRecord
+	if err := json.NewDecoder(rc).Decode(&record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// osvIndexWriter 把一个生态系统的公告逐条写入临时索引文件，全部写完后再替换原有索引，导入失败不会破坏原有数据
+type osvIndexWriter struct {
+	tmp    *os.File
+	gz     *gzip.Writer
+	writer *bufio.Writer
+	// names 已写入的规范化包名，用于统计包数量
+	names      map[string]bool
+	advisories int
+	latest     string
+}
+
+// newOSVIndexWriter 在漏洞数据目录中创建生态系统的临时索引文件
+func (a *App) newOSVIndexWriter(ecosystem string) (*osvIndexWriter, error) {
+	os.MkdirAll(a.getOSVDir(), 0755)
+	tmp, err := os.CreateTemp(a.getOSVDir(), ecosystem+"-*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(tmp)
+	return &osvIndexWriter{tmp: tmp, gz: gz, writer: bufio.NewWriter(gz), names: map[string]bool{}}, nil
+}
+
+// add 写入一个包的一条公告
+func (w *osvIndexWriter) add(name string, advisory osvAdvisory) error {
+	data, err := json.Marshal([]osvAdvisory{advisory})
+	if err != nil {
+		return err
+	}
+	w.writer.WriteString(name)
+	w.writer.WriteByte('\t')
+	w.writer.Write(data)
+	if err := w.writer.WriteByte('\n'); err != nil {
+		return err
+	}
+	w.names[name] = true
+	return nil
+}
+
+// close 写完剩余数据并关闭临时文件
+func (w *osvIndexWriter) close() error {
+	if err := w.writer.Flush(); err != nil {
+		return err
+	}
+	if err := w.gz.Close(); err != nil {
+		return err
+	}
+	return w.tmp.Close()
+}
+
+// discard 删除临时文件，已替换为正式索引时不做任何事
+func (w *osvIndexWriter) discard() {
+	w.tmp.Close()
+	os.Remove(w.tmp.Name())
+}
+
+// osvRecordSummary 公告的简短说明，没有摘要时取详情的第一行
+func osvRecordSummary(record *osvRecord) string {
+	summary := strings.TrimSpace(record.Summary)
+	if summary == "" {
+		summary = strings.TrimSpace(strings.SplitN(strings.TrimSpace(record.Details), "\n", 2)[0])
+	}
+	if runes := []rune(summary); len(runes) > 200 {
+		summary = string(runes[:200]) + "…"
+	}
+	return summary
+}
+
+// osvRecordSeverity 确定公告的严重程度
+// 优先使用数据库提供的等级（如GitHub公告的 database_specific.severity），否则根据CVSS v3向量计算
+func osvRecordSeverity(record *osvRecord) (string, float64) {
+	score := 0.0
+	for _, severity := range record.Severity {
+		if strings.HasPrefix(severity.Type, "CVSS_V3") {
+			if value, ok := cvss3BaseScore(severity.Score); ok {
+				score = value
+				break
+			}
+		}
+	}
+
+	if level, ok := record.DatabaseSpecific["severity"].(string); ok && level != "" {
+		level = strings.ToUpper(level)
+		if level == "MODERATE" {
+			level = "MEDIUM"
+		}
+		return level, score
+	}
+
+	switch {
+	case score >= 9:
+		return "CRITICAL", score
+	case score >= 7:
+		return "HIGH", score
+	case score >= 4:
+		return "MEDIUM", score
+	case score > 0:
+		return "LOW", score
+	}
+	return "UNKNOWN", score
+}
+
+// cvss3BaseScore 按CVSS v3.x规范计算向量的基础分数
+func cvss3BaseScore(vector string) (float64, bool) {
+	metrics := map[string]string{}
+	for _, part := range strings.Split(vector, "/") {
+		if key, value, ok := strings.Cut(part, ":"); ok {
+			metrics[key] = value
+		}
+	}
+
+	weights := map[string]map[string]float64{
+		"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+		"AC": {"L": 0.77, "H": 0.44},
+		"UI": {"N": 0.85, "R": 0.62},
+		"C":  {"H": 0.56, "L": 0.22, "N": 0},
+		"I":  {"H": 0.56, "L": 0.22, "N": 0},
+		"A":  {"H": 0.56, "L": 0.22, "N": 0},
+	}
+	values := map[string]float64{}
+	for metric, options := range weights {
+		value, ok := options[metrics[metric]]
+		if !ok {
+			return 0, false
+		}
+		values[metric] = value
+	}
+
+	changed := metrics["S"] == "C"
+	if !changed && metrics["S"] != "U" {
+		return 0, false
+	}
+	privileges := map[string]float64{"N": 0.85, "L": 0.62, "H": 0.27}
+	if changed {
+		privileges = map[string]float64{"N": 0.85, "L": 0.68, "H": 0.5}
+	}
+	pr, ok := privileges[metrics["PR"]]
+	if !ok {
+		return 0, false
+	}
+
+	iss := 1 - (1-values["C"])*(1-values["I"])*(1-values["A"])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, true
+	}
+	exploitability := 8.22 * values["AV"] * values["AC"] * pr * values["UI"]
+
+	total := impact + exploitability
+	if changed {
+		total *= 1.08
+	}
+	return cvssRoundUp(math.Min(total, 10)), true
+}
+
+// cvssRoundUp CVSS v3.1 规定的向上取整到一位小数
+func cvssRoundUp(value float64) float64 {
+	scaled := int(math.Round(value * 100000))
+	if scaled%10000 == 0 {
+		return float64(scaled) / 100000
+	}
+	return float64(scaled/10000+1) / 10
+}
+
+// osvAffects 判断版本是否受公告影响，按OSV规范依次处理排序后的事件
+func osvAffects(advisory osvAdvisory, version string) bool {
+	for _, affected := range advisory.Versions {
+		if affected == version {
+			return true
+		}
+	}
+
+	for _, events := range advisory.Ranges {
+		sorted := append([]osvEvent(nil), events...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return compareOSVEventVersions(osvEventVersion(sorted[i]), osvEventVersion(sorted[j])) < 0
+		})
+
+		affected := false
+		for _, event := range sorted {
+			switch {
+			case event.Introduced != "":
+				if event.Introduced == "0" || compareVersions(version, event.Introduced) >= 0 {
+					affected = true
+				}
+			case event.Fixed != "":
+				if compareVersions(version, event.Fixed) >= 0 {
+					affected = false
+				}
+			case event.LastAffected != "":
+				if compareVersions(version, event.LastAffected) > 0 {
+					affected = false
+				}
+			}
+		}
+		if affected {
+			return true
+		}
+	}
+	return false
+}
+
+// osvEventVersion 返回事件中的版本号
+func osvEventVersion(event osvEvent) string {
+	switch {
+	case event.Introduced != "":
+		return event.Introduced
+	case event.Fixed != "":
+		return event.Fixed
+	}
+	return event.LastAffected
+}
+
+// compareOSVEventVersions 比较事件版本，introduced 的 "0" 表示最早的版本
+func compareOSVEventVersions(a string, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "0":
+		return -1
+	case b == "0":
+		return 1
+	}
+	return compareVersions(a, b)
+}
+
+// installedVersions 拆分列表命令输出的版本号，同一个包可能安装了多个版本，如 gem 的 "13.0.6, 12.3.3"
+func installedVersions(version string) []string {
+	versions := []string{}
+	for _, part := range strings.Split(version, ",") {
+		part = cleanInstalledVersion(part)
+		if part != "" && part != "unknown" {
+			versions = append(versions, part)
+		}
+	}
+	return versions
+}
+
+// auditedPackage 参与审计的已安装包
+type auditedPackage struct {
+	name     string
+	version  string
+	language string
+}
+
+// AuditInstalledPackages 使用本地导入的OSV数据审计语言检测列出的已安装包，不访问网络
+func (a *App) AuditInstalledPackages() (VulnerabilityAuditReport, error) {
+	// 生态系统 -> 规范化包名 -> 已安装的版本
+	installed := map[string]map[string][]auditedPackage{}
+	scanned := 0
+	for _, language := range a.getDetectedLanguages() {
+		ecosystem, ok := osvLanguageEcosystems[language.Name]
+		if !ok || !language.Installed {
+			continue
+		}
+		for _, pkg := range language.Packages {
+			name := strings.TrimSpace(pkg.Name)
+			if ecosystem == "Go" {
+				name = unescapeGoModulePath(filepath.ToSlash(name))
+			}
+			for _, version := range installedVersions(pkg.Version) {
+				if installed[ecosystem] == nil {
+					installed[ecosystem] = map[string][]auditedPackage{}
+				}
+				key := normalizeOSVName(ecosystem, name)
+				installed[ecosystem][key] = append(installed[ecosystem][key], auditedPackage{name: name, version: version, language: language.Name})
+				scanned++
+			}
+		}
+	}
+
+	osvMu.Lock()
+	defer osvMu.Unlock()
+
+	status := a.loadOSVStatus()
+	if len(status) == 0 {
+		return VulnerabilityAuditReport{}, fmt.Errorf("尚未导入漏洞数据，请先导入OSV导出文件或在线更新")
+	}
+
+	report := VulnerabilityAuditReport{
+		GeneratedAt:       time.Now().Format(time.RFC3339),
+		Findings:          []VulnerabilityFinding{},
+		Scanned:           scanned,
+		Databases:         sortedOSVStatus(status),
+		MissingEcosystems: []string{},
+	}
+
+	for ecosystem, packages := range installed {
+		if _, ok := status[ecosystem]; !ok {
+			report.MissingEcosystems = append(report.MissingEcosystems, ecosystem)
+			continue
+		}
+		findings, err := a.auditEcosystem(ecosystem, packages)
+		if err != nil {
+			return report, fmt.Errorf("读取 %s 漏洞数据失败: %v", ecosystem, err)
+		}
+		report.Findings = append(report.Findings, findings...)
+	}
+	sort.Strings(report.MissingEcosystems)
+
+	rank := map[string]int{"CRITICAL": 0, "HIGH": 1, "MEDIUM": 2, "LOW": 3}
+	severityRank := func(severity string) int {
+		if value, ok := rank[severity]; ok {
+			return value
+		}
+		return len(rank)
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		fi, fj := report.Findings[i], report.Findings[j]
+		if severityRank(fi.Severity) != severityRank(fj.Severity) {
+			return severityRank(fi.Severity) < severityRank(fj.Severity)
+		}
+		if fi.Name != fj.Name {
+			return strings.ToLower(fi.Name) < strings.ToLower(fj.Name)
+		}
+		return fi.AdvisoryID < fj.AdvisoryID
+	})
+
+	for _, finding := range report.Findings {
+		switch finding.Severity {
+		case "CRITICAL":
+			report.Summary.Critical++
+		case "HIGH":
+			report.Summary.High++
+		case "MEDIUM":
+			report.Summary.Medium++
+		case "LOW":
+			report.Summary.Low++
+		default:
+			report.Summary.Unknown++
+		}
+	}
+
+	return report, nil
+}
+
+// auditEcosystem 逐行读取生态系统的索引，只解析已安装包的公告
+func (a *App) auditEcosystem(ecosystem string, packages map[string][]auditedPackage) ([]VulnerabilityFinding, error) {
+	file, err := os.Open(a.osvIndexPath(ecosystem))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	findings := []VulnerabilityFinding{}
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 256*1024), 64*1024*1024)
+	for scanner.Scan() {
+		name, data, ok := strings.Cut(scanner.Text(), "\t")
+		if !ok {
+			continue
+		}
+		installed, ok := packages[name]
+		if !ok {
+			continue
+		}
+
+		var advisories []osvAdvisory
+		if err := json.Unmarshal([]byte(data), &advisories); err != nil {
+			continue
+		}
+		for _, pkg := range installed {
+			findings = append(findings, osvFindings(ecosystem, pkg, advisories)...)
+		}
+	}
+	return findings, scanner.Err()
+}
+
+// osvFindings 生成一个已安装包版本受影响的公告列表
+func osvFindings(ecosystem string, pkg auditedPackage, advisories []osvAdvisory) []VulnerabilityFinding {
+	findings := []VulnerabilityFinding{}
+	for _, advisory := range advisories {
+		if !osvAffects(advisory, pkg.version) {
+			continue
+		}
+
+		fixed := []string{}
+		target := ""
+		for _, events := range advisory.Ranges {
+			for _, event := range events {
+				if event.Fixed == "" {
+					continue
+				}
+				fixed = append(fixed, event.Fixed)
+				if compareVersions(event.Fixed, pkg.version) > 0 && (target == "" || compareVersions(event.Fixed, target) < 0) {
+					target = event.Fixed
+				}
+			}
+		}
+
+		aliases := advisory.Aliases
+		if aliases == nil {
+			aliases = []string{}
+		}
+		severity := advisory.Severity
+		if severity == "" {
+			severity = "UNKNOWN"
+		}
+		findings = append(findings, VulnerabilityFinding{
+			Ecosystem:      ecosystem,
+			Language:       pkg.language,
+			Name:           pkg.name,
+			Version:        pkg.version,
+			AdvisoryID:     advisory.ID,
+			Aliases:        aliases,
+			Summary:        advisory.Summary,
+			Severity:       severity,
+			Score:          advisory.Score,
+			FixedVersions:  fixed,
+			UpgradeCommand: osvUpgradeCommand(ecosystem, pkg.name, target),
+		})
+	}
+	return findings
+}
+
+// osvUpgradeCommand 生成升级到修复版本的命令
+// Go模块缓存中的是项目依赖，使用 go get；Maven的包名与OSV相同为 group:artifact（见 listMavenPackages、listGradlePackages），
+// 给出依赖配置片段；其他生态系统与包操作任务使用相同的安装命令
+func osvUpgradeCommand(ecosystem string, name string, version string) string {
+	if version == "" {
+		return ""
+	}
+	manager := osvEcosystems[ecosystem]
+	switch manager {
+	case "go":
+		if !strings.HasPrefix(version, "v") {
+			version = "v" + version
+		}
+		return fmt.Sprintf("go get %s@%s", name, version)
+	case "maven":
+		if group, artifact, ok := strings.Cut(name, ":"); ok {
+			return fmt.Sprintf("<dependency>\n  <groupId>%s</groupId>\n  <artifactId>%s</artifactId>\n  <version>%s</version>\n</dependency>", group, artifact, version)
+		}
+		return ""
+	}
+
+	args, _, err := buildPackageArgs(manager, "install", name, version, false)
+	if err != nil {
+		return ""
+	}
+	return strings.Join(args, " ")
+}
+
+// emitOSVUpdateEvent 推送在线更新的进度
+func (a *App) emitOSVUpdateEvent(ecosystem string, stage string, message string) {
+	if a.ctx == nil {
+		return
+	}
+	wailsruntime.EventsEmit(a.ctx, osvUpdateEventName, map[string]string{
+		"ecosystem": ecosystem,
+		"stage":     stage,
+		"message":   message,
+	})
+}
+
+// UpdateOSVDatabase 在线下载并导入OSV导出文件，ecosystems 为空时更新已导入的生态系统，
+// 尚未导入任何数据时更新全部支持的生态系统；下载使用网络设置中的代理和证书
+// 更新过程可以通过 CancelOSVUpdate 取消，已完成的生态系统保留新数据
+func (a *App) UpdateOSVDatabase(ecosystems []string) ([]OSVDatabaseStatus, error) {
+	ctx, finish, err := a.beginOSVUpdate()
+	if err != nil {
+		return nil, err
+	}
+	defer finish()
+
+	if len(ecosystems) == 0 {
+		for _, item := range a.GetOSVDatabaseStatus() {
+			ecosystems = append(ecosystems, item.Ecosystem)
+		}
+	}
+	if len(ecosystems) == 0 {
+		for ecosystem := range osvEcosystems {
+			ecosystems = append(ecosystems, ecosystem)
+		}
+		sort.Strings(ecosystems)
+	}
+
+	updated := []OSVDatabaseStatus{}
+	failures := []string{}
+	for _, ecosystem := range ecosystems {
+		if ctx.Err() != nil {
+			a.emitOSVUpdateEvent(ecosystem, "cancelled", "已取消")
+			return updated, fmt.Errorf("已取消更新漏洞数据")
+		}
+		if _, ok := osvEcosystems[ecosystem]; !ok {
+			failures = append(failures, fmt.Sprintf("%s: 不支持的生态系统", ecosystem))
+			continue
+		}
+
+		statuses, err := a.downloadOSVDump(ctx, ecosystem)
+		if ctx.Err() != nil {
+			a.emitOSVUpdateEvent(ecosystem, "cancelled", "已取消")
+			return updated, fmt.Errorf("已取消更新漏洞数据")
+		}
+		if err != nil {
+			a.emitOSVUpdateEvent(ecosystem, "error", err.Error())
+			failures = append(failures, fmt.Sprintf("%s: %v", ecosystem, err))
+			continue
+		}
+		a.emitOSVUpdateEvent(ecosystem, "done", "导入完成")
+		updated = append(updated, statuses...)
+	}
+
+	if len(failures) > 0 {
+		return updated, fmt.Errorf("部分生态系统更新失败: %s", strings.Join(failures, "；"))
+	}
+	return updated, nil
+}
+
+// downloadOSVDump 下载单个生态系统的导出文件到临时文件后导入，ctx 取消时中断下载或导入
+func (a *App) downloadOSVDump(ctx context.Context, ecosystem string) ([]OSVDatabaseStatus, error) {
+	dumpURL := osvDumpBaseURL + "/" + url.PathEscape(ecosystem) + "/all.zip"
+	a.emitOSVUpdateEvent(ecosystem, "download", "正在下载 "+dumpURL)
+
+	ctx, cancel := context.WithTimeout(ctx, osvDownloadTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dumpURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", registryUserAgent)
+
+	resp, err := newHTTPClient(0).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下载失败，状态码 %d", resp.StatusCode)
+	}
+
+	tmp, err := os.CreateTemp("", "osv-*.zip")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, resp.Body)
+	tmp.Close()
+	if err != nil {
+		return nil, fmt.Errorf("下载中断: %v", err)
+	}
+
+	a.emitOSVUpdateEvent(ecosystem, "import", "正在导入")
+	return a.importOSVZip(ctx, tmp.Name(), dumpURL)
+}
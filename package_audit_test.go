@@ -0,0 +1,125 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeOSVZip 生成与OSV导出格式相同的zip文件，每个公告一个JSON文件
+func writeOSVZip(t *testing.T, records map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "all.zip")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := zip.NewWriter(file)
+	for name, record := range records {
+		entry, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry.Write([]byte(record))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	return path
+}
+
+var osvFixtureRecords = map[string]string{
+	"GHSA-1.json": `{"id":"GHSA-1","summary":"Prototype pollution","modified":"2024-03-01T00:00:00Z","affected":[{"package":{"ecosystem":"npm","name":"lodash"},"ranges":[{"type":"SEMVER","events":[{"introduced":"0"},{"fixed":"4.17.21"}]}]}]}`,
+	"GHSA-2.json": `{"id":"GHSA-2","summary":"ReDoS","modified":"2024-05-01T00:00:00Z","affected":[{"package":{"ecosystem":"npm","name":"lodash"},"ranges":[{"type":"SEMVER","events":[{"introduced":"4.0.0"},{"fixed":"4.17.22"}]}]}]}`,
+	"GHSA-3.json": `{"id":"GHSA-3","summary":"Deserialization","modified":"2024-04-01T00:00:00Z","affected":[{"package":{"ecosystem":"Maven","name":"com.fasterxml.jackson.core:jackson-databind"},"ranges":[{"type":"ECOSYSTEM","events":[{"introduced":"0"},{"fixed":"2.9.10.8"}]}]}]}`,
+	"GHSA-4.json": `{"id":"GHSA-4","withdrawn":"2024-01-01T00:00:00Z","affected":[{"package":{"ecosystem":"npm","name":"left-pad"},"versions":["1.0.0"]}]}`,
+}
+
+func TestImportOSVZipWritesIndexPerEcosystem(t *testing.T) {
+	useTempHome(t)
+	app := NewApp()
+
+	imported, err := app.importOSVZip(context.Background(), writeOSVZip(t, osvFixtureRecords), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) != 2 || imported[0].Ecosystem != "Maven" || imported[1].Ecosystem != "npm" {
+		t.Fatalf("导入的生态系统不正确: %+v", imported)
+	}
+	if npm := imported[1]; npm.Advisories != 2 || npm.Packages != 1 || npm.LatestModified != "2024-05-01T00:00:00Z" {
+		t.Errorf("npm的统计不正确: %+v", npm)
+	}
+
+	// 同一个包的公告分多行写入，审计时全部读出
+	findings, err := app.auditEcosystem("npm", map[string][]auditedPackage{
+		"lodash": {{name: "lodash", version: "4.17.20", language: "Node.js"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 2 {
+		t.Errorf("应找到两条公告: %+v", findings)
+	}
+
+	// 临时索引文件不应残留
+	entries, _ := os.ReadDir(app.getOSVDir())
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			t.Errorf("残留临时文件: %s", entry.Name())
+		}
+	}
+}
+
+func TestImportOSVZipCancelled(t *testing.T) {
+	useTempHome(t)
+	app := NewApp()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := app.importOSVZip(ctx, writeOSVZip(t, osvFixtureRecords), "test"); err == nil {
+		t.Fatal("取消后导入应返回错误")
+	}
+	if status := app.GetOSVDatabaseStatus(); len(status) != 0 {
+		t.Errorf("取消的导入不应修改状态: %+v", status)
+	}
+	entries, _ := os.ReadDir(app.getOSVDir())
+	if len(entries) != 0 {
+		t.Errorf("取消的导入不应留下文件: %v", entries)
+	}
+}
+
+func TestCancelOSVUpdate(t *testing.T) {
+	app := NewApp()
+	if app.CancelOSVUpdate() {
+		t.Error("没有进行中的更新时应返回false")
+	}
+
+	ctx, finish, err := app.beginOSVUpdate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := app.beginOSVUpdate(); err == nil {
+		t.Error("同一时间只允许一次更新")
+	}
+	if !app.CancelOSVUpdate() || ctx.Err() == nil {
+		t.Error("取消应结束更新的上下文")
+	}
+	finish()
+
+	if _, finish, err := app.beginOSVUpdate(); err != nil {
+		t.Errorf("上次更新结束后应可以重新开始: %v", err)
+	} else {
+		finish()
+	}
+}
+
+func TestOSVUpgradeCommandMaven(t *testing.T) {
+	command := osvUpgradeCommand("Maven", "com.fasterxml.jackson.core:jackson-databind", "2.9.10.8")
+	if !strings.Contains(command, "<groupId>com.fasterxml.jackson.core</groupId>") || !strings.Contains(command, "<artifactId>jackson-databind</artifactId>") {
+		t.Errorf("Maven依赖片段不正确: %s", command)
+	}
+}
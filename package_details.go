@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// packageDetailsCacheTTL 缓存的包详情在这段时间内直接使用，不再请求注册表
+const packageDetailsCacheTTL = 6 * time.Hour
+
+// PackageVersion 包的一个已发布版本
+type PackageVersion struct {
+	Version    string `json:"version"`
+	ReleasedAt string `json:"releasedAt,omitempty"`
+	Prerelease bool   `json:"prerelease,omitempty"`
+	// Yanked 已撤回或取消列出（cargo yank、PyPI yank、NuGet unlist、Hex retire）
+	Yanked     bool `json:"yanked,omitempty"`
+	Deprecated bool `json:"deprecated,omitempty"`
+	// Note 撤回或废弃的原因
+	Note string `json:"note,omitempty"`
+}
+
+// PackageDependency 包声明的依赖
+type PackageDependency struct {
+	Name        string `json:"name"`
+	Requirement string `json:"requirement,omitempty"`
+	// Kind 依赖类型：runtime、dev、build、optional、peer、indirect
+	Kind string `json:"kind"`
+	// Target 依赖所属的目标框架或平台（如NuGet的 net8.0），没有时为空
+	Target string `json:"target,omitempty"`
+}
+
+// PackageDetails 包的详细信息，字段以注册表元数据接口为准，注册表不提供的字段为空
+type PackageDetails struct {
+	Manager       string `json:"manager"`
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	LatestVersion string `json:"latestVersion"`
+	License       string `json:"license,omitempty"`
+	Homepage      string `json:"homepage,omitempty"`
+	Repository    string `json:"repository,omitempty"`
+	Author        string `json:"author,omitempty"`
+	// WeeklyDownloads 最近一周的下载量，只有npm、Hex和DUB提供
+	WeeklyDownloads int64 `json:"weeklyDownloads,omitempty"`
+	TotalDownloads  int64 `json:"totalDownloads,omitempty"`
+	// Versions 所有已发布的版本，从新到旧排列
+	Versions []PackageVersion `json:"versions"`
+	// Dependencies 最新版本声明的依赖
+	Dependencies []PackageDependency `json:"dependencies"`
+	// Runtimes 支持的运行时或语言版本，如 node >=18、python >=3.8、net8.0
+	Runtimes           []string `json:"runtimes"`
+	Keywords           []string `json:"keywords,omitempty"`
+	Deprecated         bool     `json:"deprecated,omitempty"`
+	DeprecationMessage string   `json:"deprecationMessage,omitempty"`
+	FetchedAt          string   `json:"fetchedAt"`
+	FromCache          bool     `json:"fromCache,omitempty"`
+	// Stale 为true时表示注册表请求失败，返回的是过期的缓存，Warning 中是失败原因
+	Stale   bool   `json:"stale,omitempty"`
+	Warning string `json:"warning,omitempty"`
+}
+
+// packageDetailsFetchers 返回各包管理器获取详情的实现
+func (a *App) packageDetailsFetchers() map[string]func(ctx context.Context, name string) (*PackageDetails, error) {
+	return map[string]func(ctx context.Context, name string) (*PackageDetails, error){
+		"npm":      a.fetchNpmDetails,
+		"pip":      a.fetchPipDetails,
+		"gem":      a.fetchGemDetails,
+		"cargo":    a.fetchCargoDetails,
+		"composer": a.fetchComposerDetails,
+		"nuget":    a.fetchNuGetDetails,
+		"maven":    a.fetchMavenDetails,
+		"go":       a.fetchGoDetails,
+		"hex":      a.fetchHexDetails,
+		"dub":      a.fetchDubDetails,
+		"nimble":   a.fetchNimbleDetails,
+		"brew":     a.fetchBrewDetails,
+	}
+}
+
+// getPackageCacheDir 获取包详情缓存目录
+func (a *App) getPackageCacheDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "package_cache"
+	}
+	return filepath.Join(homeDir, ".networ_tester", "package_cache")
+}
+
+// packageDetailsCachePath 缓存文件按包名的摘要命名，避免包名中的 / 和 : 等字符
+func (a *App) packageDetailsCachePath(manager string, name string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(name)))
+	return filepath.Join(a.getPackageCacheDir(), manager, hex.EncodeToString(sum[:12])+".json")
+}
+
+// readPackageDetailsCache 读取缓存的包详情，不存在或无法解析时返回nil
+func (a *App) readPackageDetailsCache(manager string, name string) *PackageDetails {
+	data, err := os.ReadFile(a.packageDetailsCachePath(manager, name))
+	if err != nil {
+		return nil
+	}
+	var details PackageDetails
+	if err := json.Unmarshal(data, &details); err != nil {
+		return nil
+	}
+	return &details
+}
+
+// writePackageDetailsCache 按查询时的名称保存包详情到缓存
+func (a *App) writePackageDetailsCache(manager string, name string, details *PackageDetails) {
+	data, err := json.Marshal(details)
+	if err != nil {
+		return
+	}
+	path := a.packageDetailsCachePath(manager, name)
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		fmt.Printf("保存包详情缓存失败: %v\n", err)
+	}
+}
+
+// GetPackageDetails 获取包的详细信息，优先使用未过期的本地缓存
+// 注册表请求失败时如果有过期缓存则返回缓存并标记 Stale
+func (a *App) GetPackageDetails(manager string, name string) (PackageDetails, error) {
+	return a.getPackageDetails(manager, name, false)
+}
+
+// RefreshPackageDetails 忽略缓存，重新从注册表获取包的详细信息
+func (a *App) RefreshPackageDetails(manager string, name string) (PackageDetails, error) {
+	return a.getPackageDetails(manager, name, true)
+}
+
+// getPackageDetails 获取包详情，refresh 为true时跳过缓存
+func (a *App) getPackageDetails(manager string, name string, refresh bool) (PackageDetails, error) {
+	name = strings.TrimSpace(name)
+	fetch, ok := a.packageDetailsFetchers()[manager]
+	if !ok {
+		return PackageDetails{}, fmt.Errorf("不支持的包管理器: %s", manager)
+	}
+	if name == "" {
+		return PackageDetails{}, fmt.Errorf("包名称不能为空")
+	}
+
+	cached := a.readPackageDetailsCache(manager, name)
+	if cached != nil && !refresh {
+		if fetchedAt, err := time.Parse(time.RFC3339, cached.FetchedAt); err == nil && time.Since(fetchedAt) < packageDetailsCacheTTL {
+			cached.FromCache = true
+			return *cached, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	details, err := fetch(ctx, name)
+	if err != nil {
+		if errors.Is(err, errRegistryNotFound) {
+			return PackageDetails{}, fmt.Errorf("%s 中不存在包 %s", manager, name)
+		}
+		if cached != nil {
+			cached.FromCache = true
+			cached.Stale = true
+			cached.Warning = err.Error()
+			return *cached, nil
+		}
+		return PackageDetails{}, err
+	}
+
+	details.Manager = manager
+	if details.Name == "" {
+		details.Name = name
+	}
+	finishPackageDetails(details)
+	details.FetchedAt = time.Now().UTC().Format(time.RFC3339)
+
+	a.writePackageDetailsCache(manager, name, details)
+
+	return *details, nil
+}
+
+// finishPackageDetails 排序版本、补全最新版本和空列表，保证返回给前端的字段一致
+func finishPackageDetails(details *PackageDetails) {
+	if details.Versions == nil {
+		details.Versions = []PackageVersion{}
+	}
+	if details.Dependencies == nil {
+		details.Dependencies = []PackageDependency{}
+	}
+	if details.Runtimes == nil {
+		details.Runtimes = []string{}
+	}
+
+	for i := range details.Versions {
+		details.Versions[i].Prerelease = isPrereleaseVersion(details.Versions[i].Version)
+	}
+	sort.SliceStable(details.Versions, func(i, j int) bool {
+		return compareVersions(details.Versions[i].Version, details.Versions[j].Version) > 0
+	})
+
+	if details.LatestVersion == "" {
+		for _, version := range details.Versions {
+			if !version.Yanked && !version.Prerelease {
+				details.LatestVersion = version.Version
+				break
+			}
+		}
+	}
+	if details.LatestVersion == "" && len(details.Versions) > 0 {
+		details.LatestVersion = details.Versions[0].Version
+	}
+
+	details.License = strings.TrimSpace(details.License)
+	details.Repository = normalizeRepositoryURL(details.Repository)
+	details.Homepage = strings.TrimSpace(details.Homepage)
+}
+
+// jsonStringField 读取可能是字符串也可能是对象的字段，如npm的 author 和 repository
+func jsonStringField(raw json.RawMessage, field string) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+	var object map[string]interface{}
+	if json.Unmarshal(raw, &object) == nil {
+		if value, ok := object[field].(string); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// shorthandRepositoryPattern npm等注册表中 github:user/repo 或 user/repo 形式的仓库简写
+var shorthandRepositoryPattern = regexp.MustCompile(`^(?:(github|gitlab|bitbucket):)?([A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+)$`)
+
+// normalizeRepositoryURL 把 git+https、git@、简写等仓库地址转换为可以在浏览器打开的地址
+func normalizeRepositoryURL(repository string) string {
+	repository = strings.TrimSpace(repository)
+	if repository == "" {
+		return ""
+	}
+
+	if match := shorthandRepositoryPattern.FindStringSubmatch(repository); match != nil {
+		host := "github.com"
+		switch match[1] {
+		case "gitlab":
+			host = "gitlab.com"
+		case "bitbucket":
+			host = "bitbucket.org"
+		}
+		return "https://" + host + "/" + match[2]
+	}
+
+	repository = strings.TrimPrefix(repository, "git+")
+	repository = strings.TrimPrefix(repository, "scm:git:")
+	if strings.HasPrefix(repository, "git@") {
+		repository = "https://" + strings.Replace(strings.TrimPrefix(repository, "git@"), ":", "/", 1)
+	}
+	for _, prefix := range []string{"git://", "ssh://git@"} {
+		if strings.HasPrefix(repository, prefix) {
+			repository = "https://" + strings.TrimPrefix(repository, prefix)
+		}
+	}
+	repository = strings.TrimSuffix(repository, ".git")
+	return strings.TrimRight(repository, "/")
+}
+
+// pickURL 从名称到地址的映射中按关键字（不区分大小写）找第一个地址，用于PyPI的 project_urls、Hex的 links 等
+func pickURL(urls map[string]string, keywords ...string) string {
+	names := make([]string, 0, len(urls))
+	for name := range urls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, keyword := range keywords {
+		for _, name := range names {
+			if strings.Contains(strings.ToLower(name), keyword) && urls[name] != "" {
+				return urls[name]
+			}
+		}
+	}
+	return ""
+}
+
+// sortedDependencies 把依赖名称到版本要求的映射转换为按名称排序的列表
+func sortedDependencies(requirements map[string]string, kind string) []PackageDependency {
+	names := make([]string, 0, len(requirements))
+	for name := range requirements {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	dependencies := []PackageDependency{}
+	for _, name := range names {
+		dependencies = append(dependencies, PackageDependency{Name: name, Requirement: requirements[name], Kind: kind})
+	}
+	return dependencies
+}
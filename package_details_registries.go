@@ -0,0 +1,1216 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 以下接口与搜索接口不在同一个地址，不受注册表地址设置影响
+const (
+	npmDownloadsAPIURL   = "https://api.npmjs.org"
+	nugetRegistrationURL = "https://api.nuget.org/v3/registration5-gz-semver2"
+	mavenRepositoryURL   = "https://repo1.maven.org/maven2"
+	homebrewAPIURL       = "https://formulae.brew.sh/api"
+)
+
+// goVersionInfoLimit Go模块代理需要逐个版本查询发布时间，只查询最新的这些版本
+const goVersionInfoLimit = 50
+
+// externalRegistryClient 创建访问注册表附属接口（下载统计、元数据仓库等）的客户端
+func externalRegistryClient(manager string, baseURL string) registryClient {
+	return registryClient{
+		manager: manager,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  newHTTPClient(15 * time.Second),
+	}
+}
+
+// fetchNpmDetails 读取npm注册表中包的完整文档，周下载量来自npm下载统计接口
+func (a *App) fetchNpmDetails(ctx context.Context, name string) (*PackageDetails, error) {
+	var doc struct {
+		Name        string            `json:"name"`
+		Description string            `json:"description"`
+		DistTags    map[string]string `json:"dist-tags"`
+		License     json.RawMessage   `json:"license"`
+		Homepage    string            `json:"homepage"`
+		Repository  json.RawMessage   `json:"repository"`
+		Author      json.RawMessage   `json:"author"`
+		Keywords    []string          `json:"keywords"`
+		Time        map[string]string `json:"time"`
+		Versions    map[string]struct {
+			License              json.RawMessage   `json:"license"`
+			Dependencies         map[string]string `json:"dependencies"`
+			DevDependencies      map[string]string `json:"devDependencies"`
+			PeerDependencies     map[string]string `json:"peerDependencies"`
+			OptionalDependencies map[string]string `json:"optionalDependencies"`
+			Engines              json.RawMessage   `json:"engines"`
+			Deprecated           json.RawMessage   `json:"deprecated"`
+		} `json:"versions"`
+	}
+
+	// 带作用域的包名中的 / 需要编码为 %2F
+	if err := a.registryClient("npm").getJSON(ctx, "/"+url.PathEscape(name), nil, &doc); err != nil {
+		return nil, err
+	}
+
+	details := &PackageDetails{
+		Name:          doc.Name,
+		Description:   doc.Description,
+		LatestVersion: doc.DistTags["latest"],
+		License:       jsonStringField(doc.License, "type"),
+		Homepage:      doc.Homepage,
+		Repository:    jsonStringField(doc.Repository, "url"),
+		Author:        jsonStringField(doc.Author, "name"),
+		Keywords:      doc.Keywords,
+	}
+
+	for version, info := range doc.Versions {
+		entry := PackageVersion{Version: version, ReleasedAt: normalizeRegistryTime(doc.Time[version])}
+		var message string
+		if json.Unmarshal(info.Deprecated, &message) == nil && message != "" {
+			entry.Deprecated = true
+			entry.Note = message
+		}
+		details.Versions = append(details.Versions, entry)
+	}
+
+	if latest, ok := doc.Versions[details.LatestVersion]; ok {
+		if license := jsonStringField(latest.License, "type"); license != "" {
+			details.License = license
+		}
+		details.Dependencies = append(details.Dependencies, sortedDependencies(latest.Dependencies, "runtime")...)
+		details.Dependencies = append(details.Dependencies, sortedDependencies(latest.PeerDependencies, "peer")...)
+		details.Dependencies = append(details.Dependencies, sortedDependencies(latest.OptionalDependencies, "optional")...)
+		details.Dependencies = append(details.Dependencies, sortedDependencies(latest.DevDependencies, "dev")...)
+
+		// 早期的包中 engines 可能是数组，无法解析时忽略
+		var engines map[string]string
+		if json.Unmarshal(latest.Engines, &engines) == nil {
+			for _, dependency := range sortedDependencies(engines, "") {
+				details.Runtimes = append(details.Runtimes, strings.TrimSpace(dependency.Name+" "+dependency.Requirement))
+			}
+		}
+
+		var message string
+		if json.Unmarshal(latest.Deprecated, &message) == nil && message != "" {
+			details.Deprecated = true
+			details.DeprecationMessage = message
+		}
+	}
+
+	var downloads struct {
+		Downloads int64 `json:"downloads"`
+	}
+	if err := externalRegistryClient("npm", npmDownloadsAPIURL).getJSON(ctx, "/downloads/point/last-week/"+name, nil, &downloads); err == nil {
+		details.WeeklyDownloads = downloads.Downloads
+	}
+
+	return details, nil
+}
+
+// pipRequirementPattern 拆分 requires_dist 中的包名和版本要求，如 "urllib3 (<3,>=1.21.1) ; extra == 'socks'"
+var pipRequirementPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)(\[[^\]]*\])?\s*\(?([^;)]*)\)?\s*(?:;(.*))?$`)
+
+// fetchPipDetails 读取PyPI的JSON接口，PyPI不提供下载量
+func (a *App) fetchPipDetails(ctx context.Context, name string) (*PackageDetails, error) {
+	var doc struct {
+		Info struct {
+			Name           string            `json:"name"`
+			Version        string            `json:"version"`
+			Summary        string            `json:"summary"`
+			License        string            `json:"license"`
+			LicenseExpr    string            `json:"license_expression"`
+			HomePage       string            `json:"home_page"`
+			ProjectURLs    map[string]string `json:"project_urls"`
+			Author         string            `json:"author"`
+			AuthorEmail    string            `json:"author_email"`
+			Maintainer     string            `json:"maintainer"`
+			Keywords       string            `json:"keywords"`
+			RequiresDist   []string          `json:"requires_dist"`
+			RequiresPython string            `json:"requires_python"`
+			Classifiers    []string          `json:"classifiers"`
+		} `json:"info"`
+		Releases map[string][]struct {
+			UploadTime   string `json:"upload_time_iso_8601"`
+			Yanked       bool   `json:"yanked"`
+			YankedReason string `json:"yanked_reason"`
+		} `json:"releases"`
+	}
+
+	if err := a.registryClient("pip").getJSON(ctx, "/pypi/"+url.PathEscape(name)+"/json", nil, &doc); err != nil {
+		return nil, err
+	}
+
+	info := doc.Info
+	details := &PackageDetails{
+		Name:          info.Name,
+		Description:   info.Summary,
+		LatestVersion: info.Version,
+		License:       info.LicenseExpr,
+		Homepage:      info.HomePage,
+		Repository:    pickURL(info.ProjectURLs, "source", "repository", "code", "github", "gitlab"),
+		Author:        info.Author,
+	}
+	if details.Homepage == "" {
+		details.Homepage = pickURL(info.ProjectURLs, "homepage", "home", "documentation")
+	}
+	if details.Author == "" {
+		details.Author = info.AuthorEmail
+	}
+	if details.Author == "" {
+		details.Author = info.Maintainer
+	}
+	if details.License == "" {
+		// license 字段有时是完整的许可证文本，只取第一行
+		details.License = strings.SplitN(strings.TrimSpace(info.License), "\n", 2)[0]
+		if len(details.License) > 80 {
+			details.License = ""
+		}
+	}
+	for _, classifier := range info.Classifiers {
+		if details.License == "" && strings.HasPrefix(classifier, "License :: ") {
+			parts := strings.Split(classifier, " :: ")
+			details.License = parts[len(parts)-1]
+		}
+		if classifier == "Development Status :: 7 - Inactive" {
+			details.Deprecated = true
+			details.DeprecationMessage = "项目已标记为不再维护（Development Status :: 7 - Inactive）"
+		}
+		if strings.HasPrefix(classifier, "Programming Language :: Python :: ") && strings.Count(classifier, "::") == 2 {
+			version := strings.TrimPrefix(classifier, "Programming Language :: Python :: ")
+			if strings.Contains(version, ".") {
+				details.Runtimes = append(details.Runtimes, "python "+version)
+			}
+		}
+	}
+	if info.RequiresPython != "" {
+		details.Runtimes = append([]string{"python " + info.RequiresPython}, details.Runtimes...)
+	}
+	if info.Keywords != "" {
+		details.Keywords = strings.FieldsFunc(info.Keywords, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+
+	for version, files := range doc.Releases {
+		entry := PackageVersion{Version: version}
+		yanked := len(files) > 0
+		for _, file := range files {
+			if entry.ReleasedAt == "" || normalizeRegistryTime(file.UploadTime) < entry.ReleasedAt {
+				entry.ReleasedAt = normalizeRegistryTime(file.UploadTime)
+			}
+			if !file.Yanked {
+				yanked = false
+			} else if file.YankedReason != "" {
+				entry.Note = file.YankedReason
+			}
+		}
+		entry.Yanked = yanked
+		details.Versions = append(details.Versions, entry)
+	}
+
+	for _, requirement := range info.RequiresDist {
+		match := pipRequirementPattern.FindStringSubmatch(strings.TrimSpace(requirement))
+		if match == nil {
+			continue
+		}
+		kind := "runtime"
+		if strings.Contains(match[4], "extra") {
+			kind = "optional"
+		}
+		details.Dependencies = append(details.Dependencies, PackageDependency{
+			Name:        match[1],
+			Requirement: strings.TrimSpace(match[3]),
+			Kind:        kind,
+			Target:      strings.TrimSpace(match[4]),
+		})
+	}
+
+	return details, nil
+}
+
+// fetchGemDetails 读取RubyGems的gem信息和版本列表，已撤回的版本不会出现在列表中
+func (a *App) fetchGemDetails(ctx context.Context, name string) (*PackageDetails, error) {
+	type gemDependency struct {
+		Name         string `json:"name"`
+		Requirements string `json:"requirements"`
+	}
+	var gem struct {
+		Name          string   `json:"name"`
+		Version       string   `json:"version"`
+		Info          string   `json:"info"`
+		Licenses      []string `json:"licenses"`
+		HomepageURI   string   `json:"homepage_uri"`
+		SourceCodeURI string   `json:"source_code_uri"`
+		Authors       string   `json:"authors"`
+		Downloads     int64    `json:"downloads"`
+		Dependencies  struct {
+			Runtime     []gemDependency `json:"runtime"`
+			Development []gemDependency `json:"development"`
+		} `json:"dependencies"`
+	}
+
+	client := a.registryClient("gem")
+	if err := client.getJSON(ctx, "/api/v1/gems/"+url.PathEscape(name)+".json", nil, &gem); err != nil {
+		return nil, err
+	}
+
+	details := &PackageDetails{
+		Name:           gem.Name,
+		Description:    gem.Info,
+		LatestVersion:  gem.Version,
+		License:        strings.Join(gem.Licenses, ", "),
+		Homepage:       gem.HomepageURI,
+		Repository:     gem.SourceCodeURI,
+		Author:         gem.Authors,
+		TotalDownloads: gem.Downloads,
+	}
+	for _, dependency := range gem.Dependencies.Runtime {
+		details.Dependencies = append(details.Dependencies, PackageDependency{Name: dependency.Name, Requirement: dependency.Requirements, Kind: "runtime"})
+	}
+	for _, dependency := range gem.Dependencies.Development {
+		details.Dependencies = append(details.Dependencies, PackageDependency{Name: dependency.Name, Requirement: dependency.Requirements, Kind: "dev"})
+	}
+
+	var versions []struct {
+		Number      string `json:"number"`
+		CreatedAt   string `json:"created_at"`
+		RubyVersion string `json:"ruby_version"`
+	}
+	if err := client.getJSON(ctx, "/api/v1/versions/"+url.PathEscape(name)+".json", nil, &versions); err != nil {
+		return nil, err
+	}
+	for _, version := range versions {
+		details.Versions = append(details.Versions, PackageVersion{Version: version.Number, ReleasedAt: normalizeRegistryTime(version.CreatedAt)})
+		if version.Number == gem.Version && version.RubyVersion != "" {
+			details.Runtimes = append(details.Runtimes, "ruby "+version.RubyVersion)
+		}
+	}
+
+	return details, nil
+}
+
+// fetchCargoDetails 读取crates.io的crate信息和最新版本的依赖，配置为稀疏索引镜像时从索引读取
+func (a *App) fetchCargoDetails(ctx context.Context, name string) (*PackageDetails, error) {
+	client := a.registryClient("cargo")
+	if strings.HasPrefix(client.baseURL, sparseIndexPrefix) {
+		return fetchCargoSparseIndexDetails(ctx, client, name)
+	}
+
+	var doc struct {
+		Crate struct {
+			Name             string   `json:"name"`
+			Description      string   `json:"description"`
+			Homepage         string   `json:"homepage"`
+			Repository       string   `json:"repository"`
+			Documentation    string   `json:"documentation"`
+			Downloads        int64    `json:"downloads"`
+			MaxStableVersion string   `json:"max_stable_version"`
+			Keywords         []string `json:"keywords"`
+		} `json:"crate"`
+		Versions []struct {
+			Num         string `json:"num"`
+			CreatedAt   string `json:"created_at"`
+			Yanked      bool   `json:"yanked"`
+			License     string `json:"license"`
+			RustVersion string `json:"rust_version"`
+			PublishedBy *struct {
+				Name  string `json:"name"`
+				Login string `json:"login"`
+			} `json:"published_by"`
+		} `json:"versions"`
+	}
+
+	if err := client.getJSON(ctx, "/api/v1/crates/"+url.PathEscape(name), nil, &doc); err != nil {
+		return nil, err
+	}
+
+	details := &PackageDetails{
+		Name:           doc.Crate.Name,
+		Description:    doc.Crate.Description,
+		LatestVersion:  doc.Crate.MaxStableVersion,
+		Homepage:       doc.Crate.Homepage,
+		Repository:     doc.Crate.Repository,
+		TotalDownloads: doc.Crate.Downloads,
+		Keywords:       doc.Crate.Keywords,
+	}
+	if details.Homepage == "" {
+		details.Homepage = doc.Crate.Documentation
+	}
+
+	for _, version := range doc.Versions {
+		details.Versions = append(details.Versions, PackageVersion{
+			Version:    version.Num,
+			ReleasedAt: normalizeRegistryTime(version.CreatedAt),
+			Yanked:     version.Yanked,
+		})
+		if version.Num != details.LatestVersion {
+			continue
+		}
+		details.License = version.License
+		if version.RustVersion != "" {
+			details.Runtimes = append(details.Runtimes, "rust >= "+version.RustVersion)
+		}
+		if version.PublishedBy != nil {
+			details.Author = version.PublishedBy.Name
+			if details.Author == "" {
+				details.Author = version.PublishedBy.Login
+			}
+		}
+	}
+
+	if details.LatestVersion != "" {
+		var deps struct {
+			Dependencies []struct {
+				CrateID  string `json:"crate_id"`
+				Req      string `json:"req"`
+				Kind     string `json:"kind"`
+				Optional bool   `json:"optional"`
+				Target   string `json:"target"`
+			} `json:"dependencies"`
+		}
+		path := "/api/v1/crates/" + url.PathEscape(name) + "/" + url.PathEscape(details.LatestVersion) + "/dependencies"
+		if err := client.getJSON(ctx, path, nil, &deps); err == nil {
+			for _, dependency := range deps.Dependencies {
+				details.Dependencies = append(details.Dependencies, PackageDependency{
+					Name:        dependency.CrateID,
+					Requirement: dependency.Req,
+					Kind:        cargoDependencyKind(dependency.Kind, dependency.Optional),
+					Target:      dependency.Target,
+				})
+			}
+		}
+	}
+
+	return details, nil
+}
+
+// cargoDependencyKind 把cargo的依赖类型转换为统一的类型名称
+func cargoDependencyKind(kind string, optional bool) string {
+	switch {
+	case kind == "dev":
+		return "dev"
+	case kind == "build":
+		return "build"
+	case optional:
+		return "optional"
+	}
+	return "runtime"
+}
+
+// fetchCargoSparseIndexDetails 从稀疏索引读取crate的版本和依赖，索引中没有发布时间、许可证和描述
+func fetchCargoSparseIndexDetails(ctx context.Context, client registryClient, name string) (*PackageDetails, error) {
+	if strings.ContainsAny(name, "/\\ ") {
+		return nil, errRegistryNotFound
+	}
+
+	client.baseURL = strings.TrimPrefix(client.baseURL, sparseIndexPrefix)
+	body, err := client.get(ctx, cargoSparseIndexPath(name), nil, "text/plain")
+	if err != nil {
+		return nil, err
+	}
+
+	type indexDependency struct {
+		Name     string `json:"name"`
+		Req      string `json:"req"`
+		Kind     string `json:"kind"`
+		Optional bool   `json:"optional"`
+		Target   string `json:"target"`
+		// Package 依赖被重命名时为真实的crate名称
+		Package string `json:"package"`
+	}
+	type indexEntry struct {
+		Name        string            `json:"name"`
+		Version     string            `json:"vers"`
+		Yanked      bool              `json:"yanked"`
+		RustVersion string            `json:"rust_version"`
+		Deps        []indexDependency `json:"deps"`
+	}
+
+	details := &PackageDetails{}
+	entries := map[string]indexEntry{}
+	scanner := bufio.NewScanner(strings.NewReader(string(body)))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var entry indexEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+		details.Name = entry.Name
+		entries[entry.Version] = entry
+		details.Versions = append(details.Versions, PackageVersion{Version: entry.Version, Yanked: entry.Yanked})
+	}
+
+	// 先排序确定最新版本，再读取该版本的依赖
+	finishPackageDetails(details)
+	if latest, ok := entries[details.LatestVersion]; ok {
+		if latest.RustVersion != "" {
+			details.Runtimes = append(details.Runtimes, "rust >= "+latest.RustVersion)
+		}
+		for _, dependency := range latest.Deps {
+			dependencyName := dependency.Name
+			if dependency.Package != "" {
+				dependencyName = dependency.Package
+			}
+			details.Dependencies = append(details.Dependencies, PackageDependency{
+				Name:        dependencyName,
+				Requirement: dependency.Req,
+				Kind:        cargoDependencyKind(dependency.Kind, dependency.Optional),
+				Target:      dependency.Target,
+			})
+		}
+	}
+	return details, nil
+}
+
+// fetchComposerDetails 读取Packagist的包信息，开发分支（dev-*）不计入版本列表
+func (a *App) fetchComposerDetails(ctx context.Context, name string) (*PackageDetails, error) {
+	var doc struct {
+		Package struct {
+			Name        string          `json:"name"`
+			Description string          `json:"description"`
+			Repository  string          `json:"repository"`
+			Abandoned   json.RawMessage `json:"abandoned"`
+			Downloads   struct {
+				Total int64 `json:"total"`
+			} `json:"downloads"`
+			Versions map[string]struct {
+				Version    string            `json:"version"`
+				License    []string          `json:"license"`
+				Homepage   string            `json:"homepage"`
+				Time       string            `json:"time"`
+				Keywords   []string          `json:"keywords"`
+				Require    map[string]string `json:"require"`
+				RequireDev map[string]string `json:"require-dev"`
+				Suggest    map[string]string `json:"suggest"`
+				Authors    []struct {
+					Name string `json:"name"`
+				} `json:"authors"`
+			} `json:"versions"`
+		} `json:"package"`
+	}
+
+	if !strings.Contains(name, "/") {
+		return nil, fmt.Errorf("Composer包名称格式为 vendor/package")
+	}
+	if err := a.registryClient("composer").getJSON(ctx, "/packages/"+name+".json", nil, &doc); err != nil {
+		return nil, err
+	}
+
+	pkg := doc.Package
+	details := &PackageDetails{
+		Name:           pkg.Name,
+		Description:    pkg.Description,
+		Repository:     pkg.Repository,
+		TotalDownloads: pkg.Downloads.Total,
+	}
+
+	// abandoned 为true或替代包的名称
+	var abandoned bool
+	var replacement string
+	if json.Unmarshal(pkg.Abandoned, &replacement) == nil && replacement != "" {
+		details.Deprecated = true
+		details.DeprecationMessage = "已弃用，建议改用 " + replacement
+	} else if json.Unmarshal(pkg.Abandoned, &abandoned) == nil && abandoned {
+		details.Deprecated = true
+		details.DeprecationMessage = "已弃用"
+	}
+
+	for key, version := range pkg.Versions {
+		if strings.HasPrefix(key, "dev-") || strings.HasSuffix(key, "-dev") {
+			continue
+		}
+		details.Versions = append(details.Versions, PackageVersion{Version: key, ReleasedAt: normalizeRegistryTime(version.Time)})
+	}
+
+	finishPackageDetails(details)
+	if latest, ok := pkg.Versions[details.LatestVersion]; ok {
+		details.License = strings.Join(latest.License, ", ")
+		details.Homepage = latest.Homepage
+		details.Keywords = latest.Keywords
+		authors := []string{}
+		for _, author := range latest.Authors {
+			authors = append(authors, author.Name)
+		}
+		details.Author = strings.Join(authors, ", ")
+
+		// php 和扩展（ext-*）是运行环境要求，其余是包依赖
+		requires := map[string]string{}
+		for dependency, requirement := range latest.Require {
+			if dependency == "php" || strings.HasPrefix(dependency, "ext-") {
+				details.Runtimes = append(details.Runtimes, dependency+" "+requirement)
+				continue
+			}
+			requires[dependency] = requirement
+		}
+		sort.Strings(details.Runtimes)
+		details.Dependencies = append(details.Dependencies, sortedDependencies(requires, "runtime")...)
+		details.Dependencies = append(details.Dependencies, sortedDependencies(latest.RequireDev, "dev")...)
+		suggests := map[string]string{}
+		for dependency := range latest.Suggest {
+			suggests[dependency] = ""
+		}
+		details.Dependencies = append(details.Dependencies, sortedDependencies(suggests, "optional")...)
+	}
+
+	return details, nil
+}
+
+// nugetCatalogEntry NuGet注册信息中一个版本的目录项
+type nugetCatalogEntry struct {
+	ID                string          `json:"id"`
+	Version           string          `json:"version"`
+	Description       string          `json:"description"`
+	Authors           string          `json:"authors"`
+	Published         string          `json:"published"`
+	Listed            *bool           `json:"listed"`
+	LicenseExpression string          `json:"licenseExpression"`
+	LicenseURL        string          `json:"licenseUrl"`
+	ProjectURL        string          `json:"projectUrl"`
+	Tags              json.RawMessage `json:"tags"`
+	Deprecation       *struct {
+		Reasons []string `json:"reasons"`
+		Message string   `json:"message"`
+	} `json:"deprecation"`
+	DependencyGroups []struct {
+		TargetFramework string `json:"targetFramework"`
+		Dependencies    []struct {
+			ID    string `json:"id"`
+			Range string `json:"range"`
+		} `json:"dependencies"`
+	} `json:"dependencyGroups"`
+}
+
+// nugetRegistrationPage NuGet注册信息的一页，版本较多时页内的版本需要单独请求
+type nugetRegistrationPage struct {
+	ID    string `json:"@id"`
+	Items []struct {
+		CatalogEntry nugetCatalogEntry `json:"catalogEntry"`
+	} `json:"items"`
+}
+
+// fetchNuGetDetails 读取NuGet的注册信息，总下载量来自搜索接口
+func (a *App) fetchNuGetDetails(ctx context.Context, name string) (*PackageDetails, error) {
+	var index struct {
+		Items []nugetRegistrationPage `json:"items"`
+	}
+
+	client := externalRegistryClient("nuget", nugetRegistrationURL)
+	if err := client.getJSON(ctx, "/"+url.PathEscape(strings.ToLower(name))+"/index.json", nil, &index); err != nil {
+		return nil, err
+	}
+
+	entries := []nugetCatalogEntry{}
+	for _, page := range index.Items {
+		if len(page.Items) == 0 && page.ID != "" {
+			// 未内联的页使用完整地址请求
+			if err := externalRegistryClient("nuget", "").getJSON(ctx, page.ID, nil, &page); err != nil {
+				return nil, err
+			}
+		}
+		for _, item := range page.Items {
+			entries = append(entries, item.CatalogEntry)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, errRegistryNotFound
+	}
+
+	details := &PackageDetails{}
+	for _, entry := range entries {
+		// 取消列出的版本 listed 为false，旧数据中发布时间为1900年
+		version := PackageVersion{
+			Version:    entry.Version,
+			ReleasedAt: normalizeRegistryTime(entry.Published),
+			Yanked:     (entry.Listed != nil && !*entry.Listed) || strings.HasPrefix(entry.Published, "1900-"),
+		}
+		if version.Yanked {
+			version.ReleasedAt = ""
+		}
+		if entry.Deprecation != nil {
+			version.Deprecated = true
+			version.Note = strings.TrimSpace(strings.Join(entry.Deprecation.Reasons, ", ") + " " + entry.Deprecation.Message)
+		}
+		details.Versions = append(details.Versions, version)
+	}
+
+	finishPackageDetails(details)
+	for _, entry := range entries {
+		if entry.Version != details.LatestVersion {
+			continue
+		}
+		details.Name = entry.ID
+		details.Description = entry.Description
+		details.Author = entry.Authors
+		details.License = entry.LicenseExpression
+		if details.License == "" {
+			details.License = entry.LicenseURL
+		}
+		details.Homepage = entry.ProjectURL
+		if strings.Contains(entry.ProjectURL, "github.com/") {
+			details.Repository = entry.ProjectURL
+		}
+		var tags []string
+		if json.Unmarshal(entry.Tags, &tags) == nil {
+			details.Keywords = tags
+		}
+		if entry.Deprecation != nil {
+			details.Deprecated = true
+			details.DeprecationMessage = strings.TrimSpace(strings.Join(entry.Deprecation.Reasons, ", ") + " " + entry.Deprecation.Message)
+		}
+		for _, group := range entry.DependencyGroups {
+			if group.TargetFramework != "" {
+				details.Runtimes = append(details.Runtimes, group.TargetFramework)
+			}
+			for _, dependency := range group.Dependencies {
+				details.Dependencies = append(details.Dependencies, PackageDependency{
+					Name:        dependency.ID,
+					Requirement: dependency.Range,
+					Kind:        "runtime",
+					Target:      group.TargetFramework,
+				})
+			}
+		}
+	}
+
+	var search struct {
+		Data []struct {
+			ID             string `json:"id"`
+			TotalDownloads int64  `json:"totalDownloads"`
+		} `json:"data"`
+	}
+	if err := a.registryClient("nuget").getJSON(ctx, "/query", url.Values{"q": {"packageid:" + name}, "prerelease": {"true"}}, &search); err == nil {
+		for _, item := range search.Data {
+			if strings.EqualFold(item.ID, name) {
+				details.TotalDownloads = item.TotalDownloads
+			}
+		}
+	}
+
+	return details, nil
+}
+
+// mavenPOM POM文件中详情需要的部分，不解析父POM的继承
+type mavenPOM struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+	URL         string `xml:"url"`
+	Licenses    []struct {
+		Name string `xml:"name"`
+	} `xml:"licenses>license"`
+	SCM struct {
+		URL string `xml:"url"`
+	} `xml:"scm"`
+	Developers []struct {
+		Name string `xml:"name"`
+		ID   string `xml:"id"`
+	} `xml:"developers>developer"`
+	Properties struct {
+		Entries []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	} `xml:"properties"`
+	Dependencies []struct {
+		GroupID    string `xml:"groupId"`
+		ArtifactID string `xml:"artifactId"`
+		Version    string `xml:"version"`
+		Scope      string `xml:"scope"`
+		Optional   string `xml:"optional"`
+	} `xml:"dependencies>dependency"`
+}
+
+// fetchMavenDetails 从Maven Central搜索接口读取版本列表，从最新版本的POM读取许可证和依赖
+func (a *App) fetchMavenDetails(ctx context.Context, name string) (*PackageDetails, error) {
+	parts := strings.Split(name, ":")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("Maven包名称格式为 groupId:artifactId")
+	}
+	groupID, artifactID := parts[0], parts[1]
+
+	var result struct {
+		Response struct {
+			Docs []struct {
+				Version   string `json:"v"`
+				Timestamp int64  `json:"timestamp"`
+			} `json:"docs"`
+		} `json:"response"`
+	}
+	query := url.Values{
+		"q":    {fmt.Sprintf(`g:"%s" AND a:"%s"`, groupID, artifactID)},
+		"core": {"gav"},
+		"rows": {"200"},
+		"wt":   {"json"},
+	}
+	if err := a.registryClient("maven").getJSON(ctx, "/solrsearch/select", query, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Response.Docs) == 0 {
+		return nil, errRegistryNotFound
+	}
+
+	details := &PackageDetails{Name: groupID + ":" + artifactID}
+	for _, doc := range result.Response.Docs {
+		version := PackageVersion{Version: doc.Version}
+		if doc.Timestamp > 0 {
+			version.ReleasedAt = time.UnixMilli(doc.Timestamp).UTC().Format(time.RFC3339)
+		}
+		details.Versions = append(details.Versions, version)
+	}
+
+	finishPackageDetails(details)
+	pomPath := fmt.Sprintf("/%s/%s/%s/%s-%s.pom", strings.ReplaceAll(groupID, ".", "/"), artifactID, details.LatestVersion, artifactID, details.LatestVersion)
+	body, err := externalRegistryClient("maven", mavenRepositoryURL).get(ctx, pomPath, nil, "application/xml")
+	if err != nil {
+		// POM读取失败时仍返回版本列表
+		details.Warning = fmt.Sprintf("读取POM失败: %v", err)
+		return details, nil
+	}
+
+	var pom mavenPOM
+	if err := xml.Unmarshal(body, &pom); err != nil {
+		details.Warning = fmt.Sprintf("解析POM失败: %v", err)
+		return details, nil
+	}
+
+	details.Description = strings.TrimSpace(pom.Description)
+	if details.Description == "" {
+		details.Description = strings.TrimSpace(pom.Name)
+	}
+	details.Homepage = pom.URL
+	details.Repository = pom.SCM.URL
+	licenses := []string{}
+	for _, license := range pom.Licenses {
+		licenses = append(licenses, strings.TrimSpace(license.Name))
+	}
+	details.License = strings.Join(licenses, ", ")
+	developers := []string{}
+	for _, developer := range pom.Developers {
+		if developer.Name != "" {
+			developers = append(developers, developer.Name)
+		} else if developer.ID != "" {
+			developers = append(developers, developer.ID)
+		}
+	}
+	details.Author = strings.Join(developers, ", ")
+
+	for _, property := range pom.Properties.Entries {
+		switch property.XMLName.Local {
+		case "maven.compiler.release", "maven.compiler.target", "java.version":
+			details.Runtimes = append(details.Runtimes, "java "+strings.TrimSpace(property.Value))
+		}
+		if len(details.Runtimes) > 0 {
+			break
+		}
+	}
+
+	for _, dependency := range pom.Dependencies {
+		kind := "runtime"
+		switch {
+		case dependency.Scope == "test":
+			kind = "dev"
+		case dependency.Scope == "provided":
+			kind = "peer"
+		case dependency.Optional == "true":
+			kind = "optional"
+		}
+		details.Dependencies = append(details.Dependencies, PackageDependency{
+			Name:        dependency.GroupID + ":" + dependency.ArtifactID,
+			Requirement: dependency.Version,
+			Kind:        kind,
+		})
+	}
+
+	return details, nil
+}
+
+// goModRequirePattern go.mod 中的一条依赖，如 "golang.org/x/net v0.35.0 // indirect"
+var goModRequirePattern = regexp.MustCompile(`^([^\s]+)\s+(v[^\s]+)(\s*//\s*indirect)?`)
+
+// fetchGoDetails 从Go模块代理读取版本列表、发布时间和 go.mod，模块代理不提供许可证和下载量
+func (a *App) fetchGoDetails(ctx context.Context, name string) (*PackageDetails, error) {
+	modulePath := strings.Trim(strings.TrimSpace(name), "/")
+	escaped := "/" + escapeGoModulePath(modulePath)
+	client := a.registryClient("go")
+
+	body, err := client.get(ctx, escaped+"/@v/list", nil, "text/plain")
+	if err != nil {
+		return nil, err
+	}
+
+	var latest struct {
+		Version string `json:"Version"`
+		Time    string `json:"Time"`
+	}
+	if err := client.getJSON(ctx, escaped+"/@latest", nil, &latest); err != nil && !errors.Is(err, errRegistryNotFound) {
+		return nil, err
+	}
+
+	details := &PackageDetails{
+		Name:          modulePath,
+		LatestVersion: latest.Version,
+		Homepage:      "https://pkg.go.dev/" + modulePath,
+	}
+	for _, line := range strings.Split(string(body), "\n") {
+		if version := strings.TrimSpace(line); version != "" {
+			details.Versions = append(details.Versions, PackageVersion{Version: version})
+		}
+	}
+	if len(details.Versions) == 0 && latest.Version != "" {
+		// 只有伪版本的模块，列表为空
+		details.Versions = append(details.Versions, PackageVersion{Version: latest.Version})
+	}
+	if len(details.Versions) == 0 {
+		return nil, errRegistryNotFound
+	}
+	finishPackageDetails(details)
+
+	// 并发查询最新若干版本的发布时间
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, 8)
+	for i := range details.Versions {
+		if i >= goVersionInfoLimit {
+			break
+		}
+		wg.Add(1)
+		go func(version *PackageVersion) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			var info struct {
+				Time string `json:"Time"`
+			}
+			if client.getJSON(ctx, escaped+"/@v/"+escapeGoModulePath(version.Version)+".info", nil, &info) == nil {
+				version.ReleasedAt = normalizeRegistryTime(info.Time)
+			}
+		}(&details.Versions[i])
+	}
+	wg.Wait()
+
+	for _, host := range []string{"github.com/", "gitlab.com/", "bitbucket.org/"} {
+		if strings.HasPrefix(modulePath, host) {
+			segments := strings.Split(modulePath, "/")
+			if len(segments) >= 3 {
+				details.Repository = "https://" + strings.Join(segments[:3], "/")
+			}
+		}
+	}
+
+	mod, err := client.get(ctx, escaped+"/@v/"+escapeGoModulePath(details.LatestVersion)+".mod", nil, "text/plain")
+	if err == nil {
+		parseGoModDetails(string(mod), details)
+	}
+
+	return details, nil
+}
+
+// parseGoModDetails 从 go.mod 读取Go版本要求、依赖和弃用注释
+func parseGoModDetails(mod string, details *PackageDetails) {
+	inRequire := false
+	for _, raw := range strings.Split(mod, "\n") {
+		line := strings.TrimSpace(raw)
+		switch {
+		case strings.HasPrefix(line, "// Deprecated:"):
+			details.Deprecated = true
+			details.DeprecationMessage = strings.TrimSpace(strings.TrimPrefix(line, "// Deprecated:"))
+		case strings.HasPrefix(line, "go "):
+			details.Runtimes = append(details.Runtimes, "go "+strings.TrimSpace(strings.TrimPrefix(line, "go ")))
+		case strings.HasPrefix(line, "toolchain "):
+			details.Runtimes = append(details.Runtimes, "toolchain "+strings.TrimSpace(strings.TrimPrefix(line, "toolchain ")))
+		case line == "require (":
+			inRequire = true
+		case inRequire && line == ")":
+			inRequire = false
+		case inRequire || strings.HasPrefix(line, "require "):
+			match := goModRequirePattern.FindStringSubmatch(strings.TrimPrefix(line, "require "))
+			if match == nil {
+				continue
+			}
+			kind := "runtime"
+			if match[3] != "" {
+				kind = "indirect"
+			}
+			details.Dependencies = append(details.Dependencies, PackageDependency{Name: match[1], Requirement: match[2], Kind: kind})
+		}
+	}
+}
+
+// fetchHexDetails 读取hex.pm的包信息和最新版本的依赖，退役（retired）的版本按撤回处理
+func (a *App) fetchHexDetails(ctx context.Context, name string) (*PackageDetails, error) {
+	var doc struct {
+		Name                string `json:"name"`
+		HTMLURL             string `json:"html_url"`
+		DocsHTMLURL         string `json:"docs_html_url"`
+		LatestStableVersion string `json:"latest_stable_version"`
+		Meta                struct {
+			Description string            `json:"description"`
+			Licenses    []string          `json:"licenses"`
+			Links       map[string]string `json:"links"`
+		} `json:"meta"`
+		Downloads struct {
+			All  int64 `json:"all"`
+			Week int64 `json:"week"`
+		} `json:"downloads"`
+		Releases []struct {
+			Version    string `json:"version"`
+			InsertedAt string `json:"inserted_at"`
+		} `json:"releases"`
+		Retirements map[string]struct {
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"retirements"`
+		Owners []struct {
+			Username string `json:"username"`
+		} `json:"owners"`
+	}
+
+	client := a.registryClient("hex")
+	if err := client.getJSON(ctx, "/api/packages/"+url.PathEscape(name), nil, &doc); err != nil {
+		return nil, err
+	}
+
+	details := &PackageDetails{
+		Name:            doc.Name,
+		Description:     doc.Meta.Description,
+		LatestVersion:   doc.LatestStableVersion,
+		License:         strings.Join(doc.Meta.Licenses, ", "),
+		Homepage:        doc.DocsHTMLURL,
+		Repository:      pickURL(doc.Meta.Links, "github", "gitlab", "source", "repository"),
+		WeeklyDownloads: doc.Downloads.Week,
+		TotalDownloads:  doc.Downloads.All,
+	}
+	if details.Homepage == "" {
+		details.Homepage = doc.HTMLURL
+	}
+	owners := []string{}
+	for _, owner := range doc.Owners {
+		owners = append(owners, owner.Username)
+	}
+	details.Author = strings.Join(owners, ", ")
+
+	for _, release := range doc.Releases {
+		version := PackageVersion{Version: release.Version, ReleasedAt: normalizeRegistryTime(release.InsertedAt)}
+		if retirement, ok := doc.Retirements[release.Version]; ok {
+			version.Yanked = true
+			version.Note = strings.TrimSpace(retirement.Reason + " " + retirement.Message)
+		}
+		details.Versions = append(details.Versions, version)
+	}
+	if retirement, ok := doc.Retirements[details.LatestVersion]; ok {
+		details.Deprecated = true
+		details.DeprecationMessage = strings.TrimSpace(retirement.Reason + " " + retirement.Message)
+	}
+
+	if details.LatestVersion != "" {
+		var release struct {
+			Requirements map[string]struct {
+				Requirement string `json:"requirement"`
+				Optional    bool   `json:"optional"`
+			} `json:"requirements"`
+			Meta struct {
+				Elixir     string   `json:"elixir"`
+				BuildTools []string `json:"build_tools"`
+			} `json:"meta"`
+		}
+		path := "/api/packages/" + url.PathEscape(name) + "/releases/" + url.PathEscape(details.LatestVersion)
+		if err := client.getJSON(ctx, path, nil, &release); err == nil {
+			if release.Meta.Elixir != "" {
+				details.Runtimes = append(details.Runtimes, "elixir "+release.Meta.Elixir)
+			}
+			required, optional := map[string]string{}, map[string]string{}
+			for dependency, requirement := range release.Requirements {
+				if requirement.Optional {
+					optional[dependency] = requirement.Requirement
+				} else {
+					required[dependency] = requirement.Requirement
+				}
+			}
+			details.Dependencies = append(details.Dependencies, sortedDependencies(required, "runtime")...)
+			details.Dependencies = append(details.Dependencies, sortedDependencies(optional, "optional")...)
+		}
+	}
+
+	return details, nil
+}
+
+// fetchDubDetails 读取DUB注册表的包信息和下载统计，分支版本（~master）不计入版本列表
+func (a *App) fetchDubDetails(ctx context.Context, name string) (*PackageDetails, error) {
+	var doc struct {
+		Name       string `json:"name"`
+		Repository struct {
+			Kind    string `json:"kind"`
+			Owner   string `json:"owner"`
+			Project string `json:"project"`
+		} `json:"repository"`
+		Versions []struct {
+			Version      string                     `json:"version"`
+			Date         string                     `json:"date"`
+			Description  string                     `json:"description"`
+			License      string                     `json:"license"`
+			Homepage     string                     `json:"homepage"`
+			Authors      []string                   `json:"authors"`
+			Dependencies map[string]json.RawMessage `json:"dependencies"`
+		} `json:"versions"`
+	}
+
+	client := a.registryClient("dub")
+	if err := client.getJSON(ctx, "/api/packages/"+url.PathEscape(name)+"/info", nil, &doc); err != nil {
+		return nil, err
+	}
+
+	details := &PackageDetails{Name: doc.Name}
+	if doc.Repository.Owner != "" && doc.Repository.Project != "" {
+		hosts := map[string]string{"github": "github.com", "gitlab": "gitlab.com", "bitbucket": "bitbucket.org"}
+		if host, ok := hosts[doc.Repository.Kind]; ok {
+			details.Repository = "https://" + host + "/" + doc.Repository.Owner + "/" + doc.Repository.Project
+		}
+	}
+	for _, version := range doc.Versions {
+		if strings.HasPrefix(version.Version, "~") {
+			continue
+		}
+		details.Versions = append(details.Versions, PackageVersion{Version: version.Version, ReleasedAt: normalizeRegistryTime(version.Date)})
+	}
+
+	finishPackageDetails(details)
+	for _, version := range doc.Versions {
+		if version.Version != details.LatestVersion {
+			continue
+		}
+		details.Description = version.Description
+		details.License = version.License
+		details.Homepage = version.Homepage
+		details.Author = strings.Join(version.Authors, ", ")
+
+		// 依赖可能是版本字符串，也可能是包含 version 和 optional 的对象
+		required, optional := map[string]string{}, map[string]string{}
+		for dependency, raw := range version.Dependencies {
+			var spec struct {
+				Version  string `json:"version"`
+				Optional bool   `json:"optional"`
+			}
+			var requirement string
+			if json.Unmarshal(raw, &requirement) != nil && json.Unmarshal(raw, &spec) == nil {
+				requirement = spec.Version
+			}
+			if spec.Optional {
+				optional[dependency] = requirement
+			} else {
+				required[dependency] = requirement
+			}
+		}
+		details.Dependencies = append(details.Dependencies, sortedDependencies(required, "runtime")...)
+		details.Dependencies = append(details.Dependencies, sortedDependencies(optional, "optional")...)
+	}
+
+	var stats struct {
+		Downloads struct {
+			Total  int64 `json:"total"`
+			Weekly int64 `json:"weekly"`
+		} `json:"downloads"`
+	}
+	if err := client.getJSON(ctx, "/api/packages/"+url.PathEscape(name)+"/stats", nil, &stats); err == nil {
+		details.TotalDownloads = stats.Downloads.Total
+		details.WeeklyDownloads = stats.Downloads.Weekly
+	}
+
+	return details, nil
+}
+
+// fetchNimbleDetails 从Nimble官方包列表读取包信息，包列表不记录版本
+func (a *App) fetchNimbleDetails(ctx context.Context, name string) (*PackageDetails, error) {
+	packages, err := a.loadNimblePackages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pkg := range packages {
+		if !strings.EqualFold(pkg.Name, name) {
+			continue
+		}
+		if pkg.Alias != "" {
+			return a.fetchNimbleDetails(ctx, pkg.Alias)
+		}
+		return &PackageDetails{
+			Name:        pkg.Name,
+			Description: pkg.Description,
+			License:     pkg.License,
+			Homepage:    pkg.Web,
+			Repository:  pkg.URL,
+			Keywords:    pkg.Tags,
+		}, nil
+	}
+	return nil, errRegistryNotFound
+}
+
+// fetchBrewDetails 读取Homebrew的formula信息，Homebrew只保留当前版本
+func (a *App) fetchBrewDetails(ctx context.Context, name string) (*PackageDetails, error) {
+	var formula struct {
+		Name     string `json:"name"`
+		Desc     string `json:"desc"`
+		License  string `json:"license"`
+		Homepage string `json:"homepage"`
+		Versions struct {
+			Stable string `json:"stable"`
+		} `json:"versions"`
+		URLs struct {
+			Stable struct {
+				URL string `json:"url"`
+			} `json:"stable"`
+			Head struct {
+				URL string `json:"url"`
+			} `json:"head"`
+		} `json:"urls"`
+		Dependencies         []string `json:"dependencies"`
+		BuildDependencies    []string `json:"build_dependencies"`
+		OptionalDependencies []string `json:"optional_dependencies"`
+		Deprecated           bool     `json:"deprecated"`
+		DeprecationReason    string   `json:"deprecation_reason"`
+		Disabled             bool     `json:"disabled"`
+		DisableReason        string   `json:"disable_reason"`
+	}
+
+	if err := externalRegistryClient("brew", homebrewAPIURL).getJSON(ctx, "/formula/"+url.PathEscape(name)+".json", nil, &formula); err != nil {
+		return nil, err
+	}
+
+	details := &PackageDetails{
+		Name:          formula.Name,
+		Description:   formula.Desc,
+		LatestVersion: formula.Versions.Stable,
+		License:       formula.License,
+		Homepage:      formula.Homepage,
+		Repository:    formula.URLs.Head.URL,
+		Versions:      []PackageVersion{{Version: formula.Versions.Stable}},
+	}
+	if details.Repository == "" && strings.Contains(formula.URLs.Stable.URL, "github.com/") {
+		details.Repository = strings.SplitN(formula.URLs.Stable.URL, "/archive/", 2)[0]
+	}
+	if formula.Deprecated || formula.Disabled {
+		details.Deprecated = true
+		details.DeprecationMessage = formula.DeprecationReason
+		if formula.Disabled {
+			details.DeprecationMessage = formula.DisableReason
+		}
+	}
+	for _, dependency := range formula.Dependencies {
+		details.Dependencies = append(details.Dependencies, PackageDependency{Name: dependency, Kind: "runtime"})
+	}
+	for _, dependency := range formula.BuildDependencies {
+		details.Dependencies = append(details.Dependencies, PackageDependency{Name: dependency, Kind: "build"})
+	}
+	for _, dependency := range formula.OptionalDependencies {
+		details.Dependencies = append(details.Dependencies, PackageDependency{Name: dependency, Kind: "optional"})
+	}
+
+	return details, nil
+}
@@ -0,0 +1,668 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	// packageJobEventName 任务状态变化、输出和包列表刷新都通过这个事件推送
+	packageJobEventName = "package:job"
+	// packageJobTimeout 单个任务的最长执行时间，编译安装（如cargo install）可能很慢
+	packageJobTimeout = 30 * time.Minute
+	// packageJobOutputLimit 每个任务保留的输出长度，超出时只保留末尾
+	packageJobOutputLimit = 256 * 1024
+	// packageJobQueueSize 排队中的任务上限
+	packageJobQueueSize = 64
+)
+
+// packageJobNamePattern 未单独列出的包管理器允许的包名，不允许以 - 开头，避免被当作命令行选项
+var packageJobNamePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]*$`)
+
+// packageJobNamePatterns 各包管理器允许的包名格式
+// 只在该包管理器的命名规则需要时才允许 @、/、:、+，避免 git+https://、file: 等地址或本地路径被当作包名安装
+var packageJobNamePatterns = map[string]*regexp.Regexp{
+	// npm 允许 @scope/name 形式的作用域包
+	"npm": regexp.MustCompile(`^(@[A-Za-z0-9_][A-Za-z0-9._~-]*/)?[A-Za-z0-9_][A-Za-z0-9._~-]*$`),
+	"pip": regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?$`),
+	// composer 的包名固定为 vendor/name
+	"composer": regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]*/[A-Za-z0-9_][A-Za-z0-9._-]*$`),
+	// go 使用模块路径（可以带 /... 通配），路径的每一段不能以 - 开头
+	"go": regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._~-]*(/[A-Za-z0-9_.][A-Za-z0-9._~-]*)*$`),
+	// brew 的公式名可以带 @版本 和 +（如 python@3.12、libsigc++），第三方仓库的公式写作 user/tap/formula
+	"brew": regexp.MustCompile(`^([A-Za-z0-9_-]+/[A-Za-z0-9_-]+/)?[A-Za-z0-9_][A-Za-z0-9@._+-]*$`),
+	// maven 使用 group:artifact
+	"maven": regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]*:[A-Za-z0-9_][A-Za-z0-9._-]*$`),
+}
+
+// validPackageJobName 判断包名是否符合该包管理器的命名规则
+func validPackageJobName(manager string, name string) bool {
+	if pattern, ok := packageJobNamePatterns[manager]; ok {
+		return pattern.MatchString(name)
+	}
+	return packageJobNamePattern.MatchString(name)
+}
+
+// packageJobVersionPattern 允许在命令中使用的版本号
+var packageJobVersionPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+~-]*$`)
+
+// PackageJobRequest 安装、升级或卸载包的请求
+type PackageJobRequest struct {
+	Manager string `json:"manager"`
+	// Language 没有指定 Manager 时根据语言名称确定包管理器，如语言详情中的已安装包
+	Language string `json:"language,omitempty"`
+	Name     string `json:"name"`
+	// Version 安装指定版本，为空时安装最新版本；卸载gem时只卸载该版本，其他情况下忽略
+	Version string `json:"version,omitempty"`
+	// Action 操作：install、upgrade、uninstall
+	Action string `json:"action"`
+	// DryRun 演练模式，包管理器支持时带上演练参数执行，不支持时只显示将要执行的命令
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// PackageCommand 请求对应的命令，用于确认对话框和复制命令
+type PackageCommand struct {
+	Manager string `json:"manager"`
+	Action  string `json:"action"`
+	Name    string `json:"name"`
+	// Command 可以复制到终端执行的完整命令
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	// Supported 为false时只能复制命令手动执行，Reason 中是原因
+	Supported bool   `json:"supported"`
+	Reason    string `json:"reason,omitempty"`
+	// DryRun 命令是否带有包管理器自身的演练参数
+	DryRun bool `json:"dryRun"`
+	// Language 操作成功后需要刷新包列表的语言
+	Language string `json:"language,omitempty"`
+}
+
+// PackageJob 队列中的一个任务
+type PackageJob struct {
+	ID      string `json:"id"`
+	Manager string `json:"manager"`
+	Name    string `json:"name"`
+	Action  string `json:"action"`
+	Command string `json:"command"`
+	DryRun  bool   `json:"dryRun"`
+	// Status 状态：queued、running、succeeded、failed、cancelled
+	Status     string `json:"status"`
+	ExitCode   int    `json:"exitCode"`
+	Output     string `json:"output"`
+	Error      string `json:"error,omitempty"`
+	QueuedAt   string `json:"queuedAt"`
+	StartedAt  string `json:"startedAt,omitempty"`
+	FinishedAt string `json:"finishedAt,omitempty"`
+
+	args     []string
+	language string
+	cancel   context.CancelFunc
+	ctx      context.Context
+	// rollbackOf 回滚任务对应的操作记录
+	rollbackOf string
+	// dependsOn 前置任务，前置任务没有成功时不执行
+	dependsOn *PackageJob
+	// done 任务结束（包括取消）并写入操作记录后关闭
+	done chan struct{}
+}
+
+// PackageJobEvent 推送给前端的任务事件
+type PackageJobEvent struct {
+	// Type 事件类型：status（状态变化）、output（新的输出行）、refreshed（包列表已刷新）
+	Type string     `json:"type"`
+	Job  PackageJob `json:"job"`
+	Line string     `json:"line,omitempty"`
+	// Language 刷新后的语言信息，只在 refreshed 事件中提供
+	Language *LanguageInfo `json:"language,omitempty"`
+}
+
+// packageJobLanguages 包管理器对应的语言，操作成功后重新检测该语言的包列表
+var packageJobLanguages = map[string]string{
+	"npm":      "Node.js",
+	"pip":      "Python",
+	"gem":      "Ruby",
+	"cargo":    "Rust",
+	"composer": "PHP",
+	"nuget":    "C# (.NET)",
+	"go":       "Go",
+	"nimble":   "Nim",
+}
+
+// pipCommand 返回可用的pip命令，优先使用 pip
+func pipCommand() string {
+	if commandExists("pip") {
+		return "pip"
+	}
+	return "pip3"
+}
+
+// buildPackageArgs 生成各包管理器的命令参数，返回参数、是否带有演练参数，不支持的操作返回错误
+// 全部使用全局（用户级）安装，与语言检测列出的包一致
+func buildPackageArgs(manager string, action string, name string, version string, dryRun bool) ([]string, bool, error) {
+	switch manager {
+	case "npm":
+		target := name
+		if action == "upgrade" {
+			target = name + "@latest"
+		} else if version != "" {
+			target = name + "@" + version
+		}
+		args := map[string][]string{
+			"install":   {"npm", "install", "--global", target},
+			"upgrade":   {"npm", "install", "--global", target},
+			"uninstall": {"npm", "uninstall", "--global", name},
+		}[action]
+		if dryRun {
+			args = append(args, "--dry-run")
+		}
+		return args, dryRun, nil
+
+	case "pip":
+		pipCmd := pipCommand()
+		var args []string
+		switch action {
+		case "install":
+			target := name
+			if version != "" {
+				target = name + "==" + version
+			}
+			args = []string{pipCmd, "install", target}
+		case "upgrade":
+			args = []string{pipCmd, "install", "--upgrade", name}
+		case "uninstall":
+			// pip uninstall 没有演练参数
+			return []string{pipCmd, "uninstall", "--yes", name}, false, nil
+		}
+		if dryRun {
+			args = append(args, "--dry-run")
+		}
+		return args, dryRun, nil
+
+	case "gem":
+		switch action {
+		case "install":
+			if version != "" {
+				return []string{"gem", "install", name, "--version", version}, false, nil
+			}
+			return []string{"gem", "install", name}, false, nil
+		case "upgrade":
+			return []string{"gem", "update", name}, false, nil
+		case "uninstall":
+			// 同一个gem可以安装多个版本，指定版本时只卸载该版本
+			if version != "" {
+				return []string{"gem", "uninstall", name, "--version", version, "--executables"}, false, nil
+			}
+			return []string{"gem", "uninstall", name, "--all", "--executables"}, false, nil
+		}
+
+	case "cargo":
+		switch action {
+		case "install", "upgrade":
+			// cargo install 在已安装旧版本时会升级到最新版本
+			if version != "" && action == "install" {
+				return []string{"cargo", "install", name, "--version", version}, false, nil
+			}
+			return []string{"cargo", "install", name}, false, nil
+		case "uninstall":
+			return []string{"cargo", "uninstall", name}, false, nil
+		}
+
+	case "composer":
+		var args []string
+		switch action {
+		case "install":
+			target := name
+			if version != "" {
+				target = name + ":" + version
+			}
+			args = []string{"composer", "global", "require", target}
+		case "upgrade":
+			args = []string{"composer", "global", "update", name}
+		case "uninstall":
+			args = []string{"composer", "global", "remove", name}
+		}
+		args = append(args, "--no-interaction")
+		if dryRun {
+			args = append(args, "--dry-run")
+		}
+		return args, dryRun, nil
+
+	case "nuget":
+		// 只管理 dotnet 全局工具，项目依赖需要在项目目录中执行 dotnet add package
+		switch action {
+		case "install":
+			if version != "" {
+				return []string{"dotnet", "tool", "install", "--global", name, "--version", version}, false, nil
+			}
+			return []string{"dotnet", "tool", "install", "--global", name}, false, nil
+		case "upgrade":
+			return []string{"dotnet", "tool", "update", "--global", name}, false, nil
+		case "uninstall":
+			return []string{"dotnet", "tool", "uninstall", "--global", name}, false, nil
+		}
+
+	case "go":
+		switch action {
+		case "install", "upgrade":
+			if version == "" || action == "upgrade" {
+				version = "latest"
+			}
+			return []string{"go", "install", name + "@" + version}, false, nil
+		case "uninstall":
+			return nil, false, fmt.Errorf("go 没有卸载命令，请手动删除 GOPATH/bin 中的可执行文件")
+		}
+
+	case "nimble":
+		switch action {
+		case "install", "upgrade":
+			target := name
+			if version != "" && action == "install" {
+				target = name + "@" + version
+			}
+			return []string{"nimble", "install", "--accept", target}, false, nil
+		case "uninstall":
+			return []string{"nimble", "uninstall", "--accept", name}, false, nil
+		}
+
+	case "brew":
+		switch action {
+		case "install":
+			return []string{"brew", "install", name}, false, nil
+		case "upgrade":
+			return []string{"brew", "upgrade", name}, false, nil
+		case "uninstall":
+			return []string{"brew", "uninstall", name}, false, nil
+		}
+
+	case "maven", "hex", "dub":
+		return nil, false, fmt.Errorf("%s 的包是项目依赖，需要添加到项目的配置文件中", manager)
+
+	default:
+		return nil, false, fmt.Errorf("不支持的包管理器: %s", manager)
+	}
+
+	return nil, false, fmt.Errorf("不支持的操作: %s", action)
+}
+
+// PreviewPackageCommand 返回请求对应的命令，用于执行前的确认和复制命令
+// 不支持在应用内执行时 Supported 为false，Command 仍可能是可以复制的命令
+func (a *App) PreviewPackageCommand(request PackageJobRequest) (PackageCommand, error) {
+	request.Manager = strings.ToLower(strings.TrimSpace(request.Manager))
+	request.Name = strings.TrimSpace(request.Name)
+	request.Version = strings.TrimSpace(request.Version)
+	if request.Manager == "" {
+		request.Manager = outdatedLanguageManagers[request.Language]
+	}
+	if request.Manager == "" {
+		return PackageCommand{}, fmt.Errorf("无法确定 %s 的包管理器", request.Language)
+	}
+
+	switch request.Action {
+	case "install", "upgrade", "uninstall":
+	default:
+		return PackageCommand{}, fmt.Errorf("不支持的操作: %s", request.Action)
+	}
+	if !validPackageJobName(request.Manager, request.Name) {
+		return PackageCommand{}, fmt.Errorf("无效的包名称: %s", request.Name)
+	}
+	if request.Version != "" && !packageJobVersionPattern.MatchString(request.Version) {
+		return PackageCommand{}, fmt.Errorf("无效的版本号: %s", request.Version)
+	}
+
+	command := PackageCommand{
+		Manager:  request.Manager,
+		Action:   request.Action,
+		Name:     request.Name,
+		Language: packageJobLanguages[request.Manager],
+	}
+
+	args, dryRun, err := buildPackageArgs(request.Manager, request.Action, request.Name, request.Version, request.DryRun)
+	if err != nil {
+		// 项目依赖仍然提供原有的安装命令供复制
+		pkg := PackageInfo{Name: request.Name, Version: request.Version}
+		a.addPackageLinks(&pkg, request.Manager)
+		if request.Action == "install" {
+			command.Command = pkg.InstallLink
+		}
+		command.Reason = err.Error()
+		return command, nil
+	}
+
+	command.Args = args
+	command.Command = strings.Join(args, " ")
+	command.DryRun = dryRun
+	command.Supported = commandExists(args[0])
+	if !command.Supported {
+		command.Reason = fmt.Sprintf("没有找到 %s 命令", args[0])
+	}
+	return command, nil
+}
+
+// EnqueuePackageJob 把安装、升级或卸载任务加入队列，任务按加入顺序逐个执行
+// 前端应先通过 PreviewPackageCommand 向用户确认将要执行的命令
+func (a *App) EnqueuePackageJob(request PackageJobRequest) (PackageJob, error) {
+	job, err := a.enqueuePackageJob(request, "", nil)
+	if err != nil {
+		return PackageJob{}, err
+	}
+	return a.snapshotPackageJob(job), nil
+}
+
+// enqueuePackageJob 创建任务并加入队列，rollbackOf 和 dependsOn 用于回滚
+func (a *App) enqueuePackageJob(request PackageJobRequest, rollbackOf string, dependsOn *PackageJob) (*PackageJob, error) {
+	command, err := a.PreviewPackageCommand(request)
+	if err != nil {
+		return nil, err
+	}
+	if !command.Supported {
+		return nil, fmt.Errorf("无法在应用内执行: %s", command.Reason)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &PackageJob{
+		ID:       fmt.Sprintf("job-%d", time.Now().UnixNano()),
+		Manager:  command.Manager,
+		Name:     command.Name,
+		Action:   command.Action,
+		Command:  command.Command,
+		DryRun:   request.DryRun,
+		Status:   "queued",
+		QueuedAt: time.Now().Format(time.RFC3339),
+		args:     command.Args,
+		language: command.Language,
+		cancel:   cancel,
+		ctx:      ctx,
+		done:     make(chan struct{}),
+
+		rollbackOf: rollbackOf,
+		dependsOn:  dependsOn,
+	}
+
+	// 包管理器没有演练参数时不执行命令，只记录将要执行的命令
+	if request.DryRun && !command.DryRun {
+		cancel()
+		job.Status = "succeeded"
+		job.Output = fmt.Sprintf("演练模式：%s 不支持演练参数，未执行命令\n%s\n", command.Manager, command.Command)
+		job.FinishedAt = job.QueuedAt
+		close(job.done)
+		a.jobMu.Lock()
+		a.jobs = append(a.jobs, job)
+		snapshot := *job
+		a.jobMu.Unlock()
+
+		a.emitPackageJobEvent(PackageJobEvent{Type: "status", Job: snapshot})
+		return job, nil
+	}
+
+	a.jobMu.Lock()
+	if a.jobQueue == nil {
+		a.jobQueue = make(chan *PackageJob, packageJobQueueSize)
+		go a.runPackageJobs()
+	}
+	select {
+	case a.jobQueue <- job:
+		a.jobs = append(a.jobs, job)
+	default:
+		a.jobMu.Unlock()
+		cancel()
+		return nil, fmt.Errorf("排队中的任务过多，请等待当前任务完成")
+	}
+	snapshot := *job
+	a.jobMu.Unlock()
+
+	a.emitPackageJobEvent(PackageJobEvent{Type: "status", Job: snapshot})
+	return job, nil
+}
+
+// CancelPackageJob 取消排队中或正在执行的任务
+func (a *App) CancelPackageJob(jobID string) bool {
+	a.jobMu.Lock()
+	defer a.jobMu.Unlock()
+
+	for _, job := range a.jobs {
+		if job.ID == jobID && (job.Status == "queued" || job.Status == "running") {
+			job.cancel()
+			return true
+		}
+	}
+	return false
+}
+
+// waitPackageJob 等待任务结束并返回输出，任务没有成功时返回错误
+// ctx 取消时同时取消任务，不再等待
+func (a *App) waitPackageJob(ctx context.Context, job *PackageJob) (string, error) {
+	select {
+	case <-job.done:
+	case <-ctx.Done():
+		a.CancelPackageJob(job.ID)
+		return a.snapshotPackageJob(job).Output, fmt.Errorf("已取消")
+	}
+
+	snapshot := a.snapshotPackageJob(job)
+	switch snapshot.Status {
+	case "succeeded":
+		return snapshot.Output, nil
+	case "cancelled":
+		return snapshot.Output, fmt.Errorf("任务已取消")
+	}
+	if snapshot.Error != "" {
+		return snapshot.Output, fmt.Errorf("%s 失败: %s", snapshot.Command, snapshot.Error)
+	}
+	return snapshot.Output, fmt.Errorf("%s 失败，退出码 %d", snapshot.Command, snapshot.ExitCode)
+}
+
+// GetPackageJobs 返回所有任务，按加入顺序排列
+func (a *App) GetPackageJobs() []PackageJob {
+	a.jobMu.Lock()
+	defer a.jobMu.Unlock()
+
+	jobs := make([]PackageJob, 0, len(a.jobs))
+	for _, job := range a.jobs {
+		jobs = append(jobs, *job)
+	}
+	return jobs
+}
+
+// ClearFinishedPackageJobs 清除已结束的任务
+func (a *App) ClearFinishedPackageJobs() {
+	a.jobMu.Lock()
+	defer a.jobMu.Unlock()
+
+	remaining := []*PackageJob{}
+	for _, job := range a.jobs {
+		if job.Status == "queued" || job.Status == "running" {
+			remaining = append(remaining, job)
+		}
+	}
+	a.jobs = remaining
+}
+
+// runPackageJobs 逐个执行队列中的任务，包管理器同时运行可能争用锁文件
+func (a *App) runPackageJobs() {
+	for job := range a.jobQueue {
+		a.runPackageJob(job)
+	}
+}
+
+// updatePackageJob 在锁内修改任务并推送状态
+func (a *App) updatePackageJob(job *PackageJob, update func(job *PackageJob)) PackageJob {
+	a.jobMu.Lock()
+	update(job)
+	snapshot := *job
+	a.jobMu.Unlock()
+
+	a.emitPackageJobEvent(PackageJobEvent{Type: "status", Job: snapshot})
+	return snapshot
+}
+
+// runPackageJob 执行单个任务，实时推送输出，成功后刷新对应语言的包列表
+func (a *App) runPackageJob(job *PackageJob) {
+	defer job.cancel()
+	defer close(job.done)
+
+	if job.ctx.Err() != nil {
+		a.updatePackageJob(job, func(job *PackageJob) {
+			job.Status = "cancelled"
+			job.FinishedAt = time.Now().Format(time.RFC3339)
+		})
+		return
+	}
+	if job.dependsOn != nil && a.snapshotPackageJob(job.dependsOn).Status != "succeeded" {
+		a.updatePackageJob(job, func(job *PackageJob) {
+			job.Status = "cancelled"
+			job.Error = "前置任务没有成功，未执行"
+			job.FinishedAt = time.Now().Format(time.RFC3339)
+		})
+		return
+	}
+
+	// 记录执行前的版本，用于操作记录和回滚
+	previousVersion := ""
+	var previousErr error
+	if !job.DryRun {
+		previousVersion, previousErr = a.installedPackageVersion(job.Manager, job.Name)
+	}
+
+	a.updatePackageJob(job, func(job *PackageJob) {
+		job.Status = "running"
+		job.StartedAt = time.Now().Format(time.RFC3339)
+	})
+
+	ctx, cancel := context.WithTimeout(job.ctx, packageJobTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, job.args[0], job.args[1:]...)
+	// 禁止包管理器等待交互输入
+	cmd.Env = append(os.Environ(), "CI=1", "PIP_NO_INPUT=1", "COMPOSER_NO_INTERACTION=1", "npm_config_yes=true", "HOMEBREW_NO_AUTO_UPDATE=1")
+	cmd.Env = append(cmd.Env, a.networkCommandEnv()...)
+	// 子进程（如npm.cmd启动的node）可能继续持有输出管道，取消后最多再等待这么久
+	cmd.WaitDelay = 5 * time.Second
+
+	// 在Windows系统上隐藏命令窗口
+	if runtime.GOOS == "windows" {
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			HideWindow:    true,
+			CreationFlags: 0x08000000, // CREATE_NO_WINDOW
+		}
+	}
+
+	reader, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.streamPackageJobOutput(job, reader)
+	}()
+
+	err := cmd.Start()
+	if err == nil {
+		err = cmd.Wait()
+	}
+	writer.Close()
+	<-done
+
+	a.updatePackageJob(job, func(job *PackageJob) {
+		job.FinishedAt = time.Now().Format(time.RFC3339)
+		job.ExitCode = 0
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			job.ExitCode = exitErr.ExitCode()
+		}
+
+		switch {
+		case job.ctx.Err() != nil:
+			job.Status = "cancelled"
+		case ctx.Err() == context.DeadlineExceeded:
+			job.Status = "failed"
+			job.Error = fmt.Sprintf("执行超过 %v，已终止", packageJobTimeout)
+		case err != nil:
+			job.Status = "failed"
+			job.Error = err.Error()
+		default:
+			job.Status = "succeeded"
+		}
+	})
+
+	if !job.DryRun {
+		a.recordPackageJournal(job, previousVersion, previousErr)
+	}
+
+	if job.Status == "succeeded" && !job.DryRun && job.language != "" {
+		info, err := a.DetectLanguage(job.language)
+		if err != nil {
+			fmt.Printf("刷新 %s 的包列表失败: %v\n", job.language, err)
+			return
+		}
+		a.emitPackageJobEvent(PackageJobEvent{Type: "refreshed", Job: a.snapshotPackageJob(job), Language: &info})
+	}
+}
+
+// snapshotPackageJob 在锁内复制任务
+func (a *App) snapshotPackageJob(job *PackageJob) PackageJob {
+	a.jobMu.Lock()
+	defer a.jobMu.Unlock()
+	return *job
+}
+
+// streamPackageJobOutput 按行读取命令输出，保存到任务中并推送给前端
+func (a *App) streamPackageJobOutput(job *PackageJob, reader io.Reader) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	scanner.Split(scanOutputLines)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		if line == "" {
+			continue
+		}
+
+		a.jobMu.Lock()
+		job.Output += line + "\n"
+		if len(job.Output) > packageJobOutputLimit {
+			job.Output = job.Output[len(job.Output)-packageJobOutputLimit:]
+		}
+		snapshot := PackageJob{ID: job.ID, Status: job.Status}
+		a.jobMu.Unlock()
+
+		a.emitPackageJobEvent(PackageJobEvent{Type: "output", Job: snapshot, Line: line})
+	}
+	// 读取出错时也要读完管道，避免命令阻塞在写入上
+	io.Copy(io.Discard, reader)
+}
+
+// scanOutputLines 按 \n 或 \r 分行，进度条等用 \r 刷新的输出也能逐行显示
+func scanOutputLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if index := bytes.IndexAny(data, "\r\n"); index >= 0 {
+		return index + 1, data[:index], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// emitPackageJobEvent 向前端推送任务事件
+func (a *App) emitPackageJobEvent(event PackageJobEvent) {
+	if a.ctx == nil {
+		return
+	}
+	wailsruntime.EventsEmit(a.ctx, packageJobEventName, event)
+}
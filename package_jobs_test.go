@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+func TestValidPackageJobName(t *testing.T) {
+	cases := []struct {
+		manager string
+		name    string
+		valid   bool
+	}{
+		{"npm", "left-pad", true},
+		{"npm", "@types/node", true},
+		{"npm", "git+https://github.com/user/repo.git", false},
+		{"npm", "file:/tmp/pkg", false},
+		{"npm", "user/repo", false},
+		{"npm", "--global", false},
+		{"pip", "requests", true},
+		{"pip", "zope.interface", true},
+		{"pip", "git+https://github.com/user/repo", false},
+		{"pip", "./local-dir", false},
+		{"composer", "monolog/monolog", true},
+		{"composer", "monolog", false},
+		{"go", "golang.org/x/tools/gopls", true},
+		{"go", "golang.org/x/tools/cmd/...", true},
+		{"go", "https://example.com/mod", false},
+		{"go", "example.com/-flag", false},
+		{"brew", "python@3.12", true},
+		{"brew", "libsigc++", true},
+		{"brew", "homebrew/cask/firefox", true},
+		{"brew", "https://example.com/formula.rb", false},
+		{"brew", "/tmp/formula.rb", false},
+		{"maven", "com.google.guava:guava", true},
+		{"cargo", "serde_json", true},
+		{"cargo", "com.google.guava:guava", false},
+		{"gem", "rails", true},
+		{"gem", "../evil.gem", false},
+		{"nimble", "https://github.com/user/pkg", false},
+	}
+	for _, c := range cases {
+		if got := validPackageJobName(c.manager, c.name); got != c.valid {
+			t.Errorf("validPackageJobName(%q, %q) = %v, 期望 %v", c.manager, c.name, got, c.valid)
+		}
+	}
+}
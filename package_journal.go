@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// packageRollbackManagers 可以安装指定版本、支持回滚的包管理器
+var packageRollbackManagers = map[string]bool{
+	"npm":   true,
+	"pip":   true,
+	"gem":   true,
+	"cargo": true,
+	"nuget": true,
+}
+
+// PackageJournalEntry 一次通过应用执行的包操作记录
+type PackageJournalEntry struct {
+	// ID 与执行操作的任务ID相同
+	ID      string `json:"id"`
+	Manager string `json:"manager"`
+	Name    string `json:"name"`
+	Action  string `json:"action"`
+	// PreviousVersion 操作前安装的版本，为空表示确认未安装
+	PreviousVersion string `json:"previousVersion"`
+	// NewVersion 操作后安装的版本，为空表示确认未安装（如卸载后）
+	NewVersion string `json:"newVersion"`
+	Command    string `json:"command"`
+	Status     string `json:"status"`
+	ExitCode   int    `json:"exitCode"`
+	Error      string `json:"error,omitempty"`
+	StartedAt  string `json:"startedAt"`
+	FinishedAt string `json:"finishedAt"`
+	// RollbackOf 回滚操作对应的原记录ID
+	RollbackOf string `json:"rollbackOf,omitempty"`
+	// PreviousVersionUnknown、NewVersionUnknown 查询版本失败（如列表命令超时），此时对应的版本字段没有意义
+	PreviousVersionUnknown bool `json:"previousVersionUnknown,omitempty"`
+	NewVersionUnknown      bool `json:"newVersionUnknown,omitempty"`
+
+	// 以下字段在读取时计算，不保存到文件
+	// RolledBackBy 已回滚时为回滚操作的记录ID
+	RolledBackBy string `json:"rolledBackBy,omitempty"`
+	// CanRollback 是否可以回滚到操作前的状态
+	CanRollback bool `json:"canRollback"`
+}
+
+// getPackageJournalPath 获取包操作记录文件路径
+func (a *App) getPackageJournalPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "package_journal.jsonl"
+	}
+	return filepath.Join(homeDir, ".networ_tester", "package_journal.jsonl")
+}
+
+// pipNamePattern pip包名中可以互换的分隔符
+var pipNamePattern = regexp.MustCompile(`[-_.]+`)
+
+// samePackageName 判断包名是否相同，pip按PEP 503规范化，其他包管理器不区分大小写
+func samePackageName(manager string, a string, b string) bool {
+	if manager == "pip" {
+		a = pipNamePattern.ReplaceAllString(a, "-")
+		b = pipNamePattern.ReplaceAllString(b, "-")
+	}
+	return strings.EqualFold(a, b)
+}
+
+// installedPackageVersion 查询包当前安装的版本，未安装时返回空字符串
+// 支持回滚的包管理器直接执行列表命令，其他包管理器使用最近一次语言检测的结果
+// 列表命令失败或超时、语言尚未检测时返回错误，不能当作未安装
+func (a *App) installedPackageVersion(manager string, name string) (string, error) {
+	var packages []PackageInfo
+	var err error
+	switch manager {
+	case "npm":
+		packages, err = a.listNpmPackages()
+	case "pip":
+		packages, err = a.listPipPackages(pipCommand())
+	case "gem":
+		packages, err = a.listGems()
+	case "cargo":
+		packages, err = a.listCrates()
+	case "nuget":
+		packages, err = a.listDotNetTools()
+	default:
+		detected := false
+		a.detectMu.Lock()
+		for _, language := range a.detectedLanguages {
+			if language.Name == packageJobLanguages[manager] {
+				packages = language.Packages
+				detected = true
+			}
+		}
+		a.detectMu.Unlock()
+		if !detected {
+			err = fmt.Errorf("尚未检测 %s 的已安装包", manager)
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("查询 %s 已安装的版本失败: %v", manager, err)
+	}
+
+	for _, pkg := range packages {
+		if samePackageName(manager, pkg.Name, name) {
+			return cleanInstalledVersion(pkg.Version), nil
+		}
+	}
+	return "", nil
+}
+
+// recordPackageJournal 任务结束后记录操作前后的版本，失败的操作也会记录，可能已经改变了部分文件
+// previousErr 不为空表示操作前的版本查询失败
+func (a *App) recordPackageJournal(job *PackageJob, previousVersion string, previousErr error) {
+	snapshot := a.snapshotPackageJob(job)
+	if snapshot.Status == "cancelled" && snapshot.StartedAt == "" {
+		return
+	}
+
+	newVersion, newErr := a.installedPackageVersion(snapshot.Manager, snapshot.Name)
+	entry := PackageJournalEntry{
+		ID:                     snapshot.ID,
+		Manager:                snapshot.Manager,
+		Name:                   snapshot.Name,
+		Action:                 snapshot.Action,
+		PreviousVersion:        previousVersion,
+		NewVersion:             newVersion,
+		PreviousVersionUnknown: previousErr != nil,
+		NewVersionUnknown:      newErr != nil,
+		Command:                snapshot.Command,
+		Status:                 snapshot.Status,
+		ExitCode:               snapshot.ExitCode,
+		Error:                  snapshot.Error,
+		StartedAt:              snapshot.StartedAt,
+		FinishedAt:             snapshot.FinishedAt,
+		RollbackOf:             job.rollbackOf,
+	}
+	for _, err := range []error{previousErr, newErr} {
+		if err != nil {
+			fmt.Printf("记录包操作: %v\n", err)
+		}
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	a.journalMu.Lock()
+	defer a.journalMu.Unlock()
+
+	path := a.getPackageJournalPath()
+	os.MkdirAll(filepath.Dir(path), 0755)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Printf("记录包操作失败: %v\n", err)
+		return
+	}
+	defer file.Close()
+
+	file.Write(append(data, '\n'))
+}
+
+// loadPackageJournal 读取全部操作记录，按时间从旧到新排列
+func (a *App) loadPackageJournal() ([]PackageJournalEntry, error) {
+	a.journalMu.Lock()
+	defer a.journalMu.Unlock()
+
+	file, err := os.Open(a.getPackageJournalPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []PackageJournalEntry{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := []PackageJournalEntry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry PackageJournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// GetPackageJournal 获取包操作记录，最新的在前，limit 不大于0时返回全部
+func (a *App) GetPackageJournal(limit int) ([]PackageJournalEntry, error) {
+	entries, err := a.loadPackageJournal()
+	if err != nil {
+		return nil, err
+	}
+
+	// 成功的回滚操作标记在原记录上
+	rolledBack := map[string]string{}
+	for _, entry := range entries {
+		if entry.RollbackOf != "" && entry.Status == "succeeded" {
+			rolledBack[entry.RollbackOf] = entry.ID
+		}
+	}
+
+	result := make([]PackageJournalEntry, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		entry.RolledBackBy = rolledBack[entry.ID]
+		entry.CanRollback = entry.RolledBackBy == "" && len(packageRollbackSteps(entry)) > 0
+		result = append(result, entry)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+// packageRollbackSteps 生成恢复到操作前状态的请求，按顺序执行，无法回滚时返回空
+// 操作前未安装的包回滚为卸载，其余回滚为重新安装操作前的版本
+func packageRollbackSteps(entry PackageJournalEntry) []PackageJobRequest {
+	// 操作前后的版本不确定时无法判断应恢复到什么状态
+	if entry.PreviousVersionUnknown || entry.NewVersionUnknown {
+		return nil
+	}
+	// 失败或取消的操作也可能已经改变了版本，只要版本有变化就允许回滚
+	if !packageRollbackManagers[entry.Manager] || entry.PreviousVersion == entry.NewVersion {
+		return nil
+	}
+
+	request := PackageJobRequest{Manager: entry.Manager, Name: entry.Name}
+	if entry.PreviousVersion == "" {
+		request.Action = "uninstall"
+		return []PackageJobRequest{request}
+	}
+
+	install := request
+	install.Action = "install"
+	install.Version = entry.PreviousVersion
+
+	switch {
+	case entry.Manager == "gem" && entry.NewVersion != "":
+		// gem 安装旧版本后新版本仍然保留，需要再卸载新版本
+		remove := request
+		remove.Action = "uninstall"
+		remove.Version = entry.NewVersion
+		return []PackageJobRequest{install, remove}
+	case entry.Manager == "nuget" && entry.NewVersion != "":
+		// dotnet tool install 不能覆盖已安装的工具，需要先卸载
+		remove := request
+		remove.Action = "uninstall"
+		return []PackageJobRequest{remove, install}
+	}
+	return []PackageJobRequest{install}
+}
+
+// findPackageJournalEntry 按ID查找操作记录
+func (a *App) findPackageJournalEntry(entryID string) (PackageJournalEntry, error) {
+	entries, err := a.GetPackageJournal(0)
+	if err != nil {
+		return PackageJournalEntry{}, err
+	}
+	for _, entry := range entries {
+		if entry.ID == entryID {
+			return entry, nil
+		}
+	}
+	return PackageJournalEntry{}, fmt.Errorf("操作记录不存在: %s", entryID)
+}
+
+// PreviewPackageRollback 返回回滚将要执行的命令，用于确认
+func (a *App) PreviewPackageRollback(entryID string) ([]PackageCommand, error) {
+	entry, err := a.findPackageJournalEntry(entryID)
+	if err != nil {
+		return nil, err
+	}
+	if entry.PreviousVersionUnknown || entry.NewVersionUnknown {
+		return nil, fmt.Errorf("%s %s 操作前后的版本查询失败，无法确定回滚目标", entry.Manager, entry.Name)
+	}
+	if !entry.CanRollback {
+		return nil, fmt.Errorf("%s %s 的这次操作无法回滚", entry.Manager, entry.Name)
+	}
+
+	commands := []PackageCommand{}
+	for _, request := range packageRollbackSteps(entry) {
+		command, err := a.PreviewPackageCommand(request)
+		if err != nil {
+			return nil, err
+		}
+		if !command.Supported {
+			return nil, fmt.Errorf("无法回滚: %s", command.Reason)
+		}
+		commands = append(commands, command)
+	}
+	return commands, nil
+}
+
+// RollbackPackageOperation 把恢复到操作前版本的任务加入队列，多个步骤时前一步成功才执行下一步
+func (a *App) RollbackPackageOperation(entryID string) ([]PackageJob, error) {
+	if _, err := a.PreviewPackageRollback(entryID); err != nil {
+		return nil, err
+	}
+	entry, err := a.findPackageJournalEntry(entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := []PackageJob{}
+	var previous *PackageJob
+	for _, request := range packageRollbackSteps(entry) {
+		job, err := a.enqueuePackageJob(request, entry.ID, previous)
+		if err != nil {
+			if previous != nil {
+				a.CancelPackageJob(previous.ID)
+			}
+			return nil, err
+		}
+		jobs = append(jobs, a.snapshotPackageJob(job))
+		previous = job
+	}
+	return jobs, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPackageRollbackSteps(t *testing.T) {
+	entry := PackageJournalEntry{Manager: "npm", Name: "left-pad", Action: "upgrade", PreviousVersion: "1.2.0", NewVersion: "1.3.0"}
+	if steps := packageRollbackSteps(entry); len(steps) != 1 || steps[0].Action != "install" || steps[0].Version != "1.2.0" {
+		t.Errorf("应回滚为安装操作前的版本: %+v", steps)
+	}
+
+	installed := PackageJournalEntry{Manager: "npm", Name: "left-pad", Action: "install", NewVersion: "1.3.0"}
+	if steps := packageRollbackSteps(installed); len(steps) != 1 || steps[0].Action != "uninstall" {
+		t.Errorf("操作前未安装时应回滚为卸载: %+v", steps)
+	}
+
+	// 操作前的版本查询失败时不能当作未安装而卸载
+	unknown := installed
+	unknown.PreviousVersionUnknown = true
+	if steps := packageRollbackSteps(unknown); len(steps) != 0 {
+		t.Errorf("操作前版本未知时不应回滚: %+v", steps)
+	}
+
+	unknown = entry
+	unknown.NewVersionUnknown = true
+	if steps := packageRollbackSteps(unknown); len(steps) != 0 {
+		t.Errorf("操作后版本未知时不应回滚: %+v", steps)
+	}
+}
+
+func TestPreviewPackageRollbackRefusesUnknownVersion(t *testing.T) {
+	useTempHome(t)
+	app := NewApp()
+
+	entry := PackageJournalEntry{ID: "job-1", Manager: "npm", Name: "left-pad", Action: "install", NewVersion: "1.3.0", Status: "succeeded", PreviousVersionUnknown: true}
+	data, _ := json.Marshal(entry)
+	path := app.getPackageJournalPath()
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := app.GetPackageJournal(0)
+	if err != nil || len(entries) != 1 || entries[0].CanRollback {
+		t.Fatalf("版本未知的记录不应可以回滚: %+v %v", entries, err)
+	}
+	if _, err := app.PreviewPackageRollback("job-1"); err == nil || !strings.Contains(err.Error(), "版本查询失败") {
+		t.Errorf("预览回滚应拒绝版本未知的记录: %v", err)
+	}
+	if _, err := app.RollbackPackageOperation("job-1"); err == nil {
+		t.Error("回滚应拒绝版本未知的记录")
+	}
+}
+
+func TestInstalledPackageVersionReportsLookupFailure(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	app := NewApp()
+
+	if _, err := app.installedPackageVersion("npm", "left-pad"); err == nil {
+		t.Error("列表命令无法执行时应返回错误，而不是当作未安装")
+	}
+	if _, err := app.installedPackageVersion("brew", "wget"); err == nil {
+		t.Error("语言尚未检测时应返回错误")
+	}
+}
@@ -0,0 +1,552 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// packageOutdatedEventName 每个包管理器检查完成时推送的事件
+const packageOutdatedEventName = "package:outdated"
+
+// outdatedCommandTimeout 包管理器的 outdated 命令需要联网，比普通命令的超时长
+const outdatedCommandTimeout = 2 * time.Minute
+
+// outdatedLanguageManagers 语言检测结果中的语言对应的包管理器，列出的包用于注册表比对
+var outdatedLanguageManagers = map[string]string{
+	"Node.js":   "npm",
+	"Python":    "pip",
+	"Ruby":      "gem",
+	"Rust":      "cargo",
+	"PHP":       "composer",
+	"C# (.NET)": "nuget",
+	"Go":        "go",
+	"Elixir":    "hex",
+	"D":         "dub",
+	"Nim":       "nimble",
+}
+
+// OutdatedPackage 一个有新版本的已安装包
+type OutdatedPackage struct {
+	Manager string `json:"manager"`
+	Name    string `json:"name"`
+	Current string `json:"current"`
+	// Wanted 符合当前版本约束的最高版本，只有npm提供
+	Wanted string `json:"wanted,omitempty"`
+	Latest string `json:"latest"`
+	// Update 更新级别：patch、minor、major，版本号无法比较时为 unknown
+	Update string `json:"update"`
+	// Source 最新版本的来源：cli（包管理器命令）或 registry（注册表接口）
+	Source string `json:"source"`
+}
+
+// OutdatedManagerStatus 单个包管理器的检查状态
+type OutdatedManagerStatus struct {
+	Manager string `json:"manager"`
+	// Method 检查方式，如 "npm outdated" 或 "registry"
+	Method    string `json:"method"`
+	Checked   int    `json:"checked"`
+	Outdated  int    `json:"outdated"`
+	ElapsedMs int64  `json:"elapsedMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+// OutdatedSummary 按更新级别统计的数量
+type OutdatedSummary struct {
+	Patch   int `json:"patch"`
+	Minor   int `json:"minor"`
+	Major   int `json:"major"`
+	Unknown int `json:"unknown"`
+}
+
+// OutdatedReport 过期包检查报告
+type OutdatedReport struct {
+	GeneratedAt string                  `json:"generatedAt"`
+	Packages    []OutdatedPackage       `json:"packages"`
+	Managers    []OutdatedManagerStatus `json:"managers"`
+	Summary     OutdatedSummary         `json:"summary"`
+}
+
+// outdatedChecker 通过包管理器自身的命令检查过期包，command 不存在时改用注册表比对
+type outdatedChecker struct {
+	command string
+	method  string
+	check   func() ([]OutdatedPackage, error)
+}
+
+// outdatedCheckers 返回有命令行检查方式的包管理器
+func (a *App) outdatedCheckers() map[string]outdatedChecker {
+	pipCmd := pipCommand()
+
+	return map[string]outdatedChecker{
+		"npm":      {command: "npm", method: "npm outdated", check: a.checkNpmOutdated},
+		"pip":      {command: pipCmd, method: "pip list --outdated", check: func() ([]OutdatedPackage, error) { return a.checkPipOutdated(pipCmd) }},
+		"gem":      {command: "gem", method: "gem outdated", check: a.checkGemOutdated},
+		"cargo":    {command: "cargo-install-update", method: "cargo install-update", check: a.checkCargoOutdated},
+		"composer": {command: "composer", method: "composer global outdated", check: a.checkComposerOutdated},
+	}
+}
+
+// classifyUpdate 按语义化版本判断更新级别
+// 0.x 版本的次版本号变化按语义化版本的约定视为不兼容更新
+func classifyUpdate(current string, latest string) string {
+	from, okFrom := parseVersion(cleanInstalledVersion(current))
+	to, okTo := parseVersion(latest)
+	if !okFrom || !okTo {
+		return "unknown"
+	}
+
+	part := func(v parsedVersion, i int) int {
+		if i < len(v.numbers) {
+			return v.numbers[i]
+		}
+		return 0
+	}
+	switch {
+	case part(from, 0) != part(to, 0):
+		return "major"
+	case part(from, 1) != part(to, 1):
+		if part(from, 0) == 0 {
+			return "major"
+		}
+		return "minor"
+	}
+	return "patch"
+}
+
+// cleanInstalledVersion 整理列表命令输出的版本号，如 "default: 2.5.1"、"13.0.6, 12.3.3"、"v13.0.0:"
+func cleanInstalledVersion(version string) string {
+	version = strings.TrimSpace(version)
+	version = strings.TrimPrefix(version, "default: ")
+	if index := strings.Index(version, ","); index >= 0 {
+		version = version[:index]
+	}
+	return strings.TrimSuffix(strings.TrimSpace(version), ":")
+}
+
+// decodeJSONFromOutput 从混有警告信息的命令输出中解析第一个JSON值
+func decodeJSONFromOutput(output string, out interface{}) error {
+	for i, r := range output {
+		if r != '{' && r != '[' {
+			continue
+		}
+		if err := json.NewDecoder(strings.NewReader(output[i:])).Decode(out); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("命令输出中没有有效的JSON")
+}
+
+// checkNpmOutdated 使用 npm outdated 检查全局安装的包，有过期包时npm的退出码为1
+func (a *App) checkNpmOutdated() ([]OutdatedPackage, error) {
+	output, _ := executeCommandWithEnv(outdatedCommandTimeout, a.networkCommandEnv(), "npm", "outdated", "--global", "--json")
+
+	var result map[string]struct {
+		Current string `json:"current"`
+		Wanted  string `json:"wanted"`
+		Latest  string `json:"latest"`
+	}
+	if strings.TrimSpace(output) == "" {
+		return []OutdatedPackage{}, nil
+	}
+	if err := decodeJSONFromOutput(output, &result); err != nil {
+		return nil, fmt.Errorf("解析 npm outdated 输出失败: %v", err)
+	}
+
+	packages := []OutdatedPackage{}
+	for name, info := range result {
+		packages = append(packages, OutdatedPackage{Name: name, Current: info.Current, Wanted: info.Wanted, Latest: info.Latest})
+	}
+	return packages, nil
+}
+
+// checkPipOutdated 使用 pip list --outdated 检查已安装的Python包
+func (a *App) checkPipOutdated(pipCmd string) ([]OutdatedPackage, error) {
+	output, err := executeCommandWithEnv(outdatedCommandTimeout, a.networkCommandEnv(), pipCmd, "list", "--outdated", "--format=json", "--disable-pip-version-check")
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, output)
+	}
+
+	var result []struct {
+		Name          string `json:"name"`
+		Version       string `json:"version"`
+		LatestVersion string `json:"latest_version"`
+	}
+	if err := decodeJSONFromOutput(output, &result); err != nil {
+		return nil, fmt.Errorf("解析 pip 输出失败: %v", err)
+	}
+
+	packages := []OutdatedPackage{}
+	for _, item := range result {
+		packages = append(packages, OutdatedPackage{Name: item.Name, Current: item.Version, Latest: item.LatestVersion})
+	}
+	return packages, nil
+}
+
+// gemOutdatedPattern gem outdated 的输出行，如 "rake (13.0.6 < 13.2.1)"
+var gemOutdatedPattern = regexp.MustCompile(`^(\S+) \((\S+) < (\S+)\)$`)
+
+// checkGemOutdated 使用 gem outdated 检查已安装的gem
+func (a *App) checkGemOutdated() ([]OutdatedPackage, error) {
+	output, err := executeCommandWithEnv(outdatedCommandTimeout, a.networkCommandEnv(), "gem", "outdated")
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, output)
+	}
+
+	packages := []OutdatedPackage{}
+	for _, line := range strings.Split(output, "\n") {
+		if match := gemOutdatedPattern.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			packages = append(packages, OutdatedPackage{Name: match[1], Current: match[2], Latest: match[3]})
+		}
+	}
+	return packages, nil
+}
+
+// checkCargoOutdated 使用 cargo-update 插件的 cargo install-update --list 检查通过cargo安装的工具
+func (a *App) checkCargoOutdated() ([]OutdatedPackage, error) {
+	output, err := executeCommandWithEnv(outdatedCommandTimeout, a.networkCommandEnv(), "cargo", "install-update", "--list")
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, output)
+	}
+
+	// 表格格式：Package  Installed  Latest  Needs update
+	packages := []OutdatedPackage{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] == "Package" || fields[len(fields)-1] != "Yes" {
+			continue
+		}
+		packages = append(packages, OutdatedPackage{
+			Name:    fields[0],
+			Current: strings.TrimPrefix(fields[1], "v"),
+			Latest:  strings.TrimPrefix(fields[2], "v"),
+		})
+	}
+	return packages, nil
+}
+
+// checkComposerOutdated 使用 composer global outdated 检查全局安装的PHP包
+func (a *App) checkComposerOutdated() ([]OutdatedPackage, error) {
+	output, err := executeCommandWithEnv(outdatedCommandTimeout, a.networkCommandEnv(), "composer", "global", "outdated", "--format=json", "--no-interaction")
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, output)
+	}
+
+	var result struct {
+		Installed []struct {
+			Name         string `json:"name"`
+			Version      string `json:"version"`
+			Latest       string `json:"latest"`
+			LatestStatus string `json:"latest-status"`
+		} `json:"installed"`
+	}
+	if err := decodeJSONFromOutput(output, &result); err != nil {
+		return nil, fmt.Errorf("解析 composer 输出失败: %v", err)
+	}
+
+	packages := []OutdatedPackage{}
+	for _, item := range result.Installed {
+		if item.LatestStatus == "up-to-date" {
+			continue
+		}
+		packages = append(packages, OutdatedPackage{
+			Name:    item.Name,
+			Current: strings.TrimPrefix(item.Version, "v"),
+			Latest:  strings.TrimPrefix(item.Latest, "v"),
+		})
+	}
+	return packages, nil
+}
+
+// installedPackagesByManager 从最近一次语言检测结果中按包管理器收集已安装的包
+func (a *App) installedPackagesByManager() map[string][]PackageInfo {
+	installed := map[string][]PackageInfo{}
+	for _, language := range a.getDetectedLanguages() {
+		manager, ok := outdatedLanguageManagers[language.Name]
+		if !ok || !language.Installed {
+			continue
+		}
+		installed[manager] = append(installed[manager], language.Packages...)
+	}
+	return installed
+}
+
+// checkOutdatedWithRegistry 逐个查询注册表的最新版本，用于没有命令行检查方式的包管理器
+func (a *App) checkOutdatedWithRegistry(manager string, installed []PackageInfo) ([]OutdatedPackage, int, error) {
+	// 同一个包可能列出多个版本（如Go模块缓存），只比较最高的版本
+	current := map[string]string{}
+	for _, pkg := range installed {
+		name := strings.TrimSpace(pkg.Name)
+		version := cleanInstalledVersion(pkg.Version)
+		if manager == "go" {
+			name = unescapeGoModulePath(name)
+		}
+		if name == "" || version == "" || version == "unknown" {
+			continue
+		}
+		if existing, ok := current[name]; !ok || compareVersions(version, existing) > 0 {
+			current[name] = version
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, 6)
+	packages := []OutdatedPackage{}
+	failures := []string{}
+	for name, version := range current {
+		wg.Add(1)
+		go func(name string, version string) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			details, err := a.GetPackageDetails(manager, name)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures = append(failures, name)
+				return
+			}
+			if details.LatestVersion != "" && compareVersions(details.LatestVersion, version) > 0 {
+				packages = append(packages, OutdatedPackage{Name: name, Current: version, Latest: details.LatestVersion})
+			}
+		}(name, version)
+	}
+	wg.Wait()
+
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return packages, len(current), fmt.Errorf("%d 个包查询失败: %s", len(failures), strings.Join(failures, ", "))
+	}
+	return packages, len(current), nil
+}
+
+// unescapeGoModulePath 还原模块缓存目录中转义的模块路径，如 !burnt!sushi 还原为 BurntSushi
+func unescapeGoModulePath(path string) string {
+	var builder strings.Builder
+	upper := false
+	for _, r := range path {
+		if r == '!' {
+			upper = true
+			continue
+		}
+		if upper {
+			builder.WriteString(strings.ToUpper(string(r)))
+			upper = false
+			continue
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+// emitOutdatedEvent 推送单个包管理器的检查结果
+func (a *App) emitOutdatedEvent(status OutdatedManagerStatus, packages []OutdatedPackage) {
+	if a.ctx == nil {
+		return
+	}
+	wailsruntime.EventsEmit(a.ctx, packageOutdatedEventName, map[string]interface{}{
+		"status":   status,
+		"packages": packages,
+	})
+}
+
+// CheckOutdatedPackages 检查所有已安装的包是否有新版本
+// 有 outdated 命令的包管理器使用命令结果，其余根据语言检测列出的包查询注册表
+// managers 为空时检查全部包管理器；每个包管理器完成后通过 package:outdated 事件推送结果
+func (a *App) CheckOutdatedPackages(managers []string) OutdatedReport {
+	installed := a.installedPackagesByManager()
+	checkers := a.outdatedCheckers()
+
+	wanted := map[string]bool{}
+	for _, manager := range managers {
+		wanted[strings.ToLower(strings.TrimSpace(manager))] = true
+	}
+	selected := []string{}
+	for manager := range installed {
+		selected = append(selected, manager)
+	}
+	for manager, checker := range checkers {
+		if _, ok := installed[manager]; !ok && commandExists(checker.command) {
+			selected = append(selected, manager)
+		}
+	}
+
+	report := OutdatedReport{
+		GeneratedAt: time.Now().Format(time.RFC3339),
+		Packages:    []OutdatedPackage{},
+		Managers:    []OutdatedManagerStatus{},
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, manager := range selected {
+		if len(wanted) > 0 && !wanted[manager] {
+			continue
+		}
+		wg.Add(1)
+		go func(manager string) {
+			defer wg.Done()
+			started := time.Now()
+			status := OutdatedManagerStatus{Manager: manager, Method: "registry", Checked: len(installed[manager])}
+
+			var packages []OutdatedPackage
+			var err error
+			source := "registry"
+			if checker, ok := checkers[manager]; ok && commandExists(checker.command) {
+				status.Method = checker.method
+				source = "cli"
+				packages, err = checker.check()
+			}
+			if source == "registry" || (err != nil && len(installed[manager]) > 0) {
+				// 命令执行失败时退回注册表比对
+				if err != nil {
+					fmt.Printf("%s 检查失败，改用注册表比对: %v\n", status.Method, err)
+					status.Method = "registry"
+					source = "registry"
+				}
+				packages, status.Checked, err = a.checkOutdatedWithRegistry(manager, installed[manager])
+			}
+			if err != nil {
+				status.Error = err.Error()
+			}
+
+			for i := range packages {
+				packages[i].Manager = manager
+				packages[i].Source = source
+				packages[i].Update = classifyUpdate(packages[i].Current, packages[i].Latest)
+			}
+			if status.Checked < len(packages) {
+				status.Checked = len(packages)
+			}
+			status.Outdated = len(packages)
+			status.ElapsedMs = time.Since(started).Milliseconds()
+			a.emitOutdatedEvent(status, packages)
+
+			mu.Lock()
+			report.Packages = append(report.Packages, packages...)
+			report.Managers = append(report.Managers, status)
+			mu.Unlock()
+		}(manager)
+	}
+	wg.Wait()
+
+	// 不兼容更新排在前面，便于优先评估
+	rank := map[string]int{"major": 0, "minor": 1, "patch": 2, "unknown": 3}
+	sort.SliceStable(report.Packages, func(i, j int) bool {
+		pi, pj := report.Packages[i], report.Packages[j]
+		if rank[pi.Update] != rank[pj.Update] {
+			return rank[pi.Update] < rank[pj.Update]
+		}
+		if pi.Manager != pj.Manager {
+			return pi.Manager < pj.Manager
+		}
+		return strings.ToLower(pi.Name) < strings.ToLower(pj.Name)
+	})
+	sort.Slice(report.Managers, func(i, j int) bool { return report.Managers[i].Manager < report.Managers[j].Manager })
+
+	for _, pkg := range report.Packages {
+		switch pkg.Update {
+		case "patch":
+			report.Summary.Patch++
+		case "minor":
+			report.Summary.Minor++
+		case "major":
+			report.Summary.Major++
+		default:
+			report.Summary.Unknown++
+		}
+	}
+
+	return report
+}
+
+// outdatedReportToMarkdown 将过期包报告转换为Markdown表格
+func outdatedReportToMarkdown(report OutdatedReport) string {
+	var builder strings.Builder
+	builder.WriteString("# 过期包报告\n\n")
+	builder.WriteString(fmt.Sprintf("生成时间: %s\n\n", report.GeneratedAt))
+	builder.WriteString(fmt.Sprintf("不兼容更新 %d 个，次版本更新 %d 个，修订更新 %d 个，无法判断 %d 个\n\n",
+		report.Summary.Major, report.Summary.Minor, report.Summary.Patch, report.Summary.Unknown))
+
+	builder.WriteString("| 包管理器 | 包 | 当前版本 | 最新版本 | 更新级别 |\n")
+	builder.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, pkg := range report.Packages {
+		builder.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n", pkg.Manager, pkg.Name, pkg.Current, pkg.Latest, pkg.Update))
+	}
+
+	builder.WriteString("\n## 检查情况\n\n")
+	for _, status := range report.Managers {
+		line := fmt.Sprintf("- %s（%s）：检查 %d 个，过期 %d 个", status.Manager, status.Method, status.Checked, status.Outdated)
+		if status.Error != "" {
+			line += "，错误: " + status.Error
+		}
+		builder.WriteString(line + "\n")
+	}
+	return builder.String()
+}
+
+// outdatedReportToCSV 将过期包报告转换为CSV
+func outdatedReportToCSV(report OutdatedReport) ([]byte, error) {
+	var buffer bytes.Buffer
+	// 写入BOM，方便Excel正确识别UTF-8
+	buffer.WriteString("\uFEFF")
+	writer := csv.NewWriter(&buffer)
+	writer.Write([]string{"manager", "name", "current", "wanted", "latest", "update", "source"})
+	for _, pkg := range report.Packages {
+		writer.Write([]string{pkg.Manager, pkg.Name, pkg.Current, pkg.Wanted, pkg.Latest, pkg.Update, pkg.Source})
+	}
+	writer.Flush()
+	return buffer.Bytes(), writer.Error()
+}
+
+// ExportOutdatedReport 将过期包报告导出为 markdown、csv 或 json 文件，返回保存路径
+// 用户取消保存对话框时返回空路径
+func (a *App) ExportOutdatedReport(report OutdatedReport, format string) (string, error) {
+	var data []byte
+	var err error
+	var filter wailsruntime.FileFilter
+
+	switch format {
+	case "markdown", "md":
+		data = []byte(outdatedReportToMarkdown(report))
+		format = "md"
+		filter = wailsruntime.FileFilter{DisplayName: "Markdown (*.md)", Pattern: "*.md"}
+	case "csv":
+		data, err = outdatedReportToCSV(report)
+		filter = wailsruntime.FileFilter{DisplayName: "CSV (*.csv)", Pattern: "*.csv"}
+	case "json":
+		data, err = json.MarshalIndent(report, "", "  ")
+		filter = wailsruntime.FileFilter{DisplayName: "JSON (*.json)", Pattern: "*.json"}
+	default:
+		return "", fmt.Errorf("不支持的导出格式: %s", format)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if a.ctx == nil {
+		return "", fmt.Errorf("应用程序尚未启动")
+	}
+
+	path, err := wailsruntime.SaveFileDialog(a.ctx, wailsruntime.SaveDialogOptions{
+		Title:           "导出过期包报告",
+		DefaultFilename: "outdated-" + time.Now().Format("20060102") + "." + format,
+		Filters:         []wailsruntime.FileFilter{filter},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	return path, os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,683 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// defaultRegistryBaseURLs 各包注册表API的默认地址
+var defaultRegistryBaseURLs = map[string]string{
+	"npm":      "https://registry.npmjs.org",
+	"pip":      "https://pypi.org",
+	"gem":      "https://rubygems.org",
+	"cargo":    "https://crates.io",
+	"composer": "https://packagist.org",
+	"nuget":    "https://azuresearch-usnc.nuget.org",
+	"maven":    "https://search.maven.org",
+	"go":       "https://proxy.golang.org",
+	"hex":      "https://hex.pm",
+	"dub":      "https://code.dlang.org",
+	"nimble":   "https://raw.githubusercontent.com/nim-lang/packages/master",
+}
+
+// RegistryMirror 常用的注册表镜像
+type RegistryMirror struct {
+	Manager string `json:"manager"`
+	Name    string `json:"name"`
+	BaseURL string `json:"baseUrl"`
+}
+
+// registryMirrorPresets 国内常用镜像，选择后写入对应包管理器的地址
+// rsproxy只镜像crates.io的稀疏索引，没有搜索接口，cargo只能按名称精确查询
+var registryMirrorPresets = []RegistryMirror{
+	{Manager: "npm", Name: "npmmirror（淘宝）", BaseURL: "https://registry.npmmirror.com"},
+	{Manager: "pip", Name: "清华大学TUNA", BaseURL: "https://pypi.tuna.tsinghua.edu.cn"},
+	{Manager: "go", Name: "goproxy.cn（七牛云）", BaseURL: "https://goproxy.cn"},
+	{Manager: "cargo", Name: "rsproxy（字节跳动）", BaseURL: "sparse+https://rsproxy.cn/index"},
+}
+
+// sparseIndexPrefix cargo稀疏索引地址的前缀，与cargo配置文件的写法一致
+const sparseIndexPrefix = "sparse+"
+
+// registrySearchLimit 每个注册表最多返回的结果数
+const registrySearchLimit = 20
+
+// registryUserAgent crates.io等注册表要求请求带有可识别的User-Agent
+const registryUserAgent = "Coding-Tester (https://github.com/fayufm/Coding-Tester)"
+
+// errRegistryNotFound 注册表中不存在该包（HTTP 404）
+var errRegistryNotFound = errors.New("包不存在")
+
+// errKeywordSearchUnsupported 注册表只支持按完整名称查询，不支持关键词搜索
+var errKeywordSearchUnsupported = errors.New("不支持关键词搜索")
+
+// RegistrySettings 包注册表设置
+type RegistrySettings struct {
+	// BaseURLs 按包管理器覆盖的API地址，未设置的使用默认地址
+	BaseURLs map[string]string `json:"baseUrls"`
+	// Defaults 和 Mirrors 仅用于返回给前端展示，不写入磁盘
+	Defaults map[string]string `json:"defaults,omitempty"`
+	Mirrors  []RegistryMirror  `json:"mirrors,omitempty"`
+}
+
+// registryClient 访问单个包注册表JSON接口的客户端
+type registryClient struct {
+	manager string
+	baseURL string
+	client  *http.Client
+}
+
+// getRegistrySettingsPath 获取注册表设置文件路径
+func (a *App) getRegistrySettingsPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "registries.json"
+	}
+	return filepath.Join(homeDir, ".networ_tester", "registries.json")
+}
+
+// GetRegistrySettings 获取包注册表设置
+func (a *App) GetRegistrySettings() RegistrySettings {
+	settings := RegistrySettings{}
+	if data, err := os.ReadFile(a.getRegistrySettingsPath()); err == nil {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			fmt.Printf("解析注册表设置失败: %v\n", err)
+			settings = RegistrySettings{}
+		}
+	}
+	if settings.BaseURLs == nil {
+		settings.BaseURLs = map[string]string{}
+	}
+	settings.Defaults = defaultRegistryBaseURLs
+	settings.Mirrors = registryMirrorPresets
+	return settings
+}
+
+// SaveRegistrySettings 保存包注册表设置，地址为空或与默认地址相同时不保存
+func (a *App) SaveRegistrySettings(settings RegistrySettings) error {
+	baseURLs := map[string]string{}
+	for manager, baseURL := range settings.BaseURLs {
+		if _, ok := defaultRegistryBaseURLs[manager]; !ok {
+			return fmt.Errorf("未知的包管理器: %s", manager)
+		}
+		baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+		if baseURL == "" || baseURL == defaultRegistryBaseURLs[manager] {
+			continue
+		}
+		address := baseURL
+		if manager == "cargo" {
+			address = strings.TrimPrefix(address, sparseIndexPrefix)
+		}
+		if parsed, err := url.Parse(address); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("%s 的地址必须是http或https地址: %s", manager, baseURL)
+		}
+		baseURLs[manager] = baseURL
+	}
+
+	data, err := json.MarshalIndent(RegistrySettings{BaseURLs: baseURLs}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	os.MkdirAll(filepath.Dir(a.getRegistrySettingsPath()), 0755)
+	return os.WriteFile(a.getRegistrySettingsPath(), data, 0644)
+}
+
+// registryClient 创建指定包管理器的注册表客户端
+func (a *App) registryClient(manager string) registryClient {
+	baseURL := defaultRegistryBaseURLs[manager]
+	if custom := a.GetRegistrySettings().BaseURLs[manager]; custom != "" {
+		baseURL = custom
+	}
+	return registryClient{
+		manager: manager,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  newHTTPClient(15 * time.Second),
+	}
+}
+
+// get 请求注册表接口并返回响应体，404 返回 errRegistryNotFound
+func (c registryClient) get(ctx context.Context, path string, query url.Values, accept string) ([]byte, error) {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("User-Agent", registryUserAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求 %s 失败: %v", c.manager, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return nil, errRegistryNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%s 返回状态码 %d: %s", c.manager, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 响应失败: %v", c.manager, err)
+	}
+	return body, nil
+}
+
+// getJSON 请求注册表接口并解析JSON响应，404 返回 errRegistryNotFound
+func (c registryClient) getJSON(ctx context.Context, path string, query url.Values, out interface{}) error {
+	body, err := c.get(ctx, path, query, "application/json")
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("解析 %s 响应失败: %v", c.manager, err)
+	}
+	return nil
+}
+
+// normalizeRegistryTime 将注册表返回的时间统一为RFC3339格式，无法解析时返回空字符串
+func normalizeRegistryTime(value string) string {
+	if value == "" {
+		return ""
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed.UTC().Format(time.RFC3339)
+		}
+	}
+	return ""
+}
+
+// searchNpmPackage 通过npm注册表的搜索接口搜索包
+func (a *App) searchNpmPackage(ctx context.Context, packageName string) ([]PackageInfo, error) {
+	var result struct {
+		Objects []struct {
+			Package struct {
+				Name        string `json:"name"`
+				Version     string `json:"version"`
+				Description string `json:"description"`
+				Date        string `json:"date"`
+			} `json:"package"`
+			Downloads struct {
+				Monthly int64 `json:"monthly"`
+			} `json:"downloads"`
+		} `json:"objects"`
+	}
+
+	query := url.Values{"text": {packageName}, "size": {fmt.Sprint(registrySearchLimit)}}
+	if err := a.registryClient("npm").getJSON(ctx, "/-/v1/search", query, &result); err != nil {
+		return nil, err
+	}
+
+	packages := []PackageInfo{}
+	for _, object := range result.Objects {
+		packages = append(packages, PackageInfo{
+			Name:        object.Package.Name,
+			Version:     object.Package.Version,
+			Description: object.Package.Description,
+			Downloads:   object.Downloads.Monthly,
+			UpdatedAt:   normalizeRegistryTime(object.Package.Date),
+		})
+	}
+	return packages, nil
+}
+
+// searchPipPackage 查询PyPI的JSON接口
+// PyPI没有搜索接口，只能按名称精确查询
+func (a *App) searchPipPackage(ctx context.Context, packageName string) ([]PackageInfo, error) {
+	var result struct {
+		Info struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+			Summary string `json:"summary"`
+		} `json:"info"`
+		URLs []struct {
+			UploadTime string `json:"upload_time_iso_8601"`
+		} `json:"urls"`
+	}
+
+	err := a.registryClient("pip").getJSON(ctx, "/pypi/"+url.PathEscape(packageName)+"/json", nil, &result)
+	if errors.Is(err, errRegistryNotFound) {
+		return []PackageInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := PackageInfo{
+		Name:        result.Info.Name,
+		Version:     result.Info.Version,
+		Description: result.Info.Summary,
+	}
+	if len(result.URLs) > 0 {
+		pkg.UpdatedAt = normalizeRegistryTime(result.URLs[0].UploadTime)
+	}
+	return []PackageInfo{pkg}, nil
+}
+
+// searchGemPackage 通过RubyGems的搜索接口搜索gem
+func (a *App) searchGemPackage(ctx context.Context, packageName string) ([]PackageInfo, error) {
+	var result []struct {
+		Name             string `json:"name"`
+		Version          string `json:"version"`
+		Info             string `json:"info"`
+		Downloads        int64  `json:"downloads"`
+		VersionCreatedAt string `json:"version_created_at"`
+	}
+
+	if err := a.registryClient("gem").getJSON(ctx, "/api/v1/search.json", url.Values{"query": {packageName}}, &result); err != nil {
+		return nil, err
+	}
+
+	packages := []PackageInfo{}
+	for _, gem := range result {
+		packages = append(packages, PackageInfo{
+			Name:        gem.Name,
+			Version:     gem.Version,
+			Description: gem.Info,
+			Downloads:   gem.Downloads,
+			UpdatedAt:   normalizeRegistryTime(gem.VersionCreatedAt),
+		})
+		if len(packages) >= registrySearchLimit {
+			break
+		}
+	}
+	return packages, nil
+}
+
+// searchCargoPackage 通过crates.io的接口搜索crate
+func (a *App) searchCargoPackage(ctx context.Context, packageName string) ([]PackageInfo, error) {
+	var result struct {
+		Crates []struct {
+			Name          string `json:"name"`
+			MaxVersion    string `json:"max_stable_version"`
+			NewestVersion string `json:"newest_version"`
+			Description   string `json:"description"`
+			Downloads     int64  `json:"downloads"`
+			UpdatedAt     string `json:"updated_at"`
+		} `json:"crates"`
+	}
+
+	client := a.registryClient("cargo")
+	if strings.HasPrefix(client.baseURL, sparseIndexPrefix) {
+		return searchCargoSparseIndex(ctx, client, packageName)
+	}
+
+	query := url.Values{"q": {packageName}, "per_page": {fmt.Sprint(registrySearchLimit)}}
+	if err := client.getJSON(ctx, "/api/v1/crates", query, &result); err != nil {
+		return nil, err
+	}
+
+	packages := []PackageInfo{}
+	for _, crate := range result.Crates {
+		version := crate.MaxVersion
+		if version == "" {
+			version = crate.NewestVersion
+		}
+		packages = append(packages, PackageInfo{
+			Name:        crate.Name,
+			Version:     version,
+			Description: crate.Description,
+			Downloads:   crate.Downloads,
+			UpdatedAt:   normalizeRegistryTime(crate.UpdatedAt),
+		})
+	}
+	return packages, nil
+}
+
+// cargoSparseIndexPath 按cargo稀疏索引的目录规则计算crate的路径
+// 1、2个字符的名称放在 1/、2/ 下，3个字符放在 3/首字母/ 下，其余按前两个和第三、四个字符分两级目录
+func cargoSparseIndexPath(name string) string {
+	name = strings.ToLower(name)
+	switch len(name) {
+	case 1:
+		return "/1/" + name
+	case 2:
+		return "/2/" + name
+	case 3:
+		return "/3/" + name[:1] + "/" + name
+	default:
+		return "/" + name[:2] + "/" + name[2:4] + "/" + name
+	}
+}
+
+// searchCargoSparseIndex 在稀疏索引镜像中按名称精确查询crate
+// 索引文件每行是一个版本的JSON，按发布顺序排列，取最后一个未撤回的版本
+func searchCargoSparseIndex(ctx context.Context, client registryClient, packageName string) ([]PackageInfo, error) {
+	name := strings.TrimSpace(packageName)
+	if name == "" || strings.ContainsAny(name, "/\\ ") {
+		return []PackageInfo{}, nil
+	}
+
+	client.baseURL = strings.TrimPrefix(client.baseURL, sparseIndexPrefix)
+	body, err := client.get(ctx, cargoSparseIndexPath(name), nil, "text/plain")
+	if errors.Is(err, errRegistryNotFound) {
+		return []PackageInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := PackageInfo{}
+	for _, line := range strings.Split(string(body), "\n") {
+		var entry struct {
+			Name    string `json:"name"`
+			Version string `json:"vers"`
+			Yanked  bool   `json:"yanked"`
+		}
+		if json.Unmarshal([]byte(line), &entry) != nil || entry.Yanked {
+			continue
+		}
+		pkg.Name = entry.Name
+		pkg.Version = entry.Version
+	}
+	if pkg.Name == "" {
+		return []PackageInfo{}, nil
+	}
+	return []PackageInfo{pkg}, nil
+}
+
+// searchComposerPackage 通过Packagist的搜索接口搜索PHP包
+func (a *App) searchComposerPackage(ctx context.Context, packageName string) ([]PackageInfo, error) {
+	var result struct {
+		Results []struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			Downloads   int64  `json:"downloads"`
+		} `json:"results"`
+	}
+
+	query := url.Values{"q": {packageName}, "per_page": {fmt.Sprint(registrySearchLimit)}}
+	if err := a.registryClient("composer").getJSON(ctx, "/search.json", query, &result); err != nil {
+		return nil, err
+	}
+
+	packages := []PackageInfo{}
+	for _, item := range result.Results {
+		packages = append(packages, PackageInfo{
+			Name:        item.Name,
+			Description: item.Description,
+			Downloads:   item.Downloads,
+		})
+	}
+	return packages, nil
+}
+
+// searchNuGetPackage 通过NuGet的搜索服务搜索.NET包
+func (a *App) searchNuGetPackage(ctx context.Context, packageName string) ([]PackageInfo, error) {
+	var result struct {
+		Data []struct {
+			ID             string `json:"id"`
+			Version        string `json:"version"`
+			Description    string `json:"description"`
+			TotalDownloads int64  `json:"totalDownloads"`
+		} `json:"data"`
+	}
+
+	query := url.Values{"q": {packageName}, "take": {fmt.Sprint(registrySearchLimit)}, "prerelease": {"false"}}
+	if err := a.registryClient("nuget").getJSON(ctx, "/query", query, &result); err != nil {
+		return nil, err
+	}
+
+	packages := []PackageInfo{}
+	for _, item := range result.Data {
+		packages = append(packages, PackageInfo{
+			Name:        item.ID,
+			Version:     item.Version,
+			Description: item.Description,
+			Downloads:   item.TotalDownloads,
+		})
+	}
+	return packages, nil
+}
+
+// searchMavenPackage 通过Maven Central的搜索接口搜索Java包
+func (a *App) searchMavenPackage(ctx context.Context, packageName string) ([]PackageInfo, error) {
+	var result struct {
+		Response struct {
+			Docs []struct {
+				GroupID       string `json:"g"`
+				ArtifactID    string `json:"a"`
+				LatestVersion string `json:"latestVersion"`
+				Timestamp     int64  `json:"timestamp"`
+			} `json:"docs"`
+		} `json:"response"`
+	}
+
+	query := url.Values{"q": {packageName}, "rows": {fmt.Sprint(registrySearchLimit)}, "wt": {"json"}}
+	if err := a.registryClient("maven").getJSON(ctx, "/solrsearch/select", query, &result); err != nil {
+		return nil, err
+	}
+
+	packages := []PackageInfo{}
+	for _, doc := range result.Response.Docs {
+		pkg := PackageInfo{
+			Name:    doc.GroupID + ":" + doc.ArtifactID,
+			Version: doc.LatestVersion,
+		}
+		if doc.Timestamp > 0 {
+			pkg.UpdatedAt = time.UnixMilli(doc.Timestamp).UTC().Format(time.RFC3339)
+		}
+		packages = append(packages, pkg)
+	}
+	return packages, nil
+}
+
+// escapeGoModulePath 按模块代理协议转义模块路径：大写字母写成 ! 加小写字母
+func escapeGoModulePath(path string) string {
+	var builder strings.Builder
+	for _, r := range path {
+		if unicode.IsUpper(r) {
+			builder.WriteByte('!')
+			builder.WriteRune(unicode.ToLower(r))
+		} else {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// searchGoPackage 通过Go模块代理查询模块的最新版本
+// 模块代理没有搜索接口，只能查询完整的模块路径，输入关键词时返回 errKeywordSearchUnsupported
+func (a *App) searchGoPackage(ctx context.Context, packageName string) ([]PackageInfo, error) {
+	modulePath := strings.Trim(strings.TrimSpace(packageName), "/")
+	if !strings.Contains(modulePath, "/") && !strings.Contains(modulePath, ".") {
+		return nil, fmt.Errorf("%w，请输入完整的Go模块路径（如 github.com/gin-gonic/gin）", errKeywordSearchUnsupported)
+	}
+	return a.lookupGoModule(ctx, modulePath)
+}
+
+// lookupGoModule 通过Go模块代理查询模块的最新版本，模块不存在时返回空列表
+func (a *App) lookupGoModule(ctx context.Context, modulePath string) ([]PackageInfo, error) {
+	var result struct {
+		Version string `json:"Version"`
+		Time    string `json:"Time"`
+	}
+
+	err := a.registryClient("go").getJSON(ctx, "/"+escapeGoModulePath(modulePath)+"/@latest", nil, &result)
+	if errors.Is(err, errRegistryNotFound) {
+		return []PackageInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return []PackageInfo{{
+		Name:      modulePath,
+		Version:   result.Version,
+		UpdatedAt: normalizeRegistryTime(result.Time),
+	}}, nil
+}
+
+// searchHexPackage 通过hex.pm的接口搜索Elixir/Erlang包
+func (a *App) searchHexPackage(ctx context.Context, packageName string) ([]PackageInfo, error) {
+	var result []struct {
+		Name                string `json:"name"`
+		LatestStableVersion string `json:"latest_stable_version"`
+		LatestVersion       string `json:"latest_version"`
+		UpdatedAt           string `json:"updated_at"`
+		Meta                struct {
+			Description string `json:"description"`
+		} `json:"meta"`
+		Downloads struct {
+			All int64 `json:"all"`
+		} `json:"downloads"`
+	}
+
+	query := url.Values{"search": {packageName}, "sort": {"recent_downloads"}}
+	if err := a.registryClient("hex").getJSON(ctx, "/api/packages", query, &result); err != nil {
+		return nil, err
+	}
+
+	packages := []PackageInfo{}
+	for _, item := range result {
+		version := item.LatestStableVersion
+		if version == "" {
+			version = item.LatestVersion
+		}
+		packages = append(packages, PackageInfo{
+			Name:        item.Name,
+			Version:     version,
+			Description: item.Meta.Description,
+			Downloads:   item.Downloads.All,
+			UpdatedAt:   normalizeRegistryTime(item.UpdatedAt),
+		})
+		if len(packages) >= registrySearchLimit {
+			break
+		}
+	}
+	return packages, nil
+}
+
+// searchDubPackage 通过DUB注册表的接口搜索D语言包
+func (a *App) searchDubPackage(ctx context.Context, packageName string) ([]PackageInfo, error) {
+	var result []struct {
+		Name        string `json:"name"`
+		Version     string `json:"version"`
+		Description string `json:"description"`
+	}
+
+	if err := a.registryClient("dub").getJSON(ctx, "/api/packages/search", url.Values{"q": {packageName}}, &result); err != nil {
+		return nil, err
+	}
+
+	packages := []PackageInfo{}
+	for _, item := range result {
+		packages = append(packages, PackageInfo{
+			Name:        item.Name,
+			Version:     item.Version,
+			Description: item.Description,
+		})
+		if len(packages) >= registrySearchLimit {
+			break
+		}
+	}
+	return packages, nil
+}
+
+// nimblePackage Nimble包列表中的一项
+type nimblePackage struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	URL         string   `json:"url"`
+	License     string   `json:"license"`
+	Web         string   `json:"web"`
+	// Alias 改名后的旧包只有此字段，指向新名称
+	Alias string `json:"alias"`
+}
+
+// nimblePackageCache 缓存的Nimble包列表，列表文件较大，一小时内重复使用
+var nimblePackageCache struct {
+	sync.Mutex
+	baseURL  string
+	fetched  time.Time
+	packages []nimblePackage
+}
+
+// loadNimblePackages 下载或读取缓存的Nimble包列表
+func (a *App) loadNimblePackages(ctx context.Context) ([]nimblePackage, error) {
+	client := a.registryClient("nimble")
+
+	nimblePackageCache.Lock()
+	defer nimblePackageCache.Unlock()
+
+	if nimblePackageCache.baseURL == client.baseURL && time.Since(nimblePackageCache.fetched) < time.Hour {
+		return nimblePackageCache.packages, nil
+	}
+
+	var packages []nimblePackage
+	if err := client.getJSON(ctx, "/packages.json", nil, &packages); err != nil {
+		return nil, err
+	}
+
+	nimblePackageCache.baseURL = client.baseURL
+	nimblePackageCache.fetched = time.Now()
+	nimblePackageCache.packages = packages
+	return packages, nil
+}
+
+// searchNimblePackage 在Nimble官方包列表中按名称、标签和描述搜索
+func (a *App) searchNimblePackage(ctx context.Context, packageName string) ([]PackageInfo, error) {
+	all, err := a.loadNimblePackages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := strings.ToLower(packageName)
+	type match struct {
+		pkg  nimblePackage
+		rank int
+	}
+	matches := []match{}
+	for _, pkg := range all {
+		name := strings.ToLower(pkg.Name)
+		rank := -1
+		switch {
+		case name == query:
+			rank = 0
+		case strings.Contains(name, query):
+			rank = 1
+		case strings.Contains(strings.ToLower(strings.Join(pkg.Tags, " ")), query):
+			rank = 2
+		case strings.Contains(strings.ToLower(pkg.Description), query):
+			rank = 3
+		}
+		if rank >= 0 {
+			matches = append(matches, match{pkg: pkg, rank: rank})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].rank < matches[j].rank })
+
+	packages := []PackageInfo{}
+	for _, m := range matches {
+		packages = append(packages, PackageInfo{
+			Name:        m.pkg.Name,
+			Description: m.pkg.Description,
+		})
+		if len(packages) >= registrySearchLimit {
+			break
+		}
+	}
+	return packages, nil
+}
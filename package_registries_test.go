@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// registryFixtures 各注册表接口的示例响应，按请求路径匹配
+var registryFixtures = map[string]string{
+	"/-/v1/search":                          `{"objects":[{"package":{"name":"left-pad","version":"1.3.0","description":"String left pad","date":"2018-04-09T01:32:20.000Z"},"downloads":{"monthly":1200}}]}`,
+	"/pypi/requests/json":                   `{"info":{"name":"requests","version":"2.32.3","summary":"Python HTTP for Humans."},"urls":[{"upload_time_iso_8601":"2024-05-29T15:37:47.000000Z"}]}`,
+	"/api/v1/search.json":                   `[{"name":"rails","version":"7.1.3","info":"Full-stack web framework","downloads":500,"version_created_at":"2024-01-16T21:00:00.000Z"}]`,
+	"/api/v1/crates":                        `{"crates":[{"name":"serde","max_stable_version":"1.0.200","newest_version":"1.0.201-rc1","description":"A serialization framework","downloads":9000,"updated_at":"2024-05-01T00:00:00.000000+00:00"}]}`,
+	"/se/rd/serde":                          "{\"name\":\"serde\",\"vers\":\"1.0.199\",\"yanked\":false}\n{\"name\":\"serde\",\"vers\":\"1.0.200\",\"yanked\":false}\n{\"name\":\"serde\",\"vers\":\"1.0.201\",\"yanked\":true}\n",
+	"/search.json":                          `{"results":[{"name":"monolog/monolog","description":"Logging for PHP","downloads":300}]}`,
+	"/query":                                `{"data":[{"id":"Newtonsoft.Json","version":"13.0.3","description":"Json.NET","totalDownloads":4000}]}`,
+	"/solrsearch/select":                    `{"response":{"docs":[{"g":"com.google.guava","a":"guava","latestVersion":"33.2.0-jre","timestamp":1714000000000}]}}`,
+	"/github.com/!burnt!sushi/toml/@latest": `{"Version":"v1.4.0","Time":"2024-06-01T00:00:00Z"}`,
+	"/api/packages":                         `[{"name":"phoenix","latest_stable_version":"1.7.12","latest_version":"1.8.0-rc.0","updated_at":"2024-04-22T00:00:00.000000Z","meta":{"description":"Web framework"},"downloads":{"all":700}}]`,
+	"/api/packages/search":                  `[{"name":"vibe-d","version":"0.10.0","description":"Event driven web framework"}]`,
+}
+
+// newRegistryFixtureApp 把所有注册表地址指向返回示例响应的本地服务器
+func newRegistryFixtureApp(t *testing.T) (*App, *httptest.Server) {
+	t.Helper()
+	useTempHome(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != registryUserAgent {
+			t.Errorf("请求未设置User-Agent: %s", r.URL)
+		}
+		body, ok := registryFixtures[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	app := NewApp()
+	baseURLs := map[string]string{}
+	for manager := range defaultRegistryBaseURLs {
+		baseURLs[manager] = server.URL
+	}
+	if err := app.SaveRegistrySettings(RegistrySettings{BaseURLs: baseURLs}); err != nil {
+		t.Fatalf("保存注册表设置失败: %v", err)
+	}
+	return app, server
+}
+
+func TestRegistryClientsParseFixtures(t *testing.T) {
+	app, _ := newRegistryFixtureApp(t)
+	ctx := context.Background()
+
+	cases := []struct {
+		name   string
+		search func(context.Context, string) ([]PackageInfo, error)
+		query  string
+		want   PackageInfo
+	}{
+		{"npm", app.searchNpmPackage, "left-pad", PackageInfo{Name: "left-pad", Version: "1.3.0", Description: "String left pad", Downloads: 1200, UpdatedAt: "2018-04-09T01:32:20Z"}},
+		{"pip", app.searchPipPackage, "requests", PackageInfo{Name: "requests", Version: "2.32.3", Description: "Python HTTP for Humans.", UpdatedAt: "2024-05-29T15:37:47Z"}},
+		{"gem", app.searchGemPackage, "rails", PackageInfo{Name: "rails", Version: "7.1.3", Description: "Full-stack web framework", Downloads: 500, UpdatedAt: "2024-01-16T21:00:00Z"}},
+		{"cargo", app.searchCargoPackage, "serde", PackageInfo{Name: "serde", Version: "1.0.200", Description: "A serialization framework", Downloads: 9000, UpdatedAt: "2024-05-01T00:00:00Z"}},
+		{"composer", app.searchComposerPackage, "monolog", PackageInfo{Name: "monolog/monolog", Description: "Logging for PHP", Downloads: 300}},
+		{"nuget", app.searchNuGetPackage, "json", PackageInfo{Name: "Newtonsoft.Json", Version: "13.0.3", Description: "Json.NET", Downloads: 4000}},
+		{"maven", app.searchMavenPackage, "guava", PackageInfo{Name: "com.google.guava:guava", Version: "33.2.0-jre", UpdatedAt: "2024-04-24T23:06:40Z"}},
+		{"go module", app.searchGoPackage, "github.com/BurntSushi/toml", PackageInfo{Name: "github.com/BurntSushi/toml", Version: "v1.4.0", UpdatedAt: "2024-06-01T00:00:00Z"}},
+		{"hex", app.searchHexPackage, "phoenix", PackageInfo{Name: "phoenix", Version: "1.7.12", Description: "Web framework", Downloads: 700, UpdatedAt: "2024-04-22T00:00:00Z"}},
+		{"dub", app.searchDubPackage, "vibe", PackageInfo{Name: "vibe-d", Version: "0.10.0", Description: "Event driven web framework"}},
+	}
+
+	for _, c := range cases {
+		packages, err := c.search(ctx, c.query)
+		if err != nil {
+			t.Errorf("%s: 搜索失败: %v", c.name, err)
+			continue
+		}
+		if len(packages) == 0 || packages[0] != c.want {
+			t.Errorf("%s: 解析结果不正确:\n得到 %+v\n期望 %+v", c.name, packages, c.want)
+		}
+	}
+}
+
+func TestSearchGoPackageModulePathOnly(t *testing.T) {
+	app, _ := newRegistryFixtureApp(t)
+
+	// 模块代理没有搜索接口，关键词搜索应标记为不支持
+	packages, err := app.searchGoPackage(context.Background(), "gin")
+	if !errors.Is(err, errKeywordSearchUnsupported) {
+		t.Errorf("关键词搜索应返回不支持: %v", err)
+	}
+	if len(packages) != 0 {
+		t.Errorf("关键词搜索不应返回结果: %+v", packages)
+	}
+
+	// 模块代理中不存在的路径返回空列表
+	packages, err = app.searchGoPackage(context.Background(), "example.com/missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packages) != 0 {
+		t.Errorf("模块不存在时应返回空列表: %+v", packages)
+	}
+
+	_, status := app.runPackageSearcher(packageSearcher{manager: "go", timeout: time.Second, search: app.searchGoPackage}, "gin")
+	if !status.Unsupported || status.Error == "" {
+		t.Errorf("搜索状态应标记为不支持关键词: %+v", status)
+	}
+}
+
+func TestSearchCargoSparseIndex(t *testing.T) {
+	app, server := newRegistryFixtureApp(t)
+	if err := app.SaveRegistrySettings(RegistrySettings{BaseURLs: map[string]string{"cargo": sparseIndexPrefix + server.URL}}); err != nil {
+		t.Fatal(err)
+	}
+
+	packages, err := app.searchCargoPackage(context.Background(), "serde")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 已撤回的版本应跳过
+	if len(packages) != 1 || packages[0].Version != "1.0.200" {
+		t.Errorf("稀疏索引解析不正确: %+v", packages)
+	}
+
+	packages, err = app.searchCargoPackage(context.Background(), "missing-crate")
+	if err != nil || len(packages) != 0 {
+		t.Errorf("不存在的crate应返回空列表: %+v %v", packages, err)
+	}
+}
+
+func TestRegistryClientReportsHTTPErrors(t *testing.T) {
+	useTempHome(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream unavailable", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	app := NewApp()
+	if err := app.SaveRegistrySettings(RegistrySettings{BaseURLs: map[string]string{"npm": server.URL}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.searchNpmPackage(context.Background(), "left-pad"); err == nil {
+		t.Error("注册表返回错误状态码时应报告错误")
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// packageSearchEventName 每个注册表返回结果时推送的事件
+const packageSearchEventName = "package:search"
+
+// packageSearcher 描述一个可搜索的包注册表
+type packageSearcher struct {
+	manager string
+	// languages 该注册表服务的语言，与语言检测结果中的名称一致
+	languages []string
+	// command 依赖的命令行工具，为空表示通过HTTP接口搜索
+	command string
+	timeout time.Duration
+	search  func(ctx context.Context, query string) ([]PackageInfo, error)
+}
+
+// PackageSearchStatus 单个注册表的搜索状态
+type PackageSearchStatus struct {
+	Manager   string `json:"manager"`
+	Count     int    `json:"count"`
+	ElapsedMs int64  `json:"elapsedMs"`
+	TimedOut  bool   `json:"timedOut,omitempty"`
+	// Skipped 为true时表示缺少命令行工具等原因没有搜索
+	Skipped bool `json:"skipped,omitempty"`
+	// Unsupported 为true时表示该注册表不支持关键词搜索
+	Unsupported bool   `json:"unsupported,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// PackageSearchEvent 推送给前端的单个注册表结果
+type PackageSearchEvent struct {
+	Query    string              `json:"query"`
+	Status   PackageSearchStatus `json:"status"`
+	Packages []PackageInfo       `json:"packages"`
+}
+
+// PackageSearchResult 合并排序后的搜索结果
+type PackageSearchResult struct {
+	Query      string                `json:"query"`
+	Packages   []PackageInfo         `json:"packages"`
+	Registries []PackageSearchStatus `json:"registries"`
+}
+
+// packageSearchers 返回所有可搜索的注册表
+func (a *App) packageSearchers() []packageSearcher {
+	// Homebrew通过命令行搜索，不支持取消，超时后由 SearchAllPackages 放弃等待
+	brewSearch := func(ctx context.Context, query string) ([]PackageInfo, error) {
+		return a.searchBrewPackage(query)
+	}
+
+	return []packageSearcher{
+		{manager: "npm", languages: []string{"Node.js", "TypeScript", "CoffeeScript"}, timeout: 15 * time.Second, search: a.searchNpmPackage},
+		{manager: "pip", languages: []string{"Python", "Jython"}, timeout: 10 * time.Second, search: a.searchPipPackage},
+		{manager: "gem", languages: []string{"Ruby"}, timeout: 15 * time.Second, search: a.searchGemPackage},
+		{manager: "cargo", languages: []string{"Rust"}, timeout: 15 * time.Second, search: a.searchCargoPackage},
+		{manager: "composer", languages: []string{"PHP"}, timeout: 15 * time.Second, search: a.searchComposerPackage},
+		{manager: "nuget", languages: []string{"C# (.NET)", "F#"}, timeout: 15 * time.Second, search: a.searchNuGetPackage},
+		{manager: "maven", languages: []string{"Java", "Kotlin", "Scala", "Groovy", "Clojure"}, timeout: 10 * time.Second, search: a.searchMavenPackage},
+		{manager: "go", languages: []string{"Go"}, timeout: 10 * time.Second, search: a.searchGoPackage},
+		{manager: "dub", languages: []string{"D"}, timeout: 15 * time.Second, search: a.searchDubPackage},
+		{manager: "hex", languages: []string{"Elixir", "Erlang"}, timeout: 15 * time.Second, search: a.searchHexPackage},
+		{manager: "nimble", languages: []string{"Nim"}, timeout: 15 * time.Second, search: a.searchNimblePackage},
+		// Homebrew不属于任何语言，只在未指定语言时搜索
+		{manager: "brew", command: "brew", timeout: 20 * time.Second, search: brewSearch},
+	}
+}
+
+// selectPackageSearchers 按语言筛选注册表，languages 为空时使用全部注册表
+func selectPackageSearchers(searchers []packageSearcher, languages []string) []packageSearcher {
+	if len(languages) == 0 {
+		return searchers
+	}
+
+	wanted := map[string]bool{}
+	for _, language := range languages {
+		wanted[strings.ToLower(strings.TrimSpace(language))] = true
+	}
+
+	selected := []packageSearcher{}
+	for _, searcher := range searchers {
+		if wanted[searcher.manager] {
+			selected = append(selected, searcher)
+			continue
+		}
+		for _, language := range searcher.languages {
+			if wanted[strings.ToLower(language)] {
+				selected = append(selected, searcher)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// scorePackage 按名称匹配程度、注册表内的排名、下载量和更新时间计算排序分数
+func scorePackage(query string, pkg PackageInfo, position int, total int) float64 {
+	q := strings.ToLower(strings.TrimSpace(query))
+	name := strings.ToLower(pkg.Name)
+	// Maven的 group:artifact 和Go的模块路径按最后一段比较
+	short := name
+	if index := strings.LastIndexAny(name, ":/"); index >= 0 {
+		short = name[index+1:]
+	}
+
+	score := 0.0
+	switch {
+	case name == q || short == q:
+		score += 100
+	case strings.HasPrefix(short, q):
+		score += 40
+	case strings.Contains(name, q):
+		score += 20
+	}
+
+	// 注册表自身的排序反映了相关度
+	if total > 0 {
+		score += 10 * (1 - float64(position)/float64(total))
+	}
+
+	// 下载量按数量级加分，一百万次约为30分
+	if pkg.Downloads > 0 {
+		score += math.Min(30, 5*math.Log10(float64(pkg.Downloads)+1))
+	}
+
+	// 两年内有更新的包按新旧程度加分
+	if updated, err := time.Parse(time.RFC3339, pkg.UpdatedAt); err == nil {
+		days := time.Since(updated).Hours() / 24
+		score += 10 * math.Max(0, 1-days/730)
+	}
+
+	return math.Round(score*100) / 100
+}
+
+// rankPackages 按分数从高到低排序，分数相同时按名称排序
+func rankPackages(packages []PackageInfo) {
+	sort.SliceStable(packages, func(i, j int) bool {
+		if packages[i].Score != packages[j].Score {
+			return packages[i].Score > packages[j].Score
+		}
+		return packages[i].Name < packages[j].Name
+	})
+}
+
+// emitPackageSearchEvent 推送单个注册表的搜索结果
+func (a *App) emitPackageSearchEvent(event PackageSearchEvent) {
+	if a.ctx == nil {
+		return
+	}
+	wailsruntime.EventsEmit(a.ctx, packageSearchEventName, event)
+}
+
+// runPackageSearcher 在注册表的超时时间内执行搜索
+func (a *App) runPackageSearcher(searcher packageSearcher, query string) ([]PackageInfo, PackageSearchStatus) {
+	status := PackageSearchStatus{Manager: searcher.manager}
+	started := time.Now()
+
+	if searcher.command != "" && !commandExists(searcher.command) {
+		status.Skipped = true
+		status.Error = fmt.Sprintf("未找到命令 %s", searcher.command)
+		return []PackageInfo{}, status
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), searcher.timeout)
+	defer cancel()
+
+	type searchResult struct {
+		packages []PackageInfo
+		err      error
+	}
+	done := make(chan searchResult, 1)
+	go func() {
+		packages, err := searcher.search(ctx, query)
+		done <- searchResult{packages: packages, err: err}
+	}()
+
+	var packages []PackageInfo
+	select {
+	case result := <-done:
+		packages = result.packages
+		if result.err != nil {
+			status.Error = result.err.Error()
+			status.Unsupported = errors.Is(result.err, errKeywordSearchUnsupported)
+		}
+	case <-ctx.Done():
+		status.TimedOut = true
+		status.Error = fmt.Sprintf("超过 %v 未响应", searcher.timeout)
+	}
+	status.ElapsedMs = time.Since(started).Milliseconds()
+
+	if packages == nil {
+		packages = []PackageInfo{}
+	}
+	for i := range packages {
+		packages[i].Manager = searcher.manager
+		packages[i].Score = scorePackage(query, packages[i], i, len(packages))
+		a.addPackageLinks(&packages[i], searcher.manager)
+	}
+	status.Count = len(packages)
+
+	return packages, status
+}
+
+// SearchAllPackages 同时在多个注册表中搜索包，合并后按相关度排序
+// languages 为空时搜索所有注册表；每个注册表返回后通过 package:search 事件推送结果
+func (a *App) SearchAllPackages(query string, languages []string) PackageSearchResult {
+	query = strings.TrimSpace(query)
+	result := PackageSearchResult{
+		Query:      query,
+		Packages:   []PackageInfo{},
+		Registries: []PackageSearchStatus{},
+	}
+	if query == "" {
+		return result
+	}
+
+	searchers := selectPackageSearchers(a.packageSearchers(), languages)
+	fmt.Printf("在 %d 个注册表中搜索包: %s\n", len(searchers), query)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, searcher := range searchers {
+		wg.Add(1)
+		go func(searcher packageSearcher) {
+			defer wg.Done()
+
+			packages, status := a.runPackageSearcher(searcher, query)
+			a.emitPackageSearchEvent(PackageSearchEvent{Query: query, Status: status, Packages: packages})
+
+			mu.Lock()
+			result.Packages = append(result.Packages, packages...)
+			result.Registries = append(result.Registries, status)
+			mu.Unlock()
+		}(searcher)
+	}
+	wg.Wait()
+
+	rankPackages(result.Packages)
+	sort.Slice(result.Registries, func(i, j int) bool {
+		return result.Registries[i].Manager < result.Registries[j].Manager
+	})
+
+	return result
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parsedVersion 宽松解析后的版本号，兼容语义化版本、PEP 440、Maven等常见写法
+type parsedVersion struct {
+	// numbers 开头的数字部分，如 1.2.3 为 [1 2 3]
+	numbers []int
+	// suffix 数字之后的部分，如 rc1、beta.2、Final、post1
+	suffix string
+}
+
+// stableVersionSuffixes 不表示预发布的后缀，post 表示发布后的修订，比不带后缀的版本新
+var stableVersionSuffixes = []string{"final", "release", "ga", "post"}
+
+// parseVersion 解析版本号，不以数字开头（去掉v前缀后）时返回false
+func parseVersion(version string) (parsedVersion, bool) {
+	v := strings.TrimSpace(version)
+	v = strings.TrimPrefix(strings.TrimPrefix(v, "v"), "V")
+	if index := strings.Index(v, "+"); index >= 0 {
+		v = v[:index]
+	}
+
+	parsed := parsedVersion{}
+	for {
+		end := 0
+		for end < len(v) && v[end] >= '0' && v[end] <= '9' {
+			end++
+		}
+		if end == 0 {
+			break
+		}
+		number, err := strconv.Atoi(v[:end])
+		if err != nil {
+			break
+		}
+		parsed.numbers = append(parsed.numbers, number)
+		v = v[end:]
+		if len(v) > 1 && v[0] == '.' && v[1] >= '0' && v[1] <= '9' {
+			v = v[1:]
+			continue
+		}
+		break
+	}
+	if len(parsed.numbers) == 0 {
+		return parsed, false
+	}
+
+	parsed.suffix = strings.ToLower(strings.TrimLeft(v, ".-_"))
+	return parsed, true
+}
+
+// suffixRank 预发布为-1，正式版为0，发布后修订为1
+func (p parsedVersion) suffixRank() int {
+	if p.suffix == "" {
+		return 0
+	}
+	for _, stable := range stableVersionSuffixes {
+		if strings.HasPrefix(p.suffix, stable) {
+			if stable == "post" {
+				return 1
+			}
+			return 0
+		}
+	}
+	return -1
+}
+
+// isPrereleaseVersion 判断是否为预发布版本（alpha、beta、rc、SNAPSHOT等）
+func isPrereleaseVersion(version string) bool {
+	parsed, ok := parseVersion(version)
+	return ok && parsed.suffixRank() < 0
+}
+
+// compareVersions 比较两个版本号，a 较新时返回1，较旧时返回-1，相同返回0
+// 无法解析的版本号按字符串比较
+func compareVersions(a string, b string) int {
+	pa, okA := parseVersion(a)
+	pb, okB := parseVersion(b)
+	if !okA || !okB {
+		return strings.Compare(a, b)
+	}
+
+	for i := 0; i < len(pa.numbers) || i < len(pb.numbers); i++ {
+		na, nb := 0, 0
+		if i < len(pa.numbers) {
+			na = pa.numbers[i]
+		}
+		if i < len(pb.numbers) {
+			nb = pb.numbers[i]
+		}
+		if na != nb {
+			if na > nb {
+				return 1
+			}
+			return -1
+		}
+	}
+
+	if ra, rb := pa.suffixRank(), pb.suffixRank(); ra != rb {
+		if ra > rb {
+			return 1
+		}
+		return -1
+	}
+	return compareVersionSuffixes(pa.suffix, pb.suffix)
+}
+
+// splitVersionSuffix 按分隔符和字母、数字的交界处拆分后缀，如 rc.10 和 rc10 都拆成 [rc 10]
+func splitVersionSuffix(suffix string) []string {
+	parts := []string{}
+	current := ""
+	for _, r := range suffix {
+		if r == '.' || r == '-' || r == '_' {
+			if current != "" {
+				parts = append(parts, current)
+			}
+			current = ""
+			continue
+		}
+		if current != "" {
+			last := rune(current[len(current)-1])
+			if (last >= '0' && last <= '9') != (r >= '0' && r <= '9') {
+				parts = append(parts, current)
+				current = ""
+			}
+		}
+		current += string(r)
+	}
+	if current != "" {
+		parts = append(parts, current)
+	}
+	return parts
+}
+
+// compareVersionSuffixes 分段比较后缀，数字段按数值比较
+func compareVersionSuffixes(a string, b string) int {
+	partsA, partsB := splitVersionSuffix(a), splitVersionSuffix(b)
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		na, errA := strconv.Atoi(partsA[i])
+		nb, errB := strconv.Atoi(partsB[i])
+		var result int
+		if errA == nil && errB == nil {
+			result = na - nb
+		} else {
+			result = strings.Compare(partsA[i], partsB[i])
+		}
+		if result > 0 {
+			return 1
+		}
+		if result < 0 {
+			return -1
+		}
+	}
+	switch {
+	case len(partsA) > len(partsB):
+		return 1
+	case len(partsA) < len(partsB):
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SQLConnectionProfile 存储一个具名的数据库连接配置
+type SQLConnectionProfile struct {
+	Name     string `json:"name"`
+	Engine   string `json:"engine"` // mysql, postgres, sqlite, sqlserver
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	User     string `json:"user"`
+	Password string `json:"password,omitempty"` // 保存时传入的新密码，返回给前端的配置中始终为空
+	Database string `json:"database"`
+	FilePath string `json:"filePath"` // 仅用于SQLite
+	// HasPassword 是否已保存密码，保存时 Password 为空且 HasPassword 为 true 表示保留原密码
+	HasPassword bool `json:"hasPassword"`
+}
+
+// SQLIntrospectionResult 存储服务器内省结果
+type SQLIntrospectionResult struct {
+	Profile       string        `json:"profile"`
+	Engine        string        `json:"engine"`
+	ServerVersion string        `json:"serverVersion"`
+	Extensions    []PackageInfo `json:"extensions"`
+	Success       bool          `json:"success"`
+	Error         string        `json:"error"`
+}
+
+// sqlQueryTimeout 单次查询的超时时间
+const sqlQueryTimeout = 10 * time.Second
+
+// IntrospectSQLServer 使用具名连接配置查询数据库服务器的版本和扩展/插件
+// 这是需要用户显式触发的操作，DetectLanguages 不会调用它。
+// 只执行内置的只读目录查询，并在会话级别启用只读模式。
+func (a *App) IntrospectSQLServer(profileName string) SQLIntrospectionResult {
+	result := SQLIntrospectionResult{
+		Profile: profileName,
+	}
+
+	profile, err := a.findDatabaseProfile(profileName)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Engine = profile.Engine
+
+	versionQuery, extensionQuery := sqlIntrospectionQueries(profile.Engine)
+	if versionQuery == "" {
+		result.Error = "不支持的数据库类型: " + profile.Engine
+		return result
+	}
+
+	rows, err := runReadOnlySQLQuery(profile, versionQuery)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if len(rows) > 0 && len(rows[0]) > 0 {
+		result.ServerVersion = rows[0][0]
+	}
+
+	if extensionQuery != "" {
+		rows, err = runReadOnlySQLQuery(profile, extensionQuery)
+		if err != nil {
+			result.Error = "获取扩展失败: " + err.Error()
+			return result
+		}
+
+		for _, row := range rows {
+			pkg := PackageInfo{Name: row[0], Installed: true}
+			if len(row) > 1 {
+				pkg.Version = row[1]
+			}
+			if len(row) > 2 {
+				pkg.Installed = row[2] != "" && row[2] != "DISABLED"
+				pkg.Description = row[2]
+			}
+			result.Extensions = append(result.Extensions, pkg)
+		}
+	}
+
+	result.Success = true
+	return result
+}
+
+// sqlIntrospectionQueries 返回各数据库用于获取版本和扩展的只读查询
+func sqlIntrospectionQueries(engine string) (string, string) {
+	switch engine {
+	case "postgres":
+		return "SHOW server_version",
+			"SELECT name, default_version, coalesce(installed_version, '') FROM pg_available_extensions ORDER BY name"
+	case "mysql":
+		return "SELECT VERSION()",
+			"SELECT PLUGIN_NAME, PLUGIN_VERSION, PLUGIN_STATUS FROM information_schema.PLUGINS ORDER BY PLUGIN_NAME"
+	case "sqlite":
+		return "SELECT sqlite_version()",
+			"SELECT compile_options FROM pragma_compile_options"
+	case "sqlserver":
+		return "SET NOCOUNT ON; SELECT CAST(SERVERPROPERTY('ProductVersion') AS nvarchar(128))", ""
+	}
+	return "", ""
+}
+
+// runReadOnlySQLQuery 通过本地客户端以只读方式执行查询，返回按制表符分隔的行
+func runReadOnlySQLQuery(profile SQLConnectionProfile, query string) ([][]string, error) {
+	command, args, env, err := readOnlySQLCommand(profile, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if !commandExists(command) {
+		return nil, fmt.Errorf("未找到数据库客户端: %s", command)
+	}
+
+	output, err := executeCommandWithEnv(sqlQueryTimeout, env, command, args...)
+	if err != nil {
+		if output != "" {
+			return nil, fmt.Errorf("%s", output)
+		}
+		return nil, err
+	}
+
+	var rows [][]string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		rows = append(rows, strings.Split(line, "\t"))
+	}
+
+	return rows, nil
+}
+
+// readOnlySQLCommand 生成以只读方式执行查询的客户端命令、参数和额外的环境变量
+// 所有客户端均以非交互模式运行，不读取用户默认配置，密码只通过环境变量传递，不出现在命令行中。
+func readOnlySQLCommand(profile SQLConnectionProfile, query string) (command string, args []string, env []string, err error) {
+	switch profile.Engine {
+	case "postgres":
+		command = "psql"
+		// -X 不读取psqlrc，-w 从不提示输入密码
+		args = []string{"-X", "-w", "-A", "-t", "-F", "\t", "-v", "ON_ERROR_STOP=1"}
+		if profile.Host != "" {
+			args = append(args, "-h", profile.Host)
+		}
+		if profile.Port > 0 {
+			args = append(args, "-p", strconv.Itoa(profile.Port))
+		}
+		if profile.User != "" {
+			args = append(args, "-U", profile.User)
+		}
+		if profile.Database != "" {
+			args = append(args, "-d", profile.Database)
+		}
+		args = append(args, "-c", query)
+		env = []string{
+			"PGOPTIONS=-c default_transaction_read_only=on",
+			"PGCONNECT_TIMEOUT=5",
+			"PGPASSWORD=" + profile.Password,
+		}
+	case "mysql":
+		command = "mysql"
+		// --no-defaults 必须是第一个参数，避免使用my.cnf中的默认服务器
+		args = []string{"--no-defaults", "--batch", "--skip-column-names", "--connect-timeout=5"}
+		if profile.Host != "" {
+			args = append(args, "--host="+profile.Host)
+		}
+		if profile.Port > 0 {
+			args = append(args, "--port="+strconv.Itoa(profile.Port))
+		}
+		if profile.User != "" {
+			args = append(args, "--user="+profile.User)
+		}
+		if profile.Database != "" {
+			args = append(args, "--database="+profile.Database)
+		}
+		args = append(args, "--execute=SET SESSION TRANSACTION READ ONLY; "+query)
+		env = []string{"MYSQL_PWD=" + profile.Password}
+	case "sqlite":
+		if profile.FilePath == "" {
+			return "", nil, nil, fmt.Errorf("SQLite连接配置缺少数据库文件路径")
+		}
+		command = "sqlite3"
+		args = []string{"-readonly", "-batch", "-noheader", "-separator", "\t", profile.FilePath, query}
+	case "sqlserver":
+		command = "sqlcmd"
+		server := profile.Host
+		if server == "" {
+			server = "localhost"
+		}
+		if profile.Port > 0 {
+			server += "," + strconv.Itoa(profile.Port)
+		}
+		// -K ReadOnly 声明只读意图，-l 设置登录超时，-b 出错时返回非零退出码
+		args = []string{"-S", server, "-K", "ReadOnly", "-l", "5", "-b", "-h", "-1", "-W", "-s", "\t"}
+		if profile.User != "" {
+			args = append(args, "-U", profile.User)
+			env = []string{"SQLCMDPASSWORD=" + profile.Password}
+		} else {
+			args = append(args, "-E")
+		}
+		if profile.Database != "" {
+			args = append(args, "-d", profile.Database)
+		}
+		args = append(args, "-Q", query)
+	default:
+		return "", nil, nil, fmt.Errorf("不支持的数据库类型: %s", profile.Engine)
+	}
+
+	return command, args, env, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// containsArgs 判断 args 中是否按顺序连续出现 expected
+func containsArgs(args []string, expected ...string) bool {
+	for i := 0; i+len(expected) <= len(args); i++ {
+		match := true
+		for j, arg := range expected {
+			if args[i+j] != arg {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+func TestReadOnlySQLCommand(t *testing.T) {
+	const password = "p@ss w0rd;--"
+	cases := []struct {
+		name     string
+		profile  SQLConnectionProfile
+		command  string
+		args     [][]string
+		first    string
+		env      []string
+		lastArg  string
+		password bool
+	}{
+		{
+			name:    "postgres",
+			profile: SQLConnectionProfile{Engine: "postgres", Host: "db.local", Port: 5433, User: "app", Password: password, Database: "shop"},
+			command: "psql",
+			args:    [][]string{{"-X"}, {"-w"}, {"-v", "ON_ERROR_STOP=1"}, {"-h", "db.local"}, {"-p", "5433"}, {"-U", "app"}, {"-d", "shop"}},
+			first:   "-X",
+			env:     []string{"PGOPTIONS=-c default_transaction_read_only=on", "PGCONNECT_TIMEOUT=5", "PGPASSWORD=" + password},
+			lastArg: "SELECT 1",
+		},
+		{
+			name:    "postgres without password",
+			profile: SQLConnectionProfile{Engine: "postgres", User: "app"},
+			command: "psql",
+			args:    [][]string{{"-X"}, {"-w"}, {"-U", "app"}},
+			first:   "-X",
+			env:     []string{"PGOPTIONS=-c default_transaction_read_only=on"},
+			lastArg: "SELECT 1",
+		},
+		{
+			name:    "mysql",
+			profile: SQLConnectionProfile{Engine: "mysql", Host: "db.local", Port: 3307, User: "root", Password: password, Database: "shop"},
+			command: "mysql",
+			args:    [][]string{{"--batch"}, {"--connect-timeout=5"}, {"--host=db.local"}, {"--port=3307"}, {"--user=root"}, {"--database=shop"}},
+			// --no-defaults 必须是第一个参数才会生效
+			first:   "--no-defaults",
+			env:     []string{"MYSQL_PWD=" + password},
+			lastArg: "--execute=SET SESSION TRANSACTION READ ONLY; SELECT 1",
+		},
+	}
+
+	for _, c := range cases {
+		command, args, env, err := readOnlySQLCommand(c.profile, "SELECT 1")
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if command != c.command {
+			t.Errorf("%s: 命令应为 %s，实际 %s", c.name, c.command, command)
+		}
+		if len(args) == 0 || args[0] != c.first {
+			t.Errorf("%s: 第一个参数应为 %s: %q", c.name, c.first, args)
+		}
+		if args[len(args)-1] != c.lastArg {
+			t.Errorf("%s: 最后一个参数应为查询 %q: %q", c.name, c.lastArg, args)
+		}
+		for _, expected := range c.args {
+			if !containsArgs(args, expected...) {
+				t.Errorf("%s: 缺少参数 %q: %q", c.name, expected, args)
+			}
+		}
+		for _, expected := range c.env {
+			if !containsArgs(env, expected) {
+				t.Errorf("%s: 缺少环境变量 %q: %q", c.name, expected, env)
+			}
+		}
+
+		// 密码只能通过环境变量传递，命令行参数对同一台机器上的其他用户可见
+		for _, arg := range args {
+			if c.profile.Password != "" && strings.Contains(arg, c.profile.Password) {
+				t.Errorf("%s: 密码出现在命令行参数中: %q", c.name, arg)
+			}
+			if strings.HasPrefix(arg, "--password") || arg == "-W" {
+				t.Errorf("%s: 不应使用命令行密码参数: %q", c.name, arg)
+			}
+		}
+	}
+}
+
+func TestReadOnlySQLCommandRejectsUnknownEngine(t *testing.T) {
+	if _, _, _, err := readOnlySQLCommand(SQLConnectionProfile{Engine: "oracle"}, "SELECT 1"); err == nil {
+		t.Error("不支持的数据库类型应返回错误")
+	}
+	if _, _, _, err := readOnlySQLCommand(SQLConnectionProfile{Engine: "sqlite"}, "SELECT 1"); err == nil {
+		t.Error("SQLite缺少文件路径时应返回错误")
+	}
+}